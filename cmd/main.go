@@ -1,45 +1,21 @@
 package main
 
 import (
-	"os"
-	"os/signal"
-	"syscall"
+	// embed timezones for subscriber quiet hours, production image has no tzdata
+	_ "time/tzdata"
 
 	server "github.com/WildEgor/gNotifier/internal"
 	log "github.com/sirupsen/logrus"
 )
 
 func main() {
-	Start()
-	Shutdown()
-}
-
-func Start() {
-	srv, _ := server.NewServer()
-	log.Printf("Server is listening on PORT: %s", srv.AppConfig.Port)
-
-	addr := ":" + srv.AppConfig.Port
-
-	if err := srv.App.Listen(addr); err != nil {
-		log.Panicf("[CRIT] Unable to start server. Reason: %v", err)
+	srv, err := server.NewServer()
+	if err != nil {
+		log.Fatalf("[Main] Unable to build server. Reason: %v", err)
 	}
-}
 
-func Shutdown() {
-	// block main thread - wait for shutdown signal
-	sigs := make(chan os.Signal, 1)
-	done := make(chan bool, 1)
-
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-	go func() {
-		sig := <-sigs
-		log.Println()
-		log.Println(sig)
-		done <- true
-	}()
-
-	log.Println("[Main] Awaiting signal")
-	<-done
-	log.Println("[Main] Stopping consumer")
+	// Run blocks till SIGINT/SIGTERM and returns after notifications in progress are drained
+	if err := srv.Run(); err != nil {
+		log.Fatalf("[CRIT] Unable to start server. Reason: %v", err)
+	}
 }
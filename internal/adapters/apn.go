@@ -22,6 +22,7 @@ import (
 	"golang.org/x/net/http2"
 
 	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
 )
 
 var (
@@ -157,6 +158,7 @@ func NewAPNAdapter(
 
 	doOnce.Do(func() {
 		maxConcurrentIOSPushes = make(chan struct{}, 5) // WARN!
+		metrics.RegisterSlots(domain.ProviderAPNs, maxConcurrentIOSPushes)
 	})
 
 	return &APNAdapter{
@@ -408,6 +410,13 @@ func (s *APNAdapter) getApnsClient(req *domain.PushNotification) (client *apns2.
 	return client
 }
 
+// Close release HTTP/2 connections to APNs server, pushes in progress should be finished before
+func (s *APNAdapter) Close() {
+	if s.client != nil && s.client.HTTPClient != nil {
+		s.client.HTTPClient.CloseIdleConnections()
+	}
+}
+
 // Send provide send notification to APNs server.
 func (s *APNAdapter) Send(req *domain.PushNotification) (err error) {
 	log.Debug("Start push notification for iOS")
@@ -421,14 +430,15 @@ func (s *APNAdapter) Send(req *domain.PushNotification) (err error) {
 		maxRetry = req.Retry
 	}
 
-	s.retry(req, retryCount)
-
-	return err
+	return s.retry(req, retryCount, maxRetry)
 }
 
-func (s *APNAdapter) retry(req *domain.PushNotification, retryCount int) int {
-	var newTokens []string
-	var maxRetry = 5
+func (s *APNAdapter) retry(req *domain.PushNotification, retryCount, maxRetry int) error {
+	var (
+		mu          sync.Mutex
+		newTokens   []string
+		rateLimited bool
+	)
 
 	notification := ConvertToIOSNotification(req)
 	client := s.getApnsClient(req)
@@ -455,8 +465,11 @@ func (s *APNAdapter) retry(req *domain.PushNotification, retryCount int) int {
 
 				// We should retry only "retryable" statuses. More info about response:
 				// See https://apple.co/3AdNane (Handling Notification Responses from APNs)
-				if res != nil && res.StatusCode >= http.StatusInternalServerError {
+				if res != nil && (res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests) {
+					mu.Lock()
 					newTokens = append(newTokens, token)
+					rateLimited = rateLimited || res.StatusCode == http.StatusTooManyRequests
+					mu.Unlock()
 				}
 			}
 
@@ -472,26 +485,40 @@ func (s *APNAdapter) retry(req *domain.PushNotification, retryCount int) int {
 
 	wg.Wait()
 
+	// APNs does not send Retry-After, so throttler penalty is applied
+	var limitErr error
+	if rateLimited {
+		limitErr = &domain.RateLimitError{Provider: domain.ProviderAPNs}
+	}
+
 	if len(newTokens) > 0 && retryCount < maxRetry {
 		retryCount++
+		metrics.Retried(domain.ProviderAPNs, limitErr)
+		if rateLimited {
+			time.Sleep(retryDelay(retryCount, limitErr))
+		}
 
 		// resend fail token
 		req.Tokens = newTokens
-		s.retry(req, retryCount)
+		return s.retry(req, retryCount, maxRetry)
 	}
 
-	return retryCount
+	return limitErr
 }
 
 // TODO: send logs to storage
-func (s *APNAdapter) saveLogs(status, token string, req *domain.PushNotification, err error) error {
-	log.Error(map[string]interface{}{
-		"ID":       req.ID,
-		"Status":   status,
-		"Token":    token,
-		"Message":  req.Message,
-		"Platform": req.Platform,
-		"Error":    err,
+func (s *APNAdapter) saveLogs(status, token string, req *domain.PushNotification, err error) {
+	entry := log.WithContext(req.GetContext()).WithFields(log.Fields{
+		"notif_id": req.ID,
+		"status":   status,
+		"token":    token,
+		"platform": req.Platform,
 	})
-	return nil
+
+	if err != nil {
+		entry.WithError(err).Error("[APNAdapter] push failed")
+		return
+	}
+
+	entry.Debug("[APNAdapter] push sent")
 }
@@ -0,0 +1,288 @@
+package adapters
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// Circuit states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitBreaker stops calling provider after consecutive failures, so messages are not waiting for timeouts.
+// After open period few probe requests let through and circuit closed if they succeeded
+type CircuitBreaker struct {
+	name   string
+	config *configs.BreakerConfig
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+func newCircuitBreaker(name string, config *configs.BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:   name,
+		config: config,
+		state:  CircuitClosed,
+	}
+}
+
+// Execute call provider if circuit allows it and count result
+func (b *CircuitBreaker) Execute(call func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := call()
+	b.report(err)
+
+	return err
+}
+
+// Available check provider could be called right now (circuit closed or ready to probe)
+func (b *CircuitBreaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		return time.Since(b.openedAt) >= b.config.OpenDuration
+	case CircuitHalfOpen:
+		return b.probes < b.config.HalfOpenProbes
+	}
+
+	return true
+}
+
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if left := b.config.OpenDuration - time.Since(b.openedAt); left > 0 {
+			return &domain.CircuitOpenError{Provider: b.name, RetryAfter: left}
+		}
+
+		log.Infof("[CircuitBreaker] %s half-open, probing", b.name)
+		b.state = CircuitHalfOpen
+		b.probes = 0
+		b.successes = 0
+	}
+
+	if b.state == CircuitHalfOpen {
+		if b.probes >= b.config.HalfOpenProbes {
+			return &domain.CircuitOpenError{Provider: b.name, RetryAfter: time.Second}
+		}
+		b.probes++
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) report(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := isProviderFailure(err)
+
+	// provider was not called for invalid notification, so it tells nothing about provider
+	var invalid *domain.ValidationError
+	if errors.As(err, &invalid) {
+		if b.state == CircuitHalfOpen {
+			b.probes--
+		}
+		return
+	}
+
+	switch b.state {
+	case CircuitHalfOpen:
+		b.probes--
+		if failed {
+			b.trip()
+			return
+		}
+
+		b.successes++
+		if b.successes >= b.config.HalfOpenProbes {
+			log.Infof("[CircuitBreaker] %s closed", b.name)
+			b.state = CircuitClosed
+			b.failures = 0
+		}
+	case CircuitClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.trip()
+		}
+	}
+}
+
+func (b *CircuitBreaker) trip() {
+	log.Warnf("[CircuitBreaker] %s opened for %s", b.name, b.config.OpenDuration)
+	b.state = CircuitOpen
+	b.openedAt = time.Now()
+	b.failures = 0
+}
+
+// isProviderFailure check error means provider is down. Invalid tokens and throttling are answered by working provider,
+// but delivery to the rest of tokens could fail as well
+func isProviderFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var (
+		tokens      *domain.InvalidTokensError
+		rateLimited *domain.RateLimitError
+	)
+
+	if errors.As(err, &tokens) {
+		return isProviderFailure(tokens.Err)
+	}
+
+	return !errors.As(err, &rateLimited)
+}
+
+// CircuitBreakers holds breaker of every provider
+type CircuitBreakers struct {
+	config *configs.BreakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+func NewCircuitBreakers(config *configs.BreakerConfig) *CircuitBreakers {
+	return &CircuitBreakers{
+		config:   config,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns breaker of provider, created on first use
+func (c *CircuitBreakers) Get(name string) *CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[name]
+	if !ok {
+		b = newCircuitBreaker(name, c.config)
+		c.breakers[name] = b
+	}
+
+	return b
+}
+
+// Open returns names of providers with open circuit
+func (c *CircuitBreakers) Open() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var open []string
+	for name, b := range c.breakers {
+		if b.State() != CircuitClosed {
+			open = append(open, name)
+		}
+	}
+	sort.Strings(open)
+
+	return open
+}
+
+// pushBreaker wraps FCM, APNs and HMS adapters with circuit breaker
+type pushBreaker struct {
+	adapter interface {
+		Send(req *domain.PushNotification) error
+	}
+	breaker *CircuitBreaker
+}
+
+func (b *pushBreaker) Send(req *domain.PushNotification) error {
+	return b.breaker.Execute(func() error { return b.adapter.Send(req) })
+}
+
+func (b *pushBreaker) Available() bool {
+	return b.breaker.Available()
+}
+
+type webPushBreaker struct {
+	adapter IWebPushAdapter
+	breaker *CircuitBreaker
+}
+
+func (b *webPushBreaker) Send(req *domain.WebPushNotification) error {
+	return b.breaker.Execute(func() error { return b.adapter.Send(req) })
+}
+
+func (b *webPushBreaker) Available() bool {
+	return b.breaker.Available()
+}
+
+type smsBreaker struct {
+	adapter ISMSAdapter
+	breaker *CircuitBreaker
+}
+
+func (b *smsBreaker) Send(req *domain.SMSNotification) error {
+	return b.breaker.Execute(func() error { return b.adapter.Send(req) })
+}
+
+func (b *smsBreaker) Available() bool {
+	return b.breaker.Available()
+}
+
+type smtpBreaker struct {
+	adapter ISMTPAdapter
+	breaker *CircuitBreaker
+}
+
+func (b *smtpBreaker) Send(req *domain.EmailNotification) error {
+	return b.breaker.Execute(func() error { return b.adapter.Send(req) })
+}
+
+func (b *smtpBreaker) Available() bool {
+	return b.breaker.Available()
+}
+
+// NewFCMBreaker wraps FCM adapter with circuit breaker
+func NewFCMBreaker(adapter *FCMAdapter, breakers *CircuitBreakers) IFCMAdapter {
+	return &pushBreaker{adapter: adapter, breaker: breakers.Get(domain.ProviderFCM)}
+}
+
+// NewAPNBreaker wraps APNs adapter with circuit breaker
+func NewAPNBreaker(adapter *APNAdapter, breakers *CircuitBreakers) IAPNAdapter {
+	return &pushBreaker{adapter: adapter, breaker: breakers.Get(domain.ProviderAPNs)}
+}
+
+// NewHMSBreaker wraps HMS adapter with circuit breaker
+func NewHMSBreaker(adapter *HMSAdapter, breakers *CircuitBreakers) IHMSAdapter {
+	return &pushBreaker{adapter: adapter, breaker: breakers.Get(domain.ProviderHMS)}
+}
+
+// NewWebPushBreaker wraps web push adapter with circuit breaker
+func NewWebPushBreaker(adapter *WebPushAdapter, breakers *CircuitBreakers) IWebPushAdapter {
+	return &webPushBreaker{adapter: adapter, breaker: breakers.Get(domain.ProviderWebPush)}
+}
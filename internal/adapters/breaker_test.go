@@ -0,0 +1,182 @@
+package adapters
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	var (
+		errDown    = errors.New("provider is down")
+		errInvalid = &domain.ValidationError{Message: "invalid"}
+		errTokens  = &domain.InvalidTokensError{Tokens: []string{"t1"}}
+		errLimited = &domain.RateLimitError{Provider: "test"}
+		// some tokens are invalid while provider failed to deliver to the rest
+		errTokensDown = &domain.InvalidTokensError{Tokens: []string{"t1"}, Err: errDown}
+	)
+
+	type step struct {
+		// elapse open period before call
+		elapse bool
+		err    error
+		// rejected call is not passed to provider because circuit is open
+		rejected bool
+		state    string
+	}
+
+	tests := []struct {
+		name   string
+		probes int
+		steps  []step
+	}{
+		{
+			name: "opens after consecutive failures",
+			steps: []step{
+				{err: errDown, state: CircuitClosed},
+				{err: errDown, state: CircuitClosed},
+				{err: errDown, state: CircuitOpen},
+				{rejected: true, state: CircuitOpen},
+			},
+		},
+		{
+			name: "success resets failures",
+			steps: []step{
+				{err: errDown, state: CircuitClosed},
+				{err: errDown, state: CircuitClosed},
+				{state: CircuitClosed},
+				{err: errDown, state: CircuitClosed},
+				{err: errDown, state: CircuitClosed},
+			},
+		},
+		{
+			name: "provider answers are not failures",
+			steps: []step{
+				{err: errDown, state: CircuitClosed},
+				{err: errDown, state: CircuitClosed},
+				{err: errTokens, state: CircuitClosed},
+				{err: errLimited, state: CircuitClosed},
+				{err: errInvalid, state: CircuitClosed},
+			},
+		},
+		{
+			name: "invalid tokens with provider failure",
+			steps: []step{
+				{err: errDown, state: CircuitClosed},
+				{err: errDown, state: CircuitClosed},
+				{err: errTokensDown, state: CircuitOpen},
+			},
+		},
+		{
+			name: "probe success closes",
+			steps: []step{
+				{err: errDown}, {err: errDown}, {err: errDown, state: CircuitOpen},
+				{elapse: true, state: CircuitClosed},
+				{err: errDown, state: CircuitClosed},
+			},
+		},
+		{
+			name: "probe failure opens again",
+			steps: []step{
+				{err: errDown}, {err: errDown}, {err: errDown, state: CircuitOpen},
+				{elapse: true, err: errDown, state: CircuitOpen},
+				{rejected: true, state: CircuitOpen},
+			},
+		},
+		{
+			name:   "every probe must succeed",
+			probes: 2,
+			steps: []step{
+				{err: errDown}, {err: errDown}, {err: errDown, state: CircuitOpen},
+				{elapse: true, state: CircuitHalfOpen},
+				{state: CircuitClosed},
+			},
+		},
+		{
+			name: "invalid notification is not a probe result",
+			steps: []step{
+				{err: errDown}, {err: errDown}, {err: errDown, state: CircuitOpen},
+				{elapse: true, err: errInvalid, state: CircuitHalfOpen},
+				{state: CircuitClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probes := tt.probes
+			if probes == 0 {
+				probes = 1
+			}
+
+			b := newCircuitBreaker("test", &configs.BreakerConfig{
+				FailureThreshold: 3,
+				OpenDuration:     time.Minute,
+				HalfOpenProbes:   probes,
+			})
+
+			for i, s := range tt.steps {
+				if s.elapse {
+					b.mu.Lock()
+					b.openedAt = b.openedAt.Add(-time.Minute)
+					b.mu.Unlock()
+				}
+
+				called := false
+				err := b.Execute(func() error {
+					called = true
+					return s.err
+				})
+
+				if called == s.rejected {
+					t.Fatalf("step %d: called = %v, want %v", i, called, !s.rejected)
+				}
+
+				var open *domain.CircuitOpenError
+				if s.rejected && !errors.As(err, &open) {
+					t.Fatalf("step %d: err = %v, want circuit open error", i, err)
+				}
+
+				if s.state != "" && b.State() != s.state {
+					t.Fatalf("step %d: state = %s, want %s", i, b.State(), s.state)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerAvailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		openedAt time.Duration
+		probes   int
+		want     bool
+	}{
+		{name: "closed", state: CircuitClosed, want: true},
+		{name: "open", state: CircuitOpen, openedAt: -time.Second, want: false},
+		{name: "open period over", state: CircuitOpen, openedAt: -2 * time.Minute, want: true},
+		{name: "half-open with free probe", state: CircuitHalfOpen, probes: 0, want: true},
+		{name: "half-open probes taken", state: CircuitHalfOpen, probes: 1, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker("test", &configs.BreakerConfig{
+				FailureThreshold: 3,
+				OpenDuration:     time.Minute,
+				HalfOpenProbes:   1,
+			})
+			b.state = tt.state
+			b.openedAt = time.Now().Add(tt.openedAt)
+			b.probes = tt.probes
+
+			if got := b.Available(); got != tt.want {
+				t.Fatalf("Available() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,16 @@ package adapters
 
 import (
 	"errors"
-	"fmt"
 	"github.com/WildEgor/gNotifier/internal/configs"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
 	"github.com/appleboy/go-fcm"
 )
 
@@ -117,9 +120,7 @@ func (f *FCMAdapter) Send(push *domain.PushNotification) (err error) {
 		return
 	}
 
-	f.retry(push, retryCount)
-
-	return err
+	return f.retry(push, retryCount)
 }
 
 func (f *FCMAdapter) retry(push *domain.PushNotification, retryCount int) error {
@@ -136,6 +137,11 @@ func (f *FCMAdapter) retry(push *domain.PushNotification, retryCount int) error
 		// Send Message error
 		log.Println("[FCMAdapter] FCM server send message error: " + err.Error())
 
+		// go-fcm reports non 2xx status as "<code> error: <status>" and drops Retry-After
+		if strings.HasPrefix(err.Error(), strconv.Itoa(http.StatusTooManyRequests)+" ") {
+			err = &domain.RateLimitError{Provider: domain.ProviderFCM}
+		}
+
 		// Save logs depends on topic or tokens provided
 		if push.IsTopic() {
 			f.saveLogs("fail_push", push.To, push, err)
@@ -148,10 +154,11 @@ func (f *FCMAdapter) retry(push *domain.PushNotification, retryCount int) error
 	}
 
 	if !push.IsTopic() {
-		log.Debugln(fmt.Sprintf("Android Success count: %d, Failure count: %d", res.Success, res.Failure))
+		log.WithContext(push.GetContext()).Debugf("[FCMAdapter] Android success count: %d, failure count: %d", res.Success, res.Failure)
 	}
 
 	var newTokens []string
+	rateLimited := false
 	// result from Send messages to specific devices
 	for k, result := range res.Results {
 		to := ""
@@ -168,6 +175,10 @@ func (f *FCMAdapter) retry(push *domain.PushNotification, retryCount int) error
 				newTokens = append(newTokens, to)
 			}
 
+			if errors.Is(result.Error, fcm.ErrDeviceMessageRateExceeded) || errors.Is(result.Error, fcm.ErrTopicsMessageRateExceeded) {
+				rateLimited = true
+			}
+
 			f.saveLogs("fail_push", to, push, result.Error)
 			continue
 		}
@@ -190,6 +201,9 @@ func (f *FCMAdapter) retry(push *domain.PushNotification, retryCount int) error
 		} else {
 			// failure
 			f.saveLogs("fail_push", to, push, res.Error)
+			if errors.Is(res.Error, fcm.ErrTopicsMessageRateExceeded) {
+				rateLimited = true
+			}
 		}
 	}
 
@@ -199,25 +213,38 @@ func (f *FCMAdapter) retry(push *domain.PushNotification, retryCount int) error
 		f.saveLogs("fail_push", notification.To, push, errors.New("device group: partial success or all fails"))
 	}
 
+	var limitErr error
+	if rateLimited {
+		limitErr = &domain.RateLimitError{Provider: domain.ProviderFCM}
+	}
+
 	if len(newTokens) > 0 && retryCount < maxRetry {
 		retryCount++
+		metrics.Retried(domain.ProviderFCM, limitErr)
+		if rateLimited {
+			time.Sleep(retryDelay(retryCount, limitErr))
+		}
 		// resend fail token
 		push.Tokens = newTokens
-		f.retry(push, retryCount)
+		return f.retry(push, retryCount)
 	}
 
-	return nil
+	return limitErr
 }
 
 // TODO: save logs to storage
-func (f *FCMAdapter) saveLogs(status, token string, req *domain.PushNotification, err error) error {
-	log.Error(map[string]interface{}{
-		"ID":       req.ID,
-		"Status":   status,
-		"Token":    token,
-		"Message":  req.Message,
-		"Platform": req.Platform,
-		"Error":    err,
+func (f *FCMAdapter) saveLogs(status, token string, req *domain.PushNotification, err error) {
+	entry := log.WithContext(req.GetContext()).WithFields(log.Fields{
+		"notif_id": req.ID,
+		"status":   status,
+		"token":    token,
+		"platform": req.Platform,
 	})
-	return nil
+
+	if err != nil {
+		entry.WithError(err).Error("[FCMAdapter] push failed")
+		return
+	}
+
+	entry.Debug("[FCMAdapter] push sent")
 }
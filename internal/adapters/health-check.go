@@ -7,12 +7,15 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	log "github.com/sirupsen/logrus"
 )
 
 type Status string
 
-// Option is the health-container options type
-type Option func(*HealthCheckAdapter) error
+// errCheckTimeout recorded when check not finished within its timeout
+var errCheckTimeout = errors.New(string(StatusTimeout))
 
 // Status aliases
 const (
@@ -20,6 +23,7 @@ const (
 	StatusPartiallyAvailable Status = "Partially Available"
 	StatusUnavailable        Status = "Unavailable"
 	StatusTimeout            Status = "Timeout during health check"
+	StatusPending            Status = "Pending"
 )
 
 type (
@@ -44,11 +48,30 @@ type (
 		Timestamp time.Time `json:"timestamp"`
 		// Failures holds the failed checks along with their messages.
 		Failures map[string]string `json:"failures,omitempty"`
+		// Checks holds the last result and history of every check.
+		Checks map[string]CheckState `json:"checks,omitempty"`
 		// System holds information of the go process.
 		*SystemInfo `json:"system,omitempty"`
 		// Component holds information on the component for which checks are made
 		ComponentInfo `json:"component"`
 	}
+	// CheckState is the cached result of the check along with its history.
+	CheckState struct {
+		// Status is StatusOK, StatusUnavailable, StatusTimeout or StatusPending if check not run yet.
+		Status Status `json:"status"`
+		// Error is the message of the last failure.
+		Error string `json:"error,omitempty"`
+		// SkipOnErr is the check policy, failure only makes service partially available.
+		SkipOnErr bool `json:"skip_on_err"`
+		// CheckedAt is the time of the last run.
+		CheckedAt *time.Time `json:"checked_at,omitempty"`
+		// LastSuccessAt is the time of the last successful run.
+		LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+		// ConsecutiveFailures is the number of failed runs since the last successful one.
+		ConsecutiveFailures int `json:"consecutive_failures"`
+		// LatencyMs is the duration of the last run in milliseconds.
+		LatencyMs int64 `json:"latency_ms"`
+	}
 	// SystemInfo runtime variables about the go process.
 	SystemInfo struct {
 		// Version is the go version.
@@ -69,22 +92,39 @@ type (
 		// Version is the component version.
 		Version string `json:"version"`
 	}
-	// HealthCheckAdapter is the health-checks container
+	// HealthCheckAdapter is the health-checks container. Checks are run periodically in background,
+	// requests are served from cached results
 	HealthCheckAdapter struct {
-		mu                  sync.Mutex
-		checks              map[string]HealthConfig
-		maxConcurrent       int
-		instrumentationName string
-		component           ComponentInfo
-		systemInfoEnabled   bool
+		mu                sync.RWMutex
+		checks            map[string]HealthConfig
+		states            map[string]*CheckState
+		gates             map[string]bool
+		interval          time.Duration
+		maxConcurrent     int
+		component         ComponentInfo
+		systemInfoEnabled bool
+		breakers          *CircuitBreakers
+
+		stop      chan struct{}
+		wg        sync.WaitGroup
+		startOnce sync.Once
+		closeOnce sync.Once
 	}
 )
 
 // New instantiates and build new health check container
-func NewHealthCheckAdapter() (*HealthCheckAdapter, error) {
+func NewHealthCheckAdapter(
+	healthConfig *configs.HealthConfig,
+	breakers *CircuitBreakers,
+) (*HealthCheckAdapter, error) {
 	h := &HealthCheckAdapter{
 		checks:        make(map[string]HealthConfig),
+		states:        make(map[string]*CheckState),
+		gates:         make(map[string]bool),
+		interval:      healthConfig.Interval,
 		maxConcurrent: runtime.NumCPU(),
+		breakers:      breakers,
+		stop:          make(chan struct{}),
 	}
 
 	return h, nil
@@ -108,29 +148,154 @@ func (h *HealthCheckAdapter) Register(c HealthConfig) error {
 	}
 
 	h.checks[c.Name] = c
+	h.states[c.Name] = &CheckState{
+		Status:    StatusPending,
+		SkipOnErr: c.SkipOnErr,
+	}
 
 	return nil
 }
 
-// ---- Public methods
-
-// Measure runs all the registered health checks and returns summary status
-func (h *HealthCheckAdapter) Measure(ctx context.Context) HealthCheckInfo {
+// SetReady sets readiness gate, e.g. whether consumer is attached. Service is not ready while any gate is closed
+func (h *HealthCheckAdapter) SetReady(name string, ready bool) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	h.gates[name] = ready
+}
+
+// ---- Public methods
+
+// Start runs checks immediately and then every interval till Close
+func (h *HealthCheckAdapter) Start() {
+	h.startOnce.Do(func() {
+		h.wg.Add(1)
+		go h.loop()
+	})
+}
+
+// Close stops background checks and waits for running ones
+func (h *HealthCheckAdapter) Close() {
+	h.closeOnce.Do(func() {
+		close(h.stop)
+	})
+	h.wg.Wait()
+}
+
+// Measure returns summary status of the last results of registered health checks
+func (h *HealthCheckAdapter) Measure() HealthCheckInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
 	status := StatusOK
 	failures := make(map[string]string)
+	checks := make(map[string]CheckState, len(h.states))
+
+	for name, state := range h.states {
+		checks[name] = *state
+
+		if state.Status == StatusOK {
+			continue
+		}
+
+		failures[name] = string(state.Status)
+		if state.Error != "" {
+			failures[name] = state.Error
+		}
+		status = getAvailability(status, state.SkipOnErr)
+	}
+
+	// providers with open circuit are not called, service still works through the rest of them
+	if h.breakers != nil {
+		for _, name := range h.breakers.Open() {
+			failures["circuit:"+name] = "circuit " + h.breakers.Get(name).State()
+			status = getAvailability(status, true)
+		}
+	}
+
+	var systemMetrics *SystemInfo
+	if h.systemInfoEnabled {
+		systemMetrics = newSystemMetrics()
+	}
+
+	info := newCheck(h.component, status, systemMetrics, failures)
+	info.Checks = checks
+
+	return info
+}
+
+// Results returns whether each check passed on its last run, pending checks are not passed
+func (h *HealthCheckAdapter) Results() map[string]bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	results := make(map[string]bool, len(h.states))
+	for name, state := range h.states {
+		results[name] = state.Status == StatusOK
+	}
+
+	return results
+}
+
+// Ready reports whether service can take traffic: no critical (not SkipOnErr) check is failed or pending
+// and all readiness gates are open. Returns reasons if not ready
+func (h *HealthCheckAdapter) Ready() (bool, map[string]string) {
+	info := h.Measure()
+
+	reasons := make(map[string]string)
+	if info.Status == StatusUnavailable {
+		for name, state := range info.Checks {
+			if !state.SkipOnErr && state.Status != StatusOK {
+				reasons[name] = info.Failures[name]
+			}
+		}
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for name, ready := range h.gates {
+		if !ready {
+			reasons[name] = "not ready"
+		}
+	}
+
+	return len(reasons) == 0, reasons
+}
+
+// ---- Private methods
 
-	limiterCh := make(chan bool, h.maxConcurrent)
-	defer close(limiterCh)
+func (h *HealthCheckAdapter) loop() {
+	defer h.wg.Done()
 
-	var (
-		wg sync.WaitGroup
-		mu sync.Mutex
-	)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.runAll()
+
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runAll runs registered checks concurrently (at most maxConcurrent at once) and waits for them
+func (h *HealthCheckAdapter) runAll() {
+	h.mu.RLock()
+	checks := make([]HealthConfig, 0, len(h.checks))
 	for _, c := range h.checks {
-		limiterCh <- true
+		checks = append(checks, c)
+	}
+	h.mu.RUnlock()
+
+	limiterCh := make(chan struct{}, h.maxConcurrent)
+
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		limiterCh <- struct{}{}
 		wg.Add(1)
 
 		go func(c HealthConfig) {
@@ -139,49 +304,68 @@ func (h *HealthCheckAdapter) Measure(ctx context.Context) HealthCheckInfo {
 				wg.Done()
 			}()
 
-			resCh := make(chan error)
-
-			go func() {
-				resCh <- c.Check(ctx)
-				defer close(resCh)
-			}()
+			h.run(c)
+		}(c)
+	}
 
-			timeout := time.NewTimer(c.Timeout)
+	wg.Wait()
+}
 
-			select {
-			case <-timeout.C:
-				mu.Lock()
-				defer mu.Unlock()
+func (h *HealthCheckAdapter) run(c HealthConfig) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
 
-				failures[c.Name] = string(StatusTimeout)
-				status = getAvailability(status, c.SkipOnErr)
-			case res := <-resCh:
-				if !timeout.Stop() {
-					<-timeout.C
-				}
+	// check may ignore context, so result awaited no longer than timeout and buffered channel lets it finish later
+	resCh := make(chan error, 1)
+	started := time.Now()
 
-				mu.Lock()
-				defer mu.Unlock()
+	go func() {
+		resCh <- c.Check(ctx)
+	}()
 
-				if res != nil {
-					failures[c.Name] = res.Error()
-					status = getAvailability(status, c.SkipOnErr)
-				}
-			}
-		}(c)
+	var err error
+	select {
+	case <-ctx.Done():
+		err = errCheckTimeout
+	case err = <-resCh:
 	}
 
-	wg.Wait()
+	h.record(c.Name, err, started)
+}
 
-	var systemMetrics *SystemInfo
-	if h.systemInfoEnabled {
-		systemMetrics = newSystemMetrics()
+func (h *HealthCheckAdapter) record(name string, err error, started time.Time) {
+	now := time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := h.states[name]
+	state.CheckedAt = &now
+	state.LatencyMs = now.Sub(started).Milliseconds()
+
+	if err == nil {
+		if state.ConsecutiveFailures > 0 {
+			log.Infof("[HealthCheckAdapter] Check %s recovered after %d failures", name, state.ConsecutiveFailures)
+		}
+
+		state.Status = StatusOK
+		state.Error = ""
+		state.LastSuccessAt = &now
+		state.ConsecutiveFailures = 0
+		return
 	}
 
-	return newCheck(h.component, status, systemMetrics, failures)
-}
+	state.Status = StatusUnavailable
+	if errors.Is(err, errCheckTimeout) {
+		state.Status = StatusTimeout
+	}
+	state.Error = err.Error()
+	state.ConsecutiveFailures++
 
-// ---- Private methods
+	if state.ConsecutiveFailures == 1 {
+		log.Warnf("[HealthCheckAdapter] Check %s failed: %v", name, err)
+	}
+}
 
 func newCheck(c ComponentInfo, s Status, system *SystemInfo, failures map[string]string) HealthCheckInfo {
 	return HealthCheckInfo{
@@ -213,42 +397,3 @@ func getAvailability(s Status, skipOnErr bool) Status {
 
 	return StatusUnavailable
 }
-
-// ---- Static methods
-
-// SetChecks adds checks to newly instantiated health-container
-func SetChecks(checks ...HealthConfig) Option {
-	return func(h *HealthCheckAdapter) error {
-		for _, c := range checks {
-			if err := h.Register(c); err != nil {
-				return fmt.Errorf("could not register check %q: %w", c.Name, err)
-			}
-		}
-		return nil
-	}
-}
-
-// SetComponent sets the component description of the component to which this check refer
-func SetComponent(component ComponentInfo) Option {
-	return func(h *HealthCheckAdapter) error {
-		h.component = component
-		return nil
-	}
-}
-
-// SetMaxConcurrent sets max number of concurrently running checks.
-// Set to 1 if want to run all checks sequentially.
-func SetMaxConcurrent(n int) Option {
-	return func(h *HealthCheckAdapter) error {
-		h.maxConcurrent = n
-		return nil
-	}
-}
-
-// SetSystemInfo enables the option to return system information about the go process.
-func SetSystemInfo() Option {
-	return func(h *HealthCheckAdapter) error {
-		h.systemInfoEnabled = true
-		return nil
-	}
-}
@@ -0,0 +1,386 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// HMS Push Kit result codes
+// ref: https://developer.huawei.com/consumer/en/doc/development/HMSCore-References/https-send-api-0000001050986197#section13968115715131
+const (
+	hmsCodeSuccess          = "80000000"
+	hmsCodePartialSuccess   = "80100000"
+	hmsCodeOAuthExpired     = "80200003"
+	hmsCodeAllTokensInvalid = "80300007"
+	hmsCodeInternalError    = "81000001"
+
+	// max tokens per one messages:send call
+	hmsMaxTokens = 1000
+	hmsTimeout   = 10 * time.Second
+	// access token refreshed a bit earlier than it expires
+	hmsTokenRefresh = 5 * time.Minute
+)
+
+var errHMSTokenExpired = errors.New("[HMSAdapter] OAuth token expired")
+
+type IHMSAdapter interface {
+	Send(req *domain.PushNotification) error
+}
+
+type hmsMessageRequest struct {
+	ValidateOnly bool       `json:"validate_only"`
+	Message      hmsMessage `json:"message"`
+}
+
+type hmsMessage struct {
+	Data         string           `json:"data,omitempty"`
+	Notification *hmsNotification `json:"notification,omitempty"`
+	Android      *hmsAndroid      `json:"android,omitempty"`
+	Token        []string         `json:"token"`
+}
+
+type hmsNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+type hmsAndroid struct {
+	CollapseKey  *int                    `json:"collapse_key,omitempty"`
+	Urgency      string                  `json:"urgency,omitempty"`
+	TTL          string                  `json:"ttl,omitempty"`
+	Notification *hmsAndroidNotification `json:"notification,omitempty"`
+}
+
+type hmsAndroidNotification struct {
+	Title       string         `json:"title,omitempty"`
+	Body        string         `json:"body,omitempty"`
+	Image       string         `json:"image,omitempty"`
+	Sound       string         `json:"sound,omitempty"`
+	ClickAction hmsClickAction `json:"click_action"`
+}
+
+type hmsClickAction struct {
+	// 3 - open app home page
+	Type int `json:"type"`
+}
+
+type hmsMessageResponse struct {
+	Code      string `json:"code"`
+	Msg       string `json:"msg"`
+	RequestID string `json:"requestId"`
+}
+
+// hmsPartialResult is a json encoded into msg of partial success response
+type hmsPartialResult struct {
+	Success       int      `json:"success"`
+	Failure       int      `json:"failure"`
+	IllegalTokens []string `json:"illegal_tokens"`
+}
+
+type hmsAccessToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+type HMSAdapter struct {
+	client *http.Client
+	config *configs.HMSConfig
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewHMSAdapter Create new Huawei Push Kit client
+func NewHMSAdapter(
+	config *configs.HMSConfig,
+) *HMSAdapter {
+	if config.AppID == "" || config.ClientSecret == "" {
+		log.Warn("[HMSAdapter] App ID or client secret not provided, huawei push disabled")
+	}
+
+	return &HMSAdapter{
+		client: &http.Client{Timeout: hmsTimeout},
+		config: config,
+	}
+}
+
+// MapToHuaweiNotification map push fields to HMS v1 message
+// ref: https://developer.huawei.com/consumer/en/doc/development/HMSCore-References/https-send-api-0000001050986197
+func MapToHuaweiNotification(req *domain.PushNotification) *hmsMessageRequest {
+	message := hmsMessage{
+		Token:   req.Tokens,
+		Android: &hmsAndroid{},
+	}
+
+	if len(req.Data) > 0 {
+		// data of HMS message is a plain string
+		if b, err := json.Marshal(req.Data); err == nil {
+			message.Data = string(b)
+		}
+	}
+
+	if len(req.Title) > 0 || len(req.Message) > 0 {
+		message.Notification = &hmsNotification{
+			Title: req.Title,
+			Body:  req.Message,
+			Image: req.Image,
+		}
+		message.Android.Notification = &hmsAndroidNotification{
+			Title:       req.Title,
+			Body:        req.Message,
+			Image:       req.Image,
+			ClickAction: hmsClickAction{Type: 3},
+		}
+		if v, ok := req.Sound.(string); ok && len(v) > 0 {
+			message.Android.Notification.Sound = v
+		}
+	}
+
+	if req.TimeToLive != nil {
+		message.Android.TTL = fmt.Sprintf("%ds", *req.TimeToLive)
+	}
+
+	// collapse key of HMS is a number from -1 to 100
+	if len(req.CollapseKey) > 0 {
+		if key, err := strconv.Atoi(req.CollapseKey); err == nil && key >= -1 && key <= 100 {
+			message.Android.CollapseKey = &key
+		}
+	}
+
+	switch req.Priority {
+	case "high":
+		message.Android.Urgency = "HIGH"
+	case "normal":
+		message.Android.Urgency = "NORMAL"
+	}
+
+	return &hmsMessageRequest{
+		ValidateOnly: req.DryRun,
+		Message:      message,
+	}
+}
+
+// Send provide send notification to Huawei Push Kit server.
+func (h *HMSAdapter) Send(push *domain.PushNotification) (err error) {
+	err = domain.ValidatePushNotification(push)
+	if err != nil {
+		log.Println("[HMSAdapter] Not valid push notification: " + err.Error())
+		return
+	}
+
+	if h.config.AppID == "" || h.config.ClientSecret == "" {
+		return errors.New("[HMSAdapter] Credentials are not configured")
+	}
+
+	maxRetry := h.config.MaxRetry
+	if push.Retry > 0 && push.Retry < maxRetry {
+		maxRetry = push.Retry
+	}
+
+	var (
+		delivered int
+		invalid   []string
+	)
+	for start := 0; start < len(push.Tokens); start += hmsMaxTokens {
+		end := start + hmsMaxTokens
+		if end > len(push.Tokens) {
+			end = len(push.Tokens)
+		}
+
+		chunk := *push
+		chunk.Tokens = push.Tokens[start:end]
+
+		sent, illegal, sErr := h.retry(&chunk, 0, maxRetry)
+		delivered += sent
+		invalid = append(invalid, illegal...)
+		if sErr != nil {
+			err = sErr
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &domain.InvalidTokensError{Tokens: invalid, Delivered: delivered, Err: err}
+	}
+
+	return err
+}
+
+// retry returns count of tokens HMS accepted and tokens reported as illegal by HMS
+func (h *HMSAdapter) retry(push *domain.PushNotification, retryCount, maxRetry int) (int, []string, error) {
+	res, status, err := h.send(MapToHuaweiNotification(push))
+
+	if errors.Is(err, errHMSTokenExpired) {
+		h.resetAccessToken()
+	}
+
+	switch {
+	case err == nil && res.Code == hmsCodeSuccess:
+		for _, token := range push.Tokens {
+			h.saveLogs("success_push", token, push, nil)
+		}
+		return len(push.Tokens), nil, nil
+	case err == nil && res.Code == hmsCodePartialSuccess:
+		partial := hmsPartialResult{}
+		if uErr := json.Unmarshal([]byte(res.Msg), &partial); uErr != nil {
+			log.Errorf("[HMSAdapter] Cannot parse partial result: %v", uErr)
+		}
+		for _, token := range partial.IllegalTokens {
+			h.saveLogs("fail_push", token, push, errors.New("illegal token"))
+		}
+		return partial.Success, partial.IllegalTokens, nil
+	case err == nil && res.Code == hmsCodeAllTokensInvalid:
+		for _, token := range push.Tokens {
+			h.saveLogs("fail_push", token, push, errors.New(res.Msg))
+		}
+		return 0, push.Tokens, nil
+	case err == nil:
+		err = fmt.Errorf("[HMSAdapter] HMS responded %s: %s", res.Code, res.Msg)
+	}
+
+	for _, token := range push.Tokens {
+		h.saveLogs("fail_push", token, push, err)
+	}
+
+	// We should retry only "retryable" statuses: expired access token, throttling and server errors
+	retryable := errors.Is(err, errHMSTokenExpired) ||
+		status == 0 ||
+		status == http.StatusTooManyRequests ||
+		status >= http.StatusInternalServerError ||
+		(res != nil && res.Code == hmsCodeInternalError)
+
+	if retryable && retryCount < maxRetry {
+		retryCount++
+		metrics.Retried(domain.ProviderHMS, err)
+		time.Sleep(retryDelay(retryCount, err))
+		return h.retry(push, retryCount, maxRetry)
+	}
+
+	return 0, nil, err
+}
+
+func (h *HMSAdapter) send(msg *hmsMessageRequest) (*hmsMessageResponse, int, error) {
+	accessToken, err := h.getAccessToken()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/messages:send", strings.TrimRight(h.config.PushURL, "/"), h.config.AppID)
+	httpReq, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	httpReq.Header.Set("Content-Type", "application/json;charset=utf-8")
+
+	httpRes, err := h.client.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode == http.StatusUnauthorized {
+		return nil, httpRes.StatusCode, errHMSTokenExpired
+	}
+
+	if httpRes.StatusCode == http.StatusTooManyRequests {
+		return nil, httpRes.StatusCode, &domain.RateLimitError{
+			Provider:   domain.ProviderHMS,
+			RetryAfter: domain.ParseRetryAfter(httpRes.Header.Get("Retry-After")),
+		}
+	}
+
+	res := &hmsMessageResponse{}
+	if err := json.NewDecoder(httpRes.Body).Decode(res); err != nil {
+		return nil, httpRes.StatusCode, fmt.Errorf("[HMSAdapter] Cannot parse response (status %d): %w", httpRes.StatusCode, err)
+	}
+
+	if res.Code == hmsCodeOAuthExpired {
+		return res, httpRes.StatusCode, errHMSTokenExpired
+	}
+
+	return res, httpRes.StatusCode, nil
+}
+
+// getAccessToken returns cached OAuth token or requests new one by client credentials
+// ref: https://developer.huawei.com/consumer/en/doc/development/HMSCore-Guides/open-platform-oauth-0000001053629189#section11911131105212
+func (h *HMSAdapter) getAccessToken() (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.accessToken != "" && time.Until(h.expiresAt) > hmsTokenRefresh {
+		return h.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {h.config.ClientID},
+		"client_secret": {h.config.ClientSecret},
+	}
+
+	res, err := h.client.PostForm(h.config.AuthURL, form)
+	if err != nil {
+		return "", fmt.Errorf("[HMSAdapter] Failed request access token: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		reason, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return "", fmt.Errorf("[HMSAdapter] OAuth server responded %d: %s", res.StatusCode, reason)
+	}
+
+	token := hmsAccessToken{}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("[HMSAdapter] Cannot parse access token: %w", err)
+	}
+
+	h.accessToken = token.AccessToken
+	h.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return h.accessToken, nil
+}
+
+func (h *HMSAdapter) resetAccessToken() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.accessToken = ""
+}
+
+// TODO: save logs to storage
+func (h *HMSAdapter) saveLogs(status, token string, req *domain.PushNotification, err error) {
+	entry := log.WithContext(req.GetContext()).WithFields(log.Fields{
+		"notif_id": req.ID,
+		"status":   status,
+		"token":    token,
+		"platform": req.Platform,
+	})
+
+	if err != nil {
+		entry.WithError(err).Error("[HMSAdapter] push failed")
+		return
+	}
+
+	entry.Debug("[HMSAdapter] push sent")
+}
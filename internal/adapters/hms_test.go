@@ -0,0 +1,249 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+)
+
+func TestMapToHuaweiNotification(t *testing.T) {
+	ttl := uint(60)
+	collapseKey := 5
+	tokens := []string{"t1", "t2"}
+
+	tests := []struct {
+		name string
+		req  *domain.PushNotification
+		want *hmsMessageRequest
+	}{
+		{
+			name: "notification",
+			req:  &domain.PushNotification{Tokens: tokens, Title: "title", Message: "body", Image: "https://img", Sound: "default"},
+			want: &hmsMessageRequest{Message: hmsMessage{
+				Token:        tokens,
+				Notification: &hmsNotification{Title: "title", Body: "body", Image: "https://img"},
+				Android: &hmsAndroid{Notification: &hmsAndroidNotification{
+					Title: "title", Body: "body", Image: "https://img", Sound: "default", ClickAction: hmsClickAction{Type: 3},
+				}},
+			}},
+		},
+		{
+			name: "data message",
+			req:  &domain.PushNotification{Tokens: tokens, Data: domain.AnyData{"id": "42"}},
+			want: &hmsMessageRequest{Message: hmsMessage{Token: tokens, Data: `{"id":"42"}`, Android: &hmsAndroid{}}},
+		},
+		{
+			name: "android options",
+			req:  &domain.PushNotification{Tokens: tokens, TimeToLive: &ttl, CollapseKey: "5", Priority: "high", DryRun: true},
+			want: &hmsMessageRequest{ValidateOnly: true, Message: hmsMessage{
+				Token:   tokens,
+				Android: &hmsAndroid{CollapseKey: &collapseKey, Urgency: "HIGH", TTL: "60s"},
+			}},
+		},
+		{
+			name: "normal priority",
+			req:  &domain.PushNotification{Tokens: tokens, Priority: "normal"},
+			want: &hmsMessageRequest{Message: hmsMessage{Token: tokens, Android: &hmsAndroid{Urgency: "NORMAL"}}},
+		},
+		{
+			name: "collapse key out of range",
+			req:  &domain.PushNotification{Tokens: tokens, CollapseKey: "101"},
+			want: &hmsMessageRequest{Message: hmsMessage{Token: tokens, Android: &hmsAndroid{}}},
+		},
+		{
+			name: "collapse key not a number",
+			req:  &domain.PushNotification{Tokens: tokens, CollapseKey: "news"},
+			want: &hmsMessageRequest{Message: hmsMessage{Token: tokens, Android: &hmsAndroid{}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MapToHuaweiNotification(tt.req); !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Fatalf("MapToHuaweiNotification() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// hmsResponse answer of fake HMS to messages:send call
+type hmsResponse struct {
+	status int
+	code   string
+	msg    string
+}
+
+func TestHMSAdapterSend(t *testing.T) {
+	tokens := []string{"t1", "t2"}
+
+	tests := []struct {
+		name      string
+		responses []hmsResponse
+		// invalid tokens and delivered count reported by InvalidTokensError
+		invalid   []string
+		delivered int
+		wantErr   bool
+		// retryable by queue consumers, adapter retries are counted by calls
+		retryable bool
+		calls     int
+		// access token requests
+		logins int
+	}{
+		{
+			name:      "success",
+			responses: []hmsResponse{{status: http.StatusOK, code: hmsCodeSuccess}},
+			calls:     1,
+			logins:    1,
+		},
+		{
+			name:      "partial success",
+			responses: []hmsResponse{{status: http.StatusOK, code: hmsCodePartialSuccess, msg: `{"success":1,"failure":1,"illegal_tokens":["t2"]}`}},
+			invalid:   []string{"t2"},
+			delivered: 1,
+			wantErr:   true,
+			calls:     1,
+			logins:    1,
+		},
+		{
+			name:      "all tokens invalid",
+			responses: []hmsResponse{{status: http.StatusOK, code: hmsCodeAllTokensInvalid, msg: "all the tokens are invalid"}},
+			invalid:   tokens,
+			wantErr:   true,
+			calls:     1,
+			logins:    1,
+		},
+		{
+			name: "internal error retried",
+			responses: []hmsResponse{
+				{status: http.StatusOK, code: hmsCodeInternalError, msg: "internal error"},
+				{status: http.StatusOK, code: hmsCodeSuccess},
+			},
+			calls:  2,
+			logins: 1,
+		},
+		{
+			name: "server error retried",
+			responses: []hmsResponse{
+				{status: http.StatusServiceUnavailable, code: "", msg: "unavailable"},
+				{status: http.StatusOK, code: hmsCodeSuccess},
+			},
+			calls:  2,
+			logins: 1,
+		},
+		{
+			name: "throttling retried",
+			responses: []hmsResponse{
+				{status: http.StatusTooManyRequests},
+				{status: http.StatusOK, code: hmsCodeSuccess},
+			},
+			calls:  2,
+			logins: 1,
+		},
+		{
+			name: "expired access token refreshed",
+			responses: []hmsResponse{
+				{status: http.StatusOK, code: hmsCodeOAuthExpired, msg: "token expired"},
+				{status: http.StatusOK, code: hmsCodeSuccess},
+			},
+			calls:  2,
+			logins: 2,
+		},
+		{
+			name: "retries exhausted",
+			responses: []hmsResponse{
+				{status: http.StatusOK, code: hmsCodeInternalError, msg: "internal error"},
+				{status: http.StatusOK, code: hmsCodeInternalError, msg: "internal error"},
+			},
+			wantErr:   true,
+			retryable: true,
+			calls:     2,
+			logins:    1,
+		},
+		{
+			name:      "unknown code not retried by adapter",
+			responses: []hmsResponse{{status: http.StatusBadRequest, code: "80100003", msg: "illegal payload"}},
+			wantErr:   true,
+			retryable: true,
+			calls:     1,
+			logins:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu            sync.Mutex
+				calls, logins int
+			)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				logins++
+				mu.Unlock()
+
+				_ = json.NewEncoder(w).Encode(hmsAccessToken{AccessToken: "access", ExpiresIn: 3600})
+			})
+			mux.HandleFunc("/v1/app/messages:send", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				res := tt.responses[calls]
+				calls++
+				mu.Unlock()
+
+				w.WriteHeader(res.status)
+				if res.status != http.StatusTooManyRequests {
+					_ = json.NewEncoder(w).Encode(hmsMessageResponse{Code: res.code, Msg: res.msg})
+				}
+			})
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			h := &HMSAdapter{
+				client: server.Client(),
+				config: &configs.HMSConfig{
+					AppID:        "app",
+					ClientID:     "app",
+					ClientSecret: "secret",
+					AuthURL:      server.URL + "/token",
+					PushURL:      server.URL,
+					MaxRetry:     len(tt.responses) - 1,
+				},
+			}
+
+			err := h.Send(&domain.PushNotification{Tokens: tokens, Title: "title", Message: "body"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+
+			if calls != tt.calls || logins != tt.logins {
+				t.Fatalf("calls = %d, logins = %d, want %d, %d", calls, logins, tt.calls, tt.logins)
+			}
+
+			if err == nil {
+				return
+			}
+
+			var invalid *domain.InvalidTokensError
+			if errors.As(err, &invalid) != (tt.invalid != nil) {
+				t.Fatalf("err = %v, want invalid tokens %v", err, tt.invalid)
+			}
+			if invalid != nil && (!reflect.DeepEqual(invalid.Tokens, tt.invalid) || invalid.Delivered != tt.delivered) {
+				t.Fatalf("invalid = %v, delivered = %d, want %v, %d", invalid.Tokens, invalid.Delivered, tt.invalid, tt.delivered)
+			}
+
+			if retryable := domain.IsRetryable(err); retryable != tt.retryable {
+				t.Fatalf("retryable = %v, want %v", retryable, tt.retryable)
+			}
+		})
+	}
+}
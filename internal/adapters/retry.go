@@ -0,0 +1,20 @@
+package adapters
+
+import (
+	"errors"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/domain"
+)
+
+// retryDelay returns pause before next attempt: linear backoff or provider Retry-After if it is longer
+func retryDelay(retryCount int, err error) time.Duration {
+	delay := time.Duration(retryCount) * 500 * time.Millisecond
+
+	var limited *domain.RateLimitError
+	if errors.As(err, &limited) && limited.RetryAfter > delay {
+		delay = limited.RetryAfter
+	}
+
+	return delay
+}
@@ -0,0 +1,231 @@
+package adapters
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+	"github.com/emersion/go-smtp"
+	log "github.com/sirupsen/logrus"
+)
+
+// IAvailability implemented by providers which could be temporary unavailable (e.g. circuit is open)
+type IAvailability interface {
+	Available() bool
+}
+
+// providerRouter choose channel providers for destination and fail over between them
+type providerRouter struct {
+	channel string
+	routes  []*configs.ProviderRoute
+	rules   []*configs.RoutingRule
+	// match returns how specific matched pattern is (e.g. length of prefix)
+	match     func(destination, pattern string) (int, bool)
+	available func(provider string) bool
+	throttler *throttle.Throttler
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newProviderRouter(
+	channel string,
+	routes []*configs.ProviderRoute,
+	rules []*configs.RoutingRule,
+	match func(destination, pattern string) (int, bool),
+	available func(provider string) bool,
+	throttler *throttle.Throttler,
+) *providerRouter {
+	return &providerRouter{
+		channel:   channel,
+		routes:    routes,
+		rules:     rules,
+		match:     match,
+		available: available,
+		throttler: throttler,
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// send try providers in order until one of them succeeded, unavailable providers skipped. Every provider
+// is throttled by own bucket (channel:provider), so saturated gateway does not slow down the others.
+// Next provider is tried only if failure is specific to provider (see failsOver), otherwise error returned
+// right away. Error of the last tried provider returned if all failed
+func (r *providerRouter) send(destination string, send func(provider string) error) error {
+	var err error = &domain.CircuitOpenError{Provider: r.channel}
+
+	for _, provider := range r.order(destination) {
+		if !r.available(provider) {
+			log.Debugf("[ProviderRouter] %s provider %s unavailable, skipped", r.channel, provider)
+			continue
+		}
+
+		err = r.throttler.Do(r.channel+":"+provider, 1, func() error {
+			return send(provider)
+		})
+		if err == nil {
+			return nil
+		}
+
+		if !failsOver(err) {
+			log.Warnf("[ProviderRouter] %s provider %s failed: %v", r.channel, provider, err)
+			return err
+		}
+
+		log.Warnf("[ProviderRouter] %s provider %s failed, failing over: %v", r.channel, provider, err)
+	}
+
+	return err
+}
+
+// order returns providers to try: provider of the most specific matched routing rule (the first one of
+// equally specific) first, then providers by priority in weighted random order within the same priority
+func (r *providerRouter) order(destination string) []string {
+	order := make([]string, 0, len(r.routes))
+
+	var matched *configs.RoutingRule
+	best := -1
+	for _, rule := range r.rules {
+		if specificity, ok := r.match(destination, rule.Pattern); ok && specificity > best {
+			matched, best = rule, specificity
+		}
+	}
+	if matched != nil {
+		order = append(order, matched.Provider)
+	}
+
+	candidates := make([]*configs.ProviderRoute, 0, len(r.routes))
+	for _, route := range r.routes {
+		if len(order) == 0 || route.Name != order[0] {
+			candidates = append(candidates, route)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Priority < candidates[j].Priority
+	})
+
+	for start := 0; start < len(candidates); {
+		end := start
+		for end < len(candidates) && candidates[end].Priority == candidates[start].Priority {
+			end++
+		}
+
+		order = append(order, r.shuffle(candidates[start:end])...)
+		start = end
+	}
+
+	return order
+}
+
+// shuffle returns providers in random order where provider with higher weight more likely goes first
+func (r *providerRouter) shuffle(routes []*configs.ProviderRoute) []string {
+	rest := make([]*configs.ProviderRoute, len(routes))
+	copy(rest, routes)
+
+	names := make([]string, 0, len(routes))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(rest) > 0 {
+		total := 0
+		for _, route := range rest {
+			total += route.Weight
+		}
+
+		// zero weight providers used only when others failed
+		picked := 0
+		if total > 0 {
+			n := r.rnd.Intn(total)
+			for i, route := range rest {
+				if n < route.Weight {
+					picked = i
+					break
+				}
+				n -= route.Weight
+			}
+		}
+
+		names = append(names, rest[picked].Name)
+		rest = append(rest[:picked], rest[picked+1:]...)
+	}
+
+	return names
+}
+
+// matchPhonePrefix compare phone with prefix by digits only, so "+7" matches "7 (999) 123-45-67".
+// Returns count of matched digits
+func matchPhonePrefix(phone, prefix string) (int, bool) {
+	prefix = digitsOf(prefix)
+
+	return len(prefix), strings.HasPrefix(digitsOf(phone), prefix)
+}
+
+// matchEmailDomain match email by domain or its parent domain. Returns count of matched domain labels
+func matchEmailDomain(email, domain string) (int, bool) {
+	_, host, ok := strings.Cut(strings.ToLower(email), "@")
+	if !ok {
+		return 0, false
+	}
+
+	domain = strings.TrimPrefix(strings.ToLower(domain), "@")
+
+	return strings.Count(domain, ".") + 1, host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// failsOver check another provider could deliver notification failed with err: provider is unreachable,
+// failed itself (HTTP 5xx, SMTP transient reply), throttles us or its circuit is open. Invalid notification
+// and rejected destination fail the same way on every provider
+func failsOver(err error) bool {
+	var (
+		limited  *domain.RateLimitError
+		open     *domain.CircuitOpenError
+		provider *domain.ProviderError
+		smtpErr  *smtp.SMTPError
+		netErr   net.Error
+	)
+
+	switch {
+	case errors.As(err, &limited), errors.As(err, &open):
+		return true
+	case errors.As(err, &provider):
+		return provider.StatusCode >= http.StatusInternalServerError
+	case errors.As(err, &smtpErr):
+		return smtpErr.Code/100 == 4
+	case errors.As(err, &netErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+
+	return false
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+// isAvailable check provider could take requests
+func isAvailable(provider interface{}) bool {
+	if a, ok := provider.(IAvailability); ok {
+		return a.Available()
+	}
+
+	return true
+}
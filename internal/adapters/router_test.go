@@ -0,0 +1,208 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+	"github.com/emersion/go-smtp"
+)
+
+func newTestRouter(routes []*configs.ProviderRoute, rules []*configs.RoutingRule) *providerRouter {
+	r := newProviderRouter("sms", routes, rules, matchPhonePrefix, func(string) bool { return true }, nil)
+	r.rnd = rand.New(rand.NewSource(1))
+
+	return r
+}
+
+func TestProviderRouterOrder(t *testing.T) {
+	routes := []*configs.ProviderRoute{
+		{Name: "backup", Priority: 2, Weight: 1},
+		{Name: "twilio", Priority: 1, Weight: 1},
+		{Name: "smsc", Priority: 3, Weight: 1},
+	}
+	rules := []*configs.RoutingRule{
+		{Pattern: "+7", Provider: "smsc"},
+		{Pattern: "+1", Provider: "twilio"},
+		{Pattern: "+7 999", Provider: "backup"},
+	}
+
+	tests := []struct {
+		name        string
+		destination string
+		want        []string
+	}{
+		{name: "by priority", destination: "+44 20 7946 0958", want: []string{"twilio", "backup", "smsc"}},
+		{name: "rule provider not repeated", destination: "+1 555 0100", want: []string{"twilio", "backup", "smsc"}},
+		{name: "the longest prefix wins", destination: "+7 (999) 123-45-67", want: []string{"backup", "twilio", "smsc"}},
+		{name: "shorter prefix of other code", destination: "+7 (495) 123-45-67", want: []string{"smsc", "twilio", "backup"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTestRouter(routes, rules).order(tt.destination); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("order(%q) = %v, want %v", tt.destination, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderRouterShuffle(t *testing.T) {
+	const runs = 10000
+
+	tests := []struct {
+		name   string
+		routes []*configs.ProviderRoute
+		// share of runs every provider went first, with 5% tolerance
+		first map[string]float64
+	}{
+		{
+			name: "equal weights",
+			routes: []*configs.ProviderRoute{
+				{Name: "a", Weight: 1},
+				{Name: "b", Weight: 1},
+			},
+			first: map[string]float64{"a": 0.5, "b": 0.5},
+		},
+		{
+			name: "weighted",
+			routes: []*configs.ProviderRoute{
+				{Name: "a", Weight: 3},
+				{Name: "b", Weight: 1},
+			},
+			first: map[string]float64{"a": 0.75, "b": 0.25},
+		},
+		{
+			name: "zero weight is fallback only",
+			routes: []*configs.ProviderRoute{
+				{Name: "a", Weight: 0},
+				{Name: "b", Weight: 1},
+				{Name: "c", Weight: 1},
+			},
+			first: map[string]float64{"a": 0, "b": 0.5, "c": 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(tt.routes, nil)
+
+			counts := make(map[string]int)
+			for i := 0; i < runs; i++ {
+				names := r.shuffle(tt.routes)
+				if len(names) != len(tt.routes) {
+					t.Fatalf("shuffle returned %v", names)
+				}
+				counts[names[0]]++
+			}
+
+			for name, share := range tt.first {
+				got := float64(counts[name]) / runs
+				if got < share-0.05 || got > share+0.05 {
+					t.Fatalf("%s went first in %.2f of runs, want %.2f", name, got, share)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchPhonePrefix(t *testing.T) {
+	tests := []struct {
+		phone       string
+		prefix      string
+		specificity int
+		want        bool
+	}{
+		{phone: "+79991234567", prefix: "+7", specificity: 1, want: true},
+		{phone: "7 (999) 123-45-67", prefix: "+7", specificity: 1, want: true},
+		{phone: "+7 999 123", prefix: "+7999", specificity: 4, want: true},
+		{phone: "+19991234567", prefix: "+7", want: false},
+		{phone: "+7999", prefix: "+79991", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone+"/"+tt.prefix, func(t *testing.T) {
+			specificity, got := matchPhonePrefix(tt.phone, tt.prefix)
+			if got != tt.want || (got && specificity != tt.specificity) {
+				t.Fatalf("matchPhonePrefix(%q, %q) = %d, %v, want %d, %v", tt.phone, tt.prefix, specificity, got, tt.specificity, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchEmailDomain(t *testing.T) {
+	tests := []struct {
+		email       string
+		domain      string
+		specificity int
+		want        bool
+	}{
+		{email: "user@gmail.com", domain: "gmail.com", specificity: 2, want: true},
+		{email: "User@GMAIL.com", domain: "@gmail.com", specificity: 2, want: true},
+		{email: "user@mail.corp.example.com", domain: "example.com", specificity: 2, want: true},
+		{email: "user@mail.corp.example.com", domain: "corp.example.com", specificity: 3, want: true},
+		{email: "user@notexample.com", domain: "example.com", want: false},
+		{email: "user@example.com.evil", domain: "example.com", want: false},
+		{email: "not an email", domain: "example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email+"/"+tt.domain, func(t *testing.T) {
+			specificity, got := matchEmailDomain(tt.email, tt.domain)
+			if got != tt.want || (got && specificity != tt.specificity) {
+				t.Fatalf("matchEmailDomain(%q, %q) = %d, %v, want %d, %v", tt.email, tt.domain, specificity, got, tt.specificity, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderRouterFailover(t *testing.T) {
+	routes := []*configs.ProviderRoute{
+		{Name: "first", Priority: 1, Weight: 1},
+		{Name: "second", Priority: 2, Weight: 1},
+	}
+
+	tests := []struct {
+		name  string
+		err   error
+		tried []string
+	}{
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, tried: []string{"first", "second"}},
+		{name: "connection closed", err: fmt.Errorf("read: %w", io.EOF), tried: []string{"first", "second"}},
+		{name: "provider 5xx", err: &domain.ProviderError{StatusCode: 503}, tried: []string{"first", "second"}},
+		{name: "rate limited", err: &domain.RateLimitError{Provider: "sms"}, tried: []string{"first", "second"}},
+		{name: "circuit open", err: &domain.CircuitOpenError{Provider: "sms"}, tried: []string{"first", "second"}},
+		{name: "smtp transient reply", err: &smtp.SMTPError{Code: 451}, tried: []string{"first", "second"}},
+		{name: "provider 4xx", err: &domain.ProviderError{StatusCode: 400}, tried: []string{"first"}},
+		{name: "smtp permanent reply", err: &smtp.SMTPError{Code: 550}, tried: []string{"first"}},
+		{name: "invalid notification", err: &domain.ValidationError{Message: "invalid"}, tried: []string{"first"}},
+		{name: "unknown error", err: errors.New("unknown"), tried: []string{"first"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(routes, nil)
+			r.throttler = throttle.NewThrottler(&configs.ThrottleConfig{})
+
+			var tried []string
+			err := r.send("+79991234567", func(provider string) error {
+				tried = append(tried, provider)
+				return tt.err
+			})
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(tried, tt.tried) {
+				t.Fatalf("tried %v, want %v", tried, tt.tried)
+			}
+		})
+	}
+}
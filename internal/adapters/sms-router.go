@@ -0,0 +1,48 @@
+package adapters
+
+import (
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+)
+
+// SMSRouter send SMS through several gateways with weighted routing, destination rules and failover.
+// Every provider has own circuit breaker, providers with open circuit are skipped
+type SMSRouter struct {
+	providers map[string]ISMSAdapter
+	router    *providerRouter
+}
+
+func NewSMSRouter(
+	config *configs.SMSConfig,
+	routing *configs.RoutingConfig,
+	breakers *CircuitBreakers,
+	throttler *throttle.Throttler,
+) *SMSRouter {
+	r := &SMSRouter{
+		providers: make(map[string]ISMSAdapter, len(routing.SMS)),
+	}
+
+	for _, route := range routing.SMS {
+		providerConfig := config
+		if route.Name != configs.DefaultProvider {
+			providerConfig = configs.NewSMSProviderConfig(route.Name)
+		}
+		r.providers[route.Name] = &smsBreaker{
+			adapter: NewSMSAdapter(providerConfig),
+			breaker: breakers.Get(domain.ProviderSMS + ":" + route.Name),
+		}
+	}
+
+	r.router = newProviderRouter(domain.ProviderSMS, routing.SMS, routing.SMSRouting, matchPhonePrefix, func(provider string) bool {
+		return isAvailable(r.providers[provider])
+	}, throttler)
+
+	return r
+}
+
+func (r *SMSRouter) Send(notification *domain.SMSNotification) error {
+	return r.router.send(notification.Phone, func(provider string) error {
+		return r.providers[provider].Send(notification)
+	})
+}
@@ -3,8 +3,10 @@ package adapters
 import (
 	"fmt"
 	"github.com/WildEgor/gNotifier/internal/configs"
+	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/WildEgor/gNotifier/internal/domain"
 	log "github.com/sirupsen/logrus"
@@ -16,6 +18,7 @@ type ISMSAdapter interface {
 
 type SMSAdapter struct {
 	config *configs.SMSConfig
+	client *http.Client
 }
 
 func NewSMSAdapter(
@@ -23,6 +26,7 @@ func NewSMSAdapter(
 ) *SMSAdapter {
 	return &SMSAdapter{
 		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -52,10 +56,33 @@ func (s *SMSAdapter) Send(notification *domain.SMSNotification) (err error) {
 
 	requestUrl := fmt.Sprintf("%v?%v", baseURL, queryParams.Encode())
 
-	_, err = http.NewRequest(http.MethodGet, requestUrl, nil)
+	req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
 	if err != nil {
-		log.Errorf("[SMSAdapter] Creating the request failed: %w", err)
-		return nil
+		log.Errorf("[SMSAdapter] Creating the request failed: %v", err)
+		return err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		log.Errorf("[SMSAdapter] Gateway request failed: %v", err)
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		return &domain.RateLimitError{
+			Provider:   domain.ProviderSMS,
+			RetryAfter: domain.ParseRetryAfter(res.Header.Get("Retry-After")),
+		}
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		reason, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return &domain.ProviderError{
+			Provider:   domain.ProviderSMS,
+			StatusCode: res.StatusCode,
+			Message:    string(reason),
+		}
 	}
 
 	return nil
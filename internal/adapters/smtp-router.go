@@ -0,0 +1,48 @@
+package adapters
+
+import (
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+)
+
+// SMTPRouter send emails through several providers with weighted routing, destination rules and failover.
+// Every provider has own circuit breaker, providers with open circuit are skipped
+type SMTPRouter struct {
+	providers map[string]ISMTPAdapter
+	router    *providerRouter
+}
+
+func NewSMTPRouter(
+	config *configs.SMTPConfig,
+	routing *configs.RoutingConfig,
+	breakers *CircuitBreakers,
+	throttler *throttle.Throttler,
+) *SMTPRouter {
+	r := &SMTPRouter{
+		providers: make(map[string]ISMTPAdapter, len(routing.SMTP)),
+	}
+
+	for _, route := range routing.SMTP {
+		providerConfig := config
+		if route.Name != configs.DefaultProvider {
+			providerConfig = configs.NewSMTPProviderConfig(route.Name)
+		}
+		r.providers[route.Name] = &smtpBreaker{
+			adapter: NewSMTPAdapter(providerConfig),
+			breaker: breakers.Get(domain.ProviderSMTP + ":" + route.Name),
+		}
+	}
+
+	r.router = newProviderRouter(domain.ProviderSMTP, routing.SMTP, routing.SMTPRouting, matchEmailDomain, func(provider string) bool {
+		return isAvailable(r.providers[provider])
+	}, throttler)
+
+	return r
+}
+
+func (r *SMTPRouter) Send(notification *domain.EmailNotification) error {
+	return r.router.send(notification.Email, func(provider string) error {
+		return r.providers[provider].Send(notification)
+	})
+}
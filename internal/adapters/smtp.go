@@ -1,6 +1,7 @@
 package adapters
 
 import (
+	"errors"
 	"fmt"
 	"github.com/WildEgor/gNotifier/internal/configs"
 	"strings"
@@ -53,8 +54,23 @@ func (s *SMTPAdapter) Send(notification *domain.EmailNotification) (err error) {
 
 	err = smtp.SendMail(address, auth, s.config.From, to, msg)
 	if err != nil {
-		log.Fatal(err)
+		log.Errorf("[SMTPAdapter] Failed send mail: %v", err)
+		return mapSMTPError(err)
 	}
 
 	return nil
 }
+
+// mapSMTPError treat "service not available" and policy (4.7.x) temporary failures as relay throttling
+func mapSMTPError(err error) error {
+	var smtpErr *smtp.SMTPError
+	if !errors.As(err, &smtpErr) {
+		return err
+	}
+
+	if smtpErr.Code == 421 || (smtpErr.Code/100 == 4 && smtpErr.EnhancedCode[0] == 4 && smtpErr.EnhancedCode[1] == 7) {
+		return &domain.RateLimitError{Provider: domain.ProviderSMTP}
+	}
+
+	return err
+}
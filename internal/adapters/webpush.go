@@ -0,0 +1,451 @@
+package adapters
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// record size of aes128gcm content coding, whole payload encrypted as single record
+	webPushRecordSize = 4096
+	// header: salt (16) + rs (4) + idlen (1) + keyid (65)
+	webPushHeaderSize = 86
+	// push services accept at most 4096 bytes of body: header, padding delimiter and AEAD tag included
+	maxWebPushPayloadSize = 4096 - webPushHeaderSize - 1 - 16
+
+	vapidTokenTTL     = 12 * time.Hour
+	vapidTokenRefresh = time.Hour
+	webPushTimeout    = 10 * time.Second
+)
+
+type IWebPushAdapter interface {
+	Send(req *domain.WebPushNotification) error
+}
+
+type vapidToken struct {
+	header    string
+	expiresAt time.Time
+}
+
+type WebPushAdapter struct {
+	client     *http.Client
+	config     *configs.WebPushConfig
+	privateKey *ecdsa.PrivateKey
+	publicKey  string
+	slots      chan struct{}
+
+	mu     sync.Mutex
+	tokens map[string]vapidToken
+}
+
+// NewWebPushAdapter Create new Web Push (RFC 8030) sender with VAPID (RFC 8292) auth
+func NewWebPushAdapter(
+	config *configs.WebPushConfig,
+) *WebPushAdapter {
+	adapter := &WebPushAdapter{
+		client: &http.Client{Timeout: webPushTimeout},
+		config: config,
+		slots:  make(chan struct{}, config.MaxConcurrent),
+		tokens: make(map[string]vapidToken),
+	}
+	metrics.RegisterSlots(domain.ProviderWebPush, adapter.slots)
+
+	if config.VAPIDPrivateKey != "" {
+		key, pub, err := parseVAPIDPrivateKey(config.VAPIDPrivateKey)
+		if err != nil {
+			log.Errorf("[WebPushAdapter] Cannot parse VAPID private key: %v", err)
+			return adapter
+		}
+
+		adapter.privateKey = key
+		adapter.publicKey = base64.RawURLEncoding.EncodeToString(pub)
+
+		if config.VAPIDPublicKey != "" && strings.TrimRight(config.VAPIDPublicKey, "=") != adapter.publicKey {
+			log.Warn("[WebPushAdapter] VAPID public key does not match private key, derived key will be used")
+		}
+	}
+
+	return adapter
+}
+
+// Send provide send notification to browser push services.
+func (w *WebPushAdapter) Send(req *domain.WebPushNotification) (err error) {
+	err = domain.ValidateWebPushNotification(req)
+	if err != nil {
+		log.Println("[WebPushAdapter] Not valid web push notification: " + err.Error())
+		return
+	}
+
+	if w.privateKey == nil {
+		return errors.New("[WebPushAdapter] VAPID keys are not configured")
+	}
+
+	payload, err := req.Payload()
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxWebPushPayloadSize {
+		return fmt.Errorf("[WebPushAdapter] Payload exceeds %d bytes", maxWebPushPayloadSize)
+	}
+
+	maxRetry := w.config.MaxRetry
+	if req.Retry > 0 && req.Retry < maxRetry {
+		maxRetry = req.Retry
+	}
+
+	delivered, gone, failed, limited := w.retry(req, payload, 0, maxRetry)
+
+	// error of subscriptions which could be delivered by next attempt
+	var sendErr error
+	switch {
+	case limited != nil:
+		sendErr = limited
+	case failed > 0:
+		sendErr = fmt.Errorf("[WebPushAdapter] Failed deliver to %d subscriptions", failed)
+	case delivered == 0 && len(gone) < len(req.Subscriptions):
+		sendErr = errors.New("[WebPushAdapter] Push service rejected every subscription")
+	}
+
+	if len(gone) > 0 {
+		return &domain.InvalidTokensError{Tokens: gone, Delivered: delivered, Err: sendErr}
+	}
+
+	return sendErr
+}
+
+// retry returns count of delivered subscriptions, endpoints which are gone, count of subscriptions failed
+// after all attempts and throttling error if push service still rate limited us on last attempt
+func (w *WebPushAdapter) retry(req *domain.WebPushNotification, payload []byte, retryCount, maxRetry int) (int, []string, int, *domain.RateLimitError) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		delivered int
+		gone      []string
+		failed    []*domain.WebPushSubscription
+		limited   *domain.RateLimitError
+	)
+
+	for _, sub := range req.Subscriptions {
+		// occupy push slot
+		w.slots <- struct{}{}
+		wg.Add(1)
+		go func(sub *domain.WebPushSubscription) {
+			defer func() {
+				// free push slot
+				<-w.slots
+				wg.Done()
+			}()
+
+			status, err := w.push(req, sub, payload)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case err == nil:
+				delivered++
+				w.saveLogs("success_push", sub.Endpoint, req, nil)
+			// subscription expired or unsubscribed, should be removed
+			// ref: https://www.rfc-editor.org/rfc/rfc8030#section-7.3
+			case status == http.StatusNotFound || status == http.StatusGone:
+				gone = append(gone, sub.Endpoint)
+				w.saveLogs("expired_push", sub.Endpoint, req, err)
+			// network errors, throttling and push service errors are retryable
+			case status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError:
+				var rateLimited *domain.RateLimitError
+				if errors.As(err, &rateLimited) && (limited == nil || rateLimited.RetryAfter > limited.RetryAfter) {
+					limited = rateLimited
+				}
+				failed = append(failed, sub)
+				w.saveLogs("fail_push", sub.Endpoint, req, err)
+			default:
+				w.saveLogs("fail_push", sub.Endpoint, req, err)
+			}
+		}(sub)
+	}
+
+	wg.Wait()
+
+	if len(failed) > 0 && retryCount < maxRetry {
+		retryCount++
+		var limitErr error
+		if limited != nil {
+			limitErr = limited
+		}
+		metrics.Retried(domain.ProviderWebPush, limitErr)
+		time.Sleep(retryDelay(retryCount, limitErr))
+
+		// resend failed subscriptions
+		retryReq := *req
+		retryReq.Subscriptions = failed
+		retryDelivered, retryGone, retryFailed, retryLimited := w.retry(&retryReq, payload, retryCount, maxRetry)
+
+		return delivered + retryDelivered, append(gone, retryGone...), retryFailed, retryLimited
+	}
+
+	return delivered, gone, len(failed), limited
+}
+
+func (w *WebPushAdapter) push(req *domain.WebPushNotification, sub *domain.WebPushSubscription, payload []byte) (int, error) {
+	body, err := encryptWebPushPayload(sub, payload)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	auth, err := w.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	ttl := w.config.TTL
+	if req.TTL != nil {
+		ttl = *req.TTL
+	}
+
+	httpReq.Header.Set("Authorization", auth)
+	httpReq.Header.Set("Content-Encoding", "aes128gcm")
+	httpReq.Header.Set("Content-Type", "application/octet-stream")
+	httpReq.Header.Set("TTL", strconv.FormatUint(uint64(ttl), 10))
+
+	if req.Urgency != "" {
+		httpReq.Header.Set("Urgency", req.Urgency)
+	}
+
+	if req.Topic != "" {
+		httpReq.Header.Set("Topic", req.Topic)
+	}
+
+	res, err := w.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		return res.StatusCode, nil
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		return res.StatusCode, &domain.RateLimitError{
+			Provider:   domain.ProviderWebPush,
+			RetryAfter: domain.ParseRetryAfter(res.Header.Get("Retry-After")),
+		}
+	}
+
+	reason, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+
+	return res.StatusCode, fmt.Errorf("[WebPushAdapter] Push service responded %d: %s", res.StatusCode, reason)
+}
+
+// vapidAuthorization build (or reuse cached) VAPID header for push service origin
+// ref: https://www.rfc-editor.org/rfc/rfc8292#section-3
+func (w *WebPushAdapter) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	aud := u.Scheme + "://" + u.Host
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if t, ok := w.tokens[aud]; ok && time.Until(t.expiresAt) > vapidTokenRefresh {
+		return t.header, nil
+	}
+
+	expiresAt := time.Now().Add(vapidTokenTTL)
+	claims := jwt.MapClaims{
+		"aud": aud,
+		"exp": expiresAt.Unix(),
+	}
+	if w.config.Subject != "" {
+		claims["sub"] = w.config.Subject
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(w.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header := fmt.Sprintf("vapid t=%s, k=%s", signed, w.publicKey)
+	w.tokens[aud] = vapidToken{
+		header:    header,
+		expiresAt: expiresAt,
+	}
+
+	return header, nil
+}
+
+// encryptWebPushPayload encrypt payload with aes128gcm content coding as single record
+// ref: https://www.rfc-editor.org/rfc/rfc8291#section-3
+func encryptWebPushPayload(sub *domain.WebPushSubscription, plaintext []byte) ([]byte, error) {
+	uaPublicBytes, err := decodeBase64URL(sub.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("[WebPushAdapter] Bad p256dh key: %w", err)
+	}
+
+	authSecret, err := decodeBase64URL(sub.Keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("[WebPushAdapter] Bad auth secret: %w", err)
+	}
+
+	curve := ecdh.P256()
+	uaPublic, err := curve.NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("[WebPushAdapter] Bad p256dh key: %w", err)
+	}
+
+	// ephemeral application server key pair
+	asPrivate, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := make([]byte, 0, 14+len(uaPublicBytes)+len(asPublicBytes))
+	keyInfo = append(keyInfo, "WebPush: info\x00"...)
+	keyInfo = append(keyInfo, uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+
+	ikm, err := hkdfExpand(hkdf.Extract(sha256.New, ecdhSecret, authSecret), keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek, err := hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// last (and only) record is terminated with 0x02 padding delimiter
+	record := make([]byte, 0, len(plaintext)+1)
+	record = append(record, plaintext...)
+	record = append(record, 0x02)
+
+	header := make([]byte, webPushHeaderSize, webPushHeaderSize+len(record)+gcm.Overhead())
+	copy(header[0:16], salt)
+	binary.BigEndian.PutUint32(header[16:20], webPushRecordSize)
+	header[20] = byte(len(asPublicBytes))
+	copy(header[21:], asPublicBytes)
+
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+func hkdfExpand(prk, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func parseVAPIDPrivateKey(s string) (*ecdsa.PrivateKey, []byte, error) {
+	d, err := decodeBase64URL(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	priv, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// uncompressed point: 0x04 || X || Y
+	pub := priv.PublicKey().Bytes()
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:65]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}, pub, nil
+}
+
+// decodeBase64URL browsers give keys as unpadded base64url, but accept padded and std alphabet too
+func decodeBase64URL(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "+/") {
+		return base64.RawStdEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// TODO: save logs to storage
+func (w *WebPushAdapter) saveLogs(status, endpoint string, req *domain.WebPushNotification, err error) {
+	entry := log.WithContext(req.GetContext()).WithFields(log.Fields{
+		"notif_id": req.ID,
+		"status":   status,
+		"endpoint": endpoint,
+		"platform": domain.PlatFormWeb,
+	})
+
+	// expired subscriptions are pruned, nothing to act on
+	if status == "expired_push" {
+		entry.WithError(err).Warn("[WebPushAdapter] subscription expired")
+		return
+	}
+
+	if err != nil {
+		entry.WithError(err).Error("[WebPushAdapter] push failed")
+		return
+	}
+
+	entry.Debug("[WebPushAdapter] push sent")
+}
@@ -0,0 +1,340 @@
+package adapters
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/hkdf"
+)
+
+// decryptWebPushPayload decrypt aes128gcm record as user agent does
+// ref: https://www.rfc-editor.org/rfc/rfc8291#section-5
+func decryptWebPushPayload(t *testing.T, uaPrivate *ecdh.PrivateKey, authSecret, body []byte) []byte {
+	t.Helper()
+
+	if len(body) < webPushHeaderSize {
+		t.Fatalf("body of %d bytes is shorter than header", len(body))
+	}
+
+	salt := body[0:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != webPushRecordSize {
+		t.Fatalf("record size = %d, want %d", rs, webPushRecordSize)
+	}
+	if idlen := int(body[20]); idlen != 65 {
+		t.Fatalf("key id length = %d, want 65", idlen)
+	}
+
+	asPublic, err := ecdh.P256().NewPublicKey(body[21:86])
+	if err != nil {
+		t.Fatalf("bad application server key: %v", err)
+	}
+
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		t.Fatalf("ecdh: %v", err)
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPrivate.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublic.Bytes()...)
+
+	ikm, err := hkdfExpand(hkdf.Extract(sha256.New, ecdhSecret, authSecret), keyInfo, 32)
+	if err != nil {
+		t.Fatalf("ikm: %v", err)
+	}
+
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek, _ := hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce, _ := hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("gcm: %v", err)
+	}
+
+	record, err := gcm.Open(nil, nonce, body[webPushHeaderSize:], nil)
+	if err != nil {
+		t.Fatalf("open record: %v", err)
+	}
+
+	// last record is terminated with 0x02 delimiter
+	end := bytes.LastIndexByte(record, 0x02)
+	if end < 0 {
+		t.Fatal("record has no padding delimiter")
+	}
+
+	return record[:end]
+}
+
+func newSubscription(t *testing.T) (*domain.WebPushSubscription, *ecdh.PrivateKey, []byte) {
+	t.Helper()
+
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	authSecret := make([]byte, 16)
+	if _, err := rand.Read(authSecret); err != nil {
+		t.Fatalf("auth secret: %v", err)
+	}
+
+	return &domain.WebPushSubscription{
+		Endpoint: "https://push.example.com/send/abc",
+		Keys: domain.WebPushKeys{
+			P256dh: base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+			Auth:   base64.RawURLEncoding.EncodeToString(authSecret),
+		},
+	}, uaPrivate, authSecret
+}
+
+func TestEncryptWebPushPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{name: "empty", plaintext: []byte{}},
+		{name: "json", plaintext: []byte(`{"title":"Hello","body":"World"}`)},
+		{name: "max size", plaintext: bytes.Repeat([]byte("a"), maxWebPushPayloadSize)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, uaPrivate, authSecret := newSubscription(t)
+
+			body, err := encryptWebPushPayload(sub, tt.plaintext)
+			if err != nil {
+				t.Fatalf("encrypt: %v", err)
+			}
+
+			if len(body) > webPushRecordSize {
+				t.Fatalf("body of %d bytes exceeds record size", len(body))
+			}
+
+			if got := decryptWebPushPayload(t, uaPrivate, authSecret, body); !bytes.Equal(got, tt.plaintext) {
+				t.Fatalf("decrypted %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptWebPushPayloadBadKeys(t *testing.T) {
+	valid, _, _ := newSubscription(t)
+
+	tests := []struct {
+		name   string
+		p256dh string
+		auth   string
+	}{
+		{name: "p256dh not base64", p256dh: "!!!", auth: valid.Keys.Auth},
+		{name: "p256dh not a point", p256dh: base64.RawURLEncoding.EncodeToString([]byte("short")), auth: valid.Keys.Auth},
+		{name: "auth not base64", p256dh: valid.Keys.P256dh, auth: "!!!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &domain.WebPushSubscription{
+				Endpoint: valid.Endpoint,
+				Keys:     domain.WebPushKeys{P256dh: tt.p256dh, Auth: tt.auth},
+			}
+
+			if _, err := encryptWebPushPayload(sub, []byte("test")); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestDecodeBase64URL(t *testing.T) {
+	raw := []byte{0xfb, 0xff, 0xfe, 0x01}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "raw url", value: base64.RawURLEncoding.EncodeToString(raw)},
+		{name: "padded url", value: base64.URLEncoding.EncodeToString(raw)},
+		{name: "std", value: base64.StdEncoding.EncodeToString(raw)},
+		{name: "raw std", value: base64.RawStdEncoding.EncodeToString(raw)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeBase64URL(tt.value)
+			if err != nil {
+				t.Fatalf("decode %q: %v", tt.value, err)
+			}
+			if !bytes.Equal(got, raw) {
+				t.Fatalf("decoded %x, want %x", got, raw)
+			}
+		})
+	}
+}
+
+func TestVAPIDAuthorization(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	privateKey, pub, err := parseVAPIDPrivateKey(base64.RawURLEncoding.EncodeToString(key.D.FillBytes(make([]byte, 32))))
+	if err != nil {
+		t.Fatalf("parse private key: %v", err)
+	}
+
+	if want := elliptic.Marshal(elliptic.P256(), key.X, key.Y); !bytes.Equal(pub, want) {
+		t.Fatalf("derived public key %x, want %x", pub, want)
+	}
+
+	w := &WebPushAdapter{
+		config:     &configs.WebPushConfig{Subject: "mailto:ops@example.com"},
+		privateKey: privateKey,
+		publicKey:  base64.RawURLEncoding.EncodeToString(pub),
+		tokens:     make(map[string]vapidToken),
+	}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		aud      string
+	}{
+		{name: "fcm", endpoint: "https://fcm.googleapis.com/fcm/send/abc", aud: "https://fcm.googleapis.com"},
+		{name: "mozilla with port", endpoint: "https://updates.push.services.mozilla.com:443/wpush/v2/abc", aud: "https://updates.push.services.mozilla.com:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := w.vapidAuthorization(tt.endpoint)
+			if err != nil {
+				t.Fatalf("authorization: %v", err)
+			}
+
+			token, k, ok := strings.Cut(strings.TrimPrefix(header, "vapid t="), ", k=")
+			if !ok || !strings.HasPrefix(header, "vapid t=") {
+				t.Fatalf("malformed header %q", header)
+			}
+			if k != w.publicKey {
+				t.Fatalf("k = %q, want %q", k, w.publicKey)
+			}
+
+			claims := jwt.MapClaims{}
+			_, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+				return &key.PublicKey, nil
+			}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}))
+			if err != nil {
+				t.Fatalf("verify token: %v", err)
+			}
+
+			if claims["aud"] != tt.aud {
+				t.Fatalf("aud = %v, want %s", claims["aud"], tt.aud)
+			}
+			if claims["sub"] != "mailto:ops@example.com" {
+				t.Fatalf("sub = %v", claims["sub"])
+			}
+
+			again, err := w.vapidAuthorization(tt.endpoint)
+			if err != nil || again != header {
+				t.Fatal("token of the same origin is not reused")
+			}
+		})
+	}
+}
+
+func TestWebPushSendReportsDelivery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusCreated)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	w := &WebPushAdapter{
+		client:     server.Client(),
+		config:     &configs.WebPushConfig{Subject: "mailto:ops@example.com"},
+		slots:      make(chan struct{}, 2),
+		privateKey: key,
+		tokens:     make(map[string]vapidToken),
+	}
+
+	tests := []struct {
+		name      string
+		paths     []string
+		gone      int
+		delivered int
+		wantErr   bool
+		retryable bool
+	}{
+		{name: "delivered", paths: []string{"/ok", "/ok"}},
+		{name: "some gone", paths: []string{"/ok", "/gone"}, gone: 1, delivered: 1, wantErr: true},
+		{name: "every gone", paths: []string{"/gone", "/gone"}, gone: 2, wantErr: true},
+		{name: "gone and down", paths: []string{"/ok", "/gone", "/down"}, gone: 1, delivered: 1, wantErr: true, retryable: true},
+		{name: "down", paths: []string{"/ok", "/down"}, wantErr: true, retryable: true},
+		{name: "every rejected", paths: []string{"/bad"}, wantErr: true, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &domain.WebPushNotification{Title: "title"}
+			for _, path := range tt.paths {
+				sub, _, _ := newSubscription(t)
+				sub.Endpoint = server.URL + path
+				req.Subscriptions = append(req.Subscriptions, sub)
+			}
+
+			err := w.Send(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			if retryable := domain.IsRetryable(err); retryable != tt.retryable {
+				t.Fatalf("retryable = %v, want %v", retryable, tt.retryable)
+			}
+
+			var invalid *domain.InvalidTokensError
+			if !errors.As(err, &invalid) {
+				if tt.gone > 0 {
+					t.Fatalf("err = %v, want invalid tokens", err)
+				}
+				return
+			}
+
+			if len(invalid.Tokens) != tt.gone || invalid.Delivered != tt.delivered {
+				t.Fatalf("gone = %d, delivered = %d, want %d, %d", len(invalid.Tokens), invalid.Delivered, tt.gone, tt.delivered)
+			}
+		})
+	}
+}
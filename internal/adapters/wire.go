@@ -1,17 +1,24 @@
 package adapters
 
 import (
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
 	"github.com/google/wire"
 )
 
 var AdaptersSet = wire.NewSet(
 	NewHealthCheckAdapter,
+	wire.Bind(new(metrics.IHealthResults), new(*HealthCheckAdapter)),
+	NewCircuitBreakers,
 	NewFCMAdapter,
-	wire.Bind(new(IFCMAdapter), new(*FCMAdapter)),
+	NewFCMBreaker,
 	NewAPNAdapter,
-	wire.Bind(new(IAPNAdapter), new(*APNAdapter)),
-	NewSMSAdapter,
-	wire.Bind(new(ISMSAdapter), new(*SMSAdapter)),
-	NewSMTPAdapter,
-	wire.Bind(new(ISMTPAdapter), new(*SMTPAdapter)),
+	NewAPNBreaker,
+	NewWebPushAdapter,
+	NewWebPushBreaker,
+	NewHMSAdapter,
+	NewHMSBreaker,
+	NewSMSRouter,
+	wire.Bind(new(ISMSAdapter), new(*SMSRouter)),
+	NewSMTPRouter,
+	wire.Bind(new(ISMTPAdapter), new(*SMTPRouter)),
 )
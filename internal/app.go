@@ -1,18 +1,26 @@
 package app
 
 import (
-	"fmt"
 	"github.com/WildEgor/gNotifier/internal/configs"
 
 	"github.com/WildEgor/gNotifier/internal/adapters"
 	handlers_http "github.com/WildEgor/gNotifier/internal/handlers/http"
+	middleware "github.com/WildEgor/gNotifier/internal/middlewares"
 	"github.com/WildEgor/gNotifier/internal/repository"
 	"github.com/WildEgor/gNotifier/internal/routers"
+	"github.com/WildEgor/gNotifier/internal/services"
+	"github.com/WildEgor/gNotifier/internal/services/checks"
+	"github.com/WildEgor/gNotifier/internal/services/logging"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	"github.com/WildEgor/gNotifier/internal/services/scheduler"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/google/wire"
 	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var AppSet = wire.NewSet(
@@ -21,18 +29,46 @@ var AppSet = wire.NewSet(
 	repository.RepositoriesSet,
 	configs.ConfigSet,
 	routers.RoutersSet,
+	services.ServicesSet,
 )
 
 type Server struct {
 	App       *fiber.App
 	AppConfig *configs.AppConfig
+
+	amqpRouter         *routers.AMQPRouter
+	kafkaRouter        *routers.KafkaRouter
+	dispatcher         *scheduler.Dispatcher
+	recurringScheduler *scheduler.RecurringScheduler
+	digestFlusher      *scheduler.DigestFlusher
+	metricsCollector   *metrics.Collector
+	healthCheckAdapter *adapters.HealthCheckAdapter
+	realtimeHub        *realtime.Hub
+	apnAdapter         *adapters.APNAdapter
+	tracingProvider    *tracing.Provider
+	mongoClient        *mongo.Client
 }
 
+// NewApp build HTTP server and register health checks, everything is started by Run
 func NewApp(
 	appConfig *configs.AppConfig,
+	logConfig *configs.LogConfig,
 	httpRouter *routers.HTTPRouter,
 	amqpRouter *routers.AMQPRouter,
+	kafkaRouter *routers.KafkaRouter,
+	dispatcher *scheduler.Dispatcher,
+	recurringScheduler *scheduler.RecurringScheduler,
+	digestFlusher *scheduler.DigestFlusher,
+	metricsCollector *metrics.Collector,
+	healthChecks *checks.Registrar,
+	healthCheckAdapter *adapters.HealthCheckAdapter,
+	realtimeHub *realtime.Hub,
+	apnAdapter *adapters.APNAdapter,
+	tracingProvider *tracing.Provider,
+	mongoClient *mongo.Client,
 ) *Server {
+	logging.Configure(logConfig)
+
 	app := fiber.New(fiber.Config{
 		ErrorHandler: handlers_http.ErrorHandler,
 	})
@@ -44,22 +80,28 @@ func NewApp(
 		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
 	}))
 	app.Use(recover.New())
+	app.Use(middleware.Correlation(logConfig.CorrelationHeader))
+	app.Use(middleware.Tracing("/metrics", "/api/v1/health"))
 
-	if !appConfig.IsProduction() {
-		log.SetLevel(log.DebugLevel)
-	} else {
-		log.SetLevel(log.ErrorLevel)
+	if err := healthChecks.Register(); err != nil {
+		log.Error("[App] Failed register health checks: ", err)
 	}
 
 	httpRouter.SetupRoutes(app)
-	amqpRouter.SetupRoutes()
-
-	defer amqpRouter.Close()
-
-	log.Info(fmt.Sprintf("Application is running on %v port...", appConfig.Port))
 
 	return &Server{
-		App:       app,
-		AppConfig: appConfig,
+		App:                app,
+		AppConfig:          appConfig,
+		amqpRouter:         amqpRouter,
+		kafkaRouter:        kafkaRouter,
+		dispatcher:         dispatcher,
+		recurringScheduler: recurringScheduler,
+		digestFlusher:      digestFlusher,
+		metricsCollector:   metricsCollector,
+		healthCheckAdapter: healthCheckAdapter,
+		realtimeHub:        realtimeHub,
+		apnAdapter:         apnAdapter,
+		tracingProvider:    tracingProvider,
+		mongoClient:        mongoClient,
 	}
 }
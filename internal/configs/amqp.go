@@ -1,12 +1,49 @@
 package configs
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/caarlos0/env/v7"
 	log "github.com/sirupsen/logrus"
 )
 
+// Queue types of consumer queue
+const (
+	QueueTypeClassic = "classic"
+	QueueTypeQuorum  = "quorum"
+)
+
 type AMQPConfig struct {
 	URI string `env:"AMQP_URI"`
+	// ConsumerNames comma separated consumers of notifications, e.g. default,bulk. Consumer settings read from
+	// env vars prefixed with its name, e.g. BULK_AMQP_QUEUE, default consumer read without prefix
+	ConsumerNames string `env:"AMQP_CONSUMERS"`
+
+	Consumers []*AMQPConsumerConfig
+}
+
+// AMQPConsumerConfig queue consumer of notifications bound to exchange by routing keys
+type AMQPConsumerConfig struct {
+	Name            string
+	Exchange        string        `env:"AMQP_EXCHANGE"`
+	ExchangeType    string        `env:"AMQP_EXCHANGE_TYPE"`
+	ExchangeDurable bool          `env:"AMQP_EXCHANGE_DURABLE"`
+	Queue           string        `env:"AMQP_QUEUE"`
+	QueueDurable    bool          `env:"AMQP_QUEUE_DURABLE"`
+	QueueType       string        `env:"AMQP_QUEUE_TYPE"`
+	QueueMaxLength  int           `env:"AMQP_QUEUE_MAX_LENGTH"`
+	QueueMessageTTL time.Duration `env:"AMQP_QUEUE_MESSAGE_TTL"`
+	// QueueArgs comma separated extra queue arguments in key=value format, e.g. x-overflow=reject-publish
+	QueueArgs string `env:"AMQP_QUEUE_ARGS"`
+	// RoutingKeys comma separated routing keys queue bound with
+	RoutingKeys string `env:"AMQP_ROUTING_KEYS"`
+	// Prefetch deliveries broker sends ahead of acknowledgements
+	Prefetch int `env:"AMQP_PREFETCH"`
+	// Concurrency deliveries handled in parallel
+	Concurrency int `env:"AMQP_CONCURRENCY"`
 }
 
 func NewAMQPConfig(
@@ -18,5 +55,135 @@ func NewAMQPConfig(
 		log.Printf("%+v\n", err)
 	}
 
+	for _, name := range strings.Split(cfg.ConsumerNames, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		cfg.Consumers = append(cfg.Consumers, NewAMQPConsumerConfig(name))
+	}
+
+	if len(cfg.Consumers) == 0 {
+		cfg.Consumers = append(cfg.Consumers, NewAMQPConsumerConfig(DefaultProvider))
+	}
+
+	return &cfg
+}
+
+// NewAMQPConsumerConfig read settings of named consumer, e.g. BULK_AMQP_QUEUE for "bulk"
+func NewAMQPConsumerConfig(name string) *AMQPConsumerConfig {
+	cfg := AMQPConsumerConfig{
+		Name: name,
+	}
+
+	if err := env.Parse(&cfg, providerEnvOptions(name)); err != nil {
+		log.Printf("[AMQPConfig] %s: %+v\n", name, err)
+	}
+
+	if cfg.Exchange == "" {
+		cfg.Exchange = "notifications"
+	}
+
+	if cfg.ExchangeType == "" {
+		cfg.ExchangeType = "direct"
+	}
+
+	if cfg.Queue == "" {
+		cfg.Queue = "notifier-queue"
+		if name != DefaultProvider {
+			cfg.Queue = "notifier-" + name + "-queue"
+		}
+	}
+
+	if cfg.QueueType != QueueTypeQuorum {
+		cfg.QueueType = QueueTypeClassic
+	}
+
+	// quorum queues are always durable
+	if cfg.QueueType == QueueTypeQuorum {
+		cfg.QueueDurable = true
+	}
+
+	if cfg.RoutingKeys == "" {
+		cfg.RoutingKeys = "notifier.send-notification"
+	}
+
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = 10
+	}
+
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
 	return &cfg
 }
+
+// GetRoutingKeys returns routing keys queue bound with
+func (c *AMQPConsumerConfig) GetRoutingKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(c.RoutingKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// IsQuorum whether queue is declared as quorum one, by type or by x-queue-type argument
+func (c *AMQPConsumerConfig) IsQuorum() bool {
+	return c.GetQueueArgs()["x-queue-type"] == QueueTypeQuorum
+}
+
+// GetQueueArgs returns arguments queue declared with
+func (c *AMQPConsumerConfig) GetQueueArgs() map[string]interface{} {
+	args := make(map[string]interface{})
+
+	if c.QueueType == QueueTypeQuorum {
+		args["x-queue-type"] = QueueTypeQuorum
+	}
+
+	if c.QueueMaxLength > 0 {
+		args["x-max-length"] = c.QueueMaxLength
+	}
+
+	if c.QueueMessageTTL > 0 {
+		args["x-message-ttl"] = c.QueueMessageTTL.Milliseconds()
+	}
+
+	for _, item := range strings.Split(c.QueueArgs, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		key, value, err := parseQueueArg(item)
+		if err != nil {
+			log.Errorf("[AMQPConfig] Skip invalid queue argument %q: %v", item, err)
+			continue
+		}
+		args[key] = value
+	}
+
+	return args
+}
+
+// parseQueueArg parse key=value argument, numeric values passed as numbers
+func parseQueueArg(item string) (string, interface{}, error) {
+	key, value, ok := strings.Cut(item, "=")
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("expected key=value")
+	}
+
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return key, n, nil
+	}
+
+	if b, err := strconv.ParseBool(value); err == nil {
+		return key, b, nil
+	}
+
+	return key, value, nil
+}
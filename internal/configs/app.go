@@ -1,6 +1,9 @@
 package configs
 
 import (
+	"strings"
+	"time"
+
 	"github.com/caarlos0/env/v7"
 	log "github.com/sirupsen/logrus"
 )
@@ -10,8 +13,24 @@ type AppConfig struct {
 	Mode    string `env:"APP_MODE"`
 	GoEnv   string `env:"GO_ENV"`
 	Version string `env:"VERSION"`
+	// ShutdownTimeout how long notifications in progress are waited for on shutdown
+	ShutdownTimeout time.Duration `env:"APP_SHUTDOWN_TIMEOUT"`
+	// Transports comma separated transports notifications consumed from: amqp, kafka
+	Transports string `env:"APP_TRANSPORTS"`
+	// AdminToken bearer token of management API (send, inbox, preferences, contacts, schedules, lanes, throttle),
+	// subscriber routes accept realtime JWT of subscriber too
+	AdminToken string `env:"APP_ADMIN_TOKEN"`
+	// OpenTokenSecret HMAC secret of per-notification open tracking tokens, opens are accepted with admin
+	// token only if empty
+	OpenTokenSecret string `env:"APP_OPEN_TOKEN_SECRET"`
 }
 
+// Transports notifications consumed from
+const (
+	TransportAMQP  = "amqp"
+	TransportKafka = "kafka"
+)
+
 func NewAppConfig(
 	c *Configurator,
 ) *AppConfig {
@@ -29,6 +48,18 @@ func NewAppConfig(
 		cfg.Version = "local"
 	}
 
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+
+	if strings.TrimSpace(cfg.Transports) == "" {
+		cfg.Transports = TransportAMQP
+	}
+
+	if cfg.AdminToken == "" {
+		log.Warn("[AppConfig] Admin token not provided, management API is available to subscribers only")
+	}
+
 	return &cfg
 }
 
@@ -39,3 +70,14 @@ func (ac AppConfig) IsProduction() bool {
 
 	return true
 }
+
+// HasTransport whether notifications are consumed from transport
+func (ac AppConfig) HasTransport(name string) bool {
+	for _, transport := range strings.Split(ac.Transports, ",") {
+		if strings.TrimSpace(transport) == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type BreakerConfig struct {
+	// FailureThreshold consecutive provider failures circuit opened after
+	FailureThreshold int `env:"BREAKER_FAILURE_THRESHOLD"`
+	// OpenDuration provider not called for this period after circuit opened
+	OpenDuration time.Duration `env:"BREAKER_OPEN_DURATION"`
+	// HalfOpenProbes requests let through after open period, circuit closed if all of them succeeded
+	HalfOpenProbes int `env:"BREAKER_HALF_OPEN_PROBES"`
+}
+
+func NewBreakerConfig(c *Configurator) *BreakerConfig {
+	cfg := BreakerConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[BreakerConfig] %+v\n", err)
+	}
+
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = 5
+	}
+
+	if cfg.OpenDuration == 0 {
+		cfg.OpenDuration = 30 * time.Second
+	}
+
+	if cfg.HalfOpenProbes == 0 {
+		cfg.HalfOpenProbes = 1
+	}
+
+	return &cfg
+}
@@ -0,0 +1,41 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type DigestConfig struct {
+	// Window notifications with the same digest key collected per subscriber for this period
+	Window time.Duration `env:"DIGEST_WINDOW"`
+	// MaxItems max items listed in digest, the rest only counted
+	MaxItems int `env:"DIGEST_MAX_ITEMS"`
+	// FlushCount digest sent before window ends as soon as it collected this many notifications
+	FlushCount int `env:"DIGEST_FLUSH_COUNT"`
+	// Template path to digest template, built-in list used if empty
+	Template string `env:"DIGEST_TEMPLATE"`
+}
+
+func NewDigestConfig(c *Configurator) *DigestConfig {
+	cfg := DigestConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[DigestConfig] %+v\n", err)
+	}
+
+	if cfg.Window == 0 {
+		cfg.Window = time.Hour
+	}
+
+	if cfg.MaxItems == 0 {
+		cfg.MaxItems = 20
+	}
+
+	if cfg.FlushCount == 0 {
+		cfg.FlushCount = 50
+	}
+
+	return &cfg
+}
@@ -0,0 +1,30 @@
+package configs
+
+import (
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type EventsConfig struct {
+	// Enabled publish status events of notifications, replies to reply_to are sent anyway
+	Enabled bool `env:"EVENTS_ENABLED" envDefault:"true"`
+	// Exchange topic exchange events published to with <channel>.<event> routing key, e.g. email.failed
+	Exchange string `env:"EVENTS_EXCHANGE"`
+	Durable  bool   `env:"EVENTS_EXCHANGE_DURABLE" envDefault:"true"`
+	// Persistent events survive broker restart in durable queues
+	Persistent bool `env:"EVENTS_PERSISTENT"`
+}
+
+func NewEventsConfig(c *Configurator) *EventsConfig {
+	cfg := EventsConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[EventsConfig] %+v\n", err)
+	}
+
+	if cfg.Exchange == "" {
+		cfg.Exchange = "notifications.events"
+	}
+
+	return &cfg
+}
@@ -0,0 +1,100 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+// Stores of frequency caps counters
+const (
+	FrequencyStoreMemory = "memory"
+	FrequencyStoreMongo  = "mongo"
+)
+
+// FrequencyCap at most Limit notifications of channel and category per recipient within Window
+type FrequencyCap struct {
+	Channel  string
+	Category string
+	Limit    int64
+	Window   time.Duration
+}
+
+func (c *FrequencyCap) String() string {
+	return fmt.Sprintf("%s:%s=%d/%s", c.Channel, c.Category, c.Limit, c.Window)
+}
+
+type FrequencyConfig struct {
+	// Rules comma separated caps in channel:category=limit/window format, "*" matches any channel or category,
+	// e.g. push:marketing=3/24h,sms:*=5/1h
+	Rules string `env:"FREQUENCY_CAPS"`
+	// Store counters kept in: memory (per instance) or mongo (shared)
+	Store string `env:"FREQUENCY_CAPS_STORE"`
+	Caps  []*FrequencyCap
+}
+
+func NewFrequencyConfig(c *Configurator) *FrequencyConfig {
+	cfg := FrequencyConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[FrequencyConfig] %+v\n", err)
+	}
+
+	if cfg.Store == "" {
+		cfg.Store = FrequencyStoreMongo
+	}
+
+	for _, rule := range strings.Split(cfg.Rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		fc, err := parseFrequencyCap(rule)
+		if err != nil {
+			log.Errorf("[FrequencyConfig] Skip invalid rule %q: %v", rule, err)
+			continue
+		}
+		cfg.Caps = append(cfg.Caps, fc)
+	}
+
+	return &cfg
+}
+
+func parseFrequencyCap(rule string) (*FrequencyCap, error) {
+	target, limits, ok := strings.Cut(rule, "=")
+	if !ok {
+		return nil, fmt.Errorf("expected channel:category=limit/window")
+	}
+
+	channel, category, ok := strings.Cut(target, ":")
+	if !ok || channel == "" || category == "" {
+		return nil, fmt.Errorf("expected channel:category")
+	}
+
+	count, period, ok := strings.Cut(limits, "/")
+	if !ok {
+		return nil, fmt.Errorf("expected limit/window")
+	}
+
+	limit, err := strconv.ParseInt(count, 10, 64)
+	if err != nil || limit < 0 {
+		return nil, fmt.Errorf("invalid limit %q", count)
+	}
+
+	window, err := time.ParseDuration(period)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid window %q", period)
+	}
+
+	return &FrequencyCap{
+		Channel:  channel,
+		Category: category,
+		Limit:    limit,
+		Window:   window,
+	}, nil
+}
@@ -0,0 +1,91 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+// CheckPolicy how long check may run and whether its failure only reported (SkipOnErr) instead of
+// marking the whole service unavailable
+type CheckPolicy struct {
+	Timeout   time.Duration
+	SkipOnErr bool
+}
+
+type HealthConfig struct {
+	// Interval checks run in background every interval, health endpoints serve the last results
+	Interval time.Duration `env:"HEALTH_INTERVAL"`
+
+	MongoTimeout   time.Duration `env:"HEALTH_MONGO_TIMEOUT"`
+	MongoSkipOnErr bool          `env:"HEALTH_MONGO_SKIP_ON_ERR"`
+	SMTPTimeout    time.Duration `env:"HEALTH_SMTP_TIMEOUT"`
+	SMTPSkipOnErr  bool          `env:"HEALTH_SMTP_SKIP_ON_ERR" envDefault:"true"`
+	FCMTimeout     time.Duration `env:"HEALTH_FCM_TIMEOUT"`
+	FCMSkipOnErr   bool          `env:"HEALTH_FCM_SKIP_ON_ERR" envDefault:"true"`
+	APNsTimeout    time.Duration `env:"HEALTH_APNS_TIMEOUT"`
+	APNsSkipOnErr  bool          `env:"HEALTH_APNS_SKIP_ON_ERR" envDefault:"true"`
+	SMSTimeout     time.Duration `env:"HEALTH_SMS_TIMEOUT"`
+	SMSSkipOnErr   bool          `env:"HEALTH_SMS_SKIP_ON_ERR" envDefault:"true"`
+	// APNsCertExpiry APNs check fails if certificate expires within this period
+	APNsCertExpiry time.Duration `env:"HEALTH_APNS_CERT_EXPIRY"`
+}
+
+func NewHealthConfig(c *Configurator) *HealthConfig {
+	cfg := HealthConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[HealthConfig] %+v\n", err)
+	}
+
+	if cfg.Interval == 0 {
+		cfg.Interval = 15 * time.Second
+	}
+
+	if cfg.MongoTimeout == 0 {
+		cfg.MongoTimeout = 2 * time.Second
+	}
+
+	if cfg.SMTPTimeout == 0 {
+		cfg.SMTPTimeout = 10 * time.Second
+	}
+
+	if cfg.FCMTimeout == 0 {
+		cfg.FCMTimeout = 5 * time.Second
+	}
+
+	if cfg.APNsTimeout == 0 {
+		cfg.APNsTimeout = 5 * time.Second
+	}
+
+	if cfg.SMSTimeout == 0 {
+		cfg.SMSTimeout = 5 * time.Second
+	}
+
+	if cfg.APNsCertExpiry == 0 {
+		cfg.APNsCertExpiry = 14 * 24 * time.Hour
+	}
+
+	return &cfg
+}
+
+func (c *HealthConfig) Mongo() CheckPolicy {
+	return CheckPolicy{Timeout: c.MongoTimeout, SkipOnErr: c.MongoSkipOnErr}
+}
+
+func (c *HealthConfig) SMTP() CheckPolicy {
+	return CheckPolicy{Timeout: c.SMTPTimeout, SkipOnErr: c.SMTPSkipOnErr}
+}
+
+func (c *HealthConfig) FCM() CheckPolicy {
+	return CheckPolicy{Timeout: c.FCMTimeout, SkipOnErr: c.FCMSkipOnErr}
+}
+
+func (c *HealthConfig) APNs() CheckPolicy {
+	return CheckPolicy{Timeout: c.APNsTimeout, SkipOnErr: c.APNsSkipOnErr}
+}
+
+func (c *HealthConfig) SMS() CheckPolicy {
+	return CheckPolicy{Timeout: c.SMSTimeout, SkipOnErr: c.SMSSkipOnErr}
+}
@@ -0,0 +1,43 @@
+package configs
+
+import (
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type HMSConfig struct {
+	AppID string `env:"HMS_APP_ID"`
+	// ClientID OAuth client id, same as AppID for most of apps
+	ClientID     string `env:"HMS_CLIENT_ID"`
+	ClientSecret string `env:"HMS_CLIENT_SECRET"`
+	// AuthURL and PushURL could be overridden for local testing
+	AuthURL  string `env:"HMS_AUTH_URL"`
+	PushURL  string `env:"HMS_PUSH_URL"`
+	MaxRetry int    `env:"HMS_MAX_RETRY"`
+}
+
+func NewHMSConfig(c *Configurator) *HMSConfig {
+	cfg := HMSConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[HMSConfig] %+v\n", err)
+	}
+
+	if cfg.ClientID == "" {
+		cfg.ClientID = cfg.AppID
+	}
+
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = "https://oauth-login.cloud.huawei.com/oauth2/v3/token"
+	}
+
+	if cfg.PushURL == "" {
+		cfg.PushURL = "https://push-api.cloud.huawei.com"
+	}
+
+	if cfg.MaxRetry == 0 {
+		cfg.MaxRetry = 5
+	}
+
+	return &cfg
+}
@@ -0,0 +1,33 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type IdempotencyConfig struct {
+	// TTL how long processed notification IDs remembered
+	TTL time.Duration `env:"IDEMPOTENCY_TTL"`
+	// LockTimeout notification in progress taken over by another worker after it (e.g. worker died)
+	LockTimeout time.Duration `env:"IDEMPOTENCY_LOCK_TIMEOUT"`
+}
+
+func NewIdempotencyConfig(c *Configurator) *IdempotencyConfig {
+	cfg := IdempotencyConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[IdempotencyConfig] %+v\n", err)
+	}
+
+	if cfg.TTL == 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+
+	if cfg.LockTimeout == 0 {
+		cfg.LockTimeout = 5 * time.Minute
+	}
+
+	return &cfg
+}
@@ -0,0 +1,104 @@
+package configs
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type KafkaConfig struct {
+	// Brokers comma separated addresses of brokers, e.g. localhost:9092
+	Brokers string `env:"KAFKA_BROKERS"`
+	// Topics comma separated topics notifications consumed from
+	Topics  string `env:"KAFKA_TOPICS"`
+	GroupID string `env:"KAFKA_GROUP_ID"`
+	// Consumers readers of consumer group, partitions are shared between them
+	Consumers int `env:"KAFKA_CONSUMERS"`
+	// RetryTopic prefix of retry topics, failed notifications republished to topic of their attempt
+	// (e.g. notifier.send-notification.retry.2) and consumed from it after backoff of attempt
+	RetryTopic string `env:"KAFKA_RETRY_TOPIC"`
+	// DeadLetterTopic notifications sent to when retries are exhausted or message is invalid
+	DeadLetterTopic string `env:"KAFKA_DLQ_TOPIC"`
+	MaxRetries      int    `env:"KAFKA_MAX_RETRIES"`
+	// RetryBackoff delay of first retry, every next retry waits longer by the same amount
+	RetryBackoff time.Duration `env:"KAFKA_RETRY_BACKOFF"`
+	// MaxWait how long broker holds fetch waiting for messages
+	MaxWait time.Duration `env:"KAFKA_MAX_WAIT"`
+}
+
+func NewKafkaConfig(c *Configurator) *KafkaConfig {
+	cfg := KafkaConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[KafkaConfig] %+v\n", err)
+	}
+
+	if cfg.Topics == "" {
+		cfg.Topics = "notifier.send-notification"
+	}
+
+	if cfg.GroupID == "" {
+		cfg.GroupID = "gnotifier"
+	}
+
+	if cfg.Consumers <= 0 {
+		cfg.Consumers = 1
+	}
+
+	if cfg.RetryTopic == "" {
+		cfg.RetryTopic = "notifier.send-notification.retry"
+	}
+
+	if cfg.DeadLetterTopic == "" {
+		cfg.DeadLetterTopic = "notifier.send-notification.dlq"
+	}
+
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 30 * time.Second
+	}
+
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = time.Second
+	}
+
+	return &cfg
+}
+
+// GetBrokers returns addresses of brokers
+func (c *KafkaConfig) GetBrokers() []string {
+	return splitList(c.Brokers)
+}
+
+// GetTopics returns topics notifications consumed from
+func (c *KafkaConfig) GetTopics() []string {
+	return splitList(c.Topics)
+}
+
+// GetRetryTopic returns topic of retry attempt, every attempt has own topic so messages of topic wait
+// the same backoff and are due in order they were republished
+func (c *KafkaConfig) GetRetryTopic(attempt int) string {
+	return c.RetryTopic + "." + strconv.Itoa(attempt)
+}
+
+// GetRetryBackoff returns delay of retry attempt, every next attempt waits longer
+func (c *KafkaConfig) GetRetryBackoff(attempt int) time.Duration {
+	return time.Duration(attempt) * c.RetryBackoff
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
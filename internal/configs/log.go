@@ -0,0 +1,106 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+// Log output formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+type LogConfig struct {
+	// Format text or json
+	Format string `env:"LOG_FORMAT"`
+	// Level default level of modules, debug in develop mode and error in production if empty
+	Level string `env:"LOG_LEVEL"`
+	// Levels comma separated levels of modules in module=level format, module is log prefix without
+	// brackets, e.g. Pipeline=debug,FCMAdapter=warn
+	Levels string `env:"LOG_LEVELS"`
+	// Redact mask emails, phone numbers and tokens, drop message bodies
+	Redact bool `env:"LOG_REDACT" envDefault:"true"`
+	// CorrelationHeader HTTP header correlation ID taken from and returned in
+	CorrelationHeader string `env:"LOG_CORRELATION_HEADER"`
+
+	DefaultLevel log.Level
+	ModuleLevels map[string]log.Level
+}
+
+func NewLogConfig(c *Configurator, appConfig *AppConfig) *LogConfig {
+	cfg := LogConfig{
+		ModuleLevels: make(map[string]log.Level),
+	}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[LogConfig] %+v\n", err)
+	}
+
+	if cfg.Format != LogFormatJSON {
+		cfg.Format = LogFormatText
+	}
+
+	if cfg.CorrelationHeader == "" {
+		cfg.CorrelationHeader = "X-Correlation-ID"
+	}
+
+	cfg.DefaultLevel = log.ErrorLevel
+	if !appConfig.IsProduction() {
+		cfg.DefaultLevel = log.DebugLevel
+	}
+
+	if cfg.Level != "" {
+		level, err := log.ParseLevel(cfg.Level)
+		if err != nil {
+			log.Errorf("[LogConfig] Skip invalid level %q: %v", cfg.Level, err)
+		} else {
+			cfg.DefaultLevel = level
+		}
+	}
+
+	for _, rule := range strings.Split(cfg.Levels, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		module, level, err := parseModuleLevel(rule)
+		if err != nil {
+			log.Errorf("[LogConfig] Skip invalid rule %q: %v", rule, err)
+			continue
+		}
+		cfg.ModuleLevels[module] = level
+	}
+
+	return &cfg
+}
+
+// MaxLevel most verbose of default and module levels, logger must let such entries through
+func (c *LogConfig) MaxLevel() log.Level {
+	level := c.DefaultLevel
+	for _, l := range c.ModuleLevels {
+		if l > level {
+			level = l
+		}
+	}
+
+	return level
+}
+
+func parseModuleLevel(rule string) (string, log.Level, error) {
+	module, value, ok := strings.Cut(rule, "=")
+	if !ok || module == "" {
+		return "", 0, fmt.Errorf("expected module=level")
+	}
+
+	level, err := log.ParseLevel(strings.TrimSpace(value))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return strings.TrimSpace(module), level, nil
+}
@@ -0,0 +1,36 @@
+package configs
+
+import (
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type PreferencesConfig struct {
+	// QuietHoursBreakthrough categories delivered even within subscriber quiet hours
+	QuietHoursBreakthrough []string `env:"PREFERENCES_QUIET_HOURS_BREAKTHROUGH" envSeparator:","`
+}
+
+func NewPreferencesConfig(c *Configurator) *PreferencesConfig {
+	cfg := PreferencesConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[PreferencesConfig] %+v\n", err)
+	}
+
+	if len(cfg.QuietHoursBreakthrough) == 0 {
+		cfg.QuietHoursBreakthrough = []string{"security", "otp"}
+	}
+
+	return &cfg
+}
+
+// IsBreakthrough check category ignores quiet hours
+func (c *PreferencesConfig) IsBreakthrough(category string) bool {
+	for _, v := range c.QuietHoursBreakthrough {
+		if v == category {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+// PriorityLane queue with dedicated workers notifications of the same priority consumed from
+type PriorityLane struct {
+	Name    string
+	Workers int
+	// Pausable lane stops taking notifications while higher lanes are loaded
+	Pausable bool
+}
+
+type PriorityConfig struct {
+	// Rules comma separated lanes from highest to lowest in name=workers format, e.g. high=8,normal=4,low=2.
+	// Notifications are consumed from single queue if empty
+	Rules string `env:"PRIORITY_LANES"`
+	// DefaultLane lane of notifications without priority, lowest one if empty
+	DefaultLane string `env:"PRIORITY_DEFAULT_LANE"`
+	// CategoryRules comma separated lanes of categories in category=lane format, e.g. otp=high,marketing=low
+	CategoryRules string `env:"PRIORITY_CATEGORIES"`
+	// PausableLanes comma separated lanes paused while higher lanes are loaded, e.g. low
+	PausableLanes string `env:"PRIORITY_PAUSABLE_LANES"`
+	// PauseThreshold fraction of busy workers of higher lanes pausable lanes paused at
+	PauseThreshold float64 `env:"PRIORITY_PAUSE_THRESHOLD"`
+	// MaxPriority lane queues declared with x-max-priority, so message priority orders notifications within lane
+	MaxPriority int `env:"PRIORITY_MAX_PRIORITY"`
+	// Exchange direct exchange lane queues bound to, separate from intake exchange so lane messages never
+	// reach intake queues whatever their bindings are
+	Exchange string `env:"PRIORITY_EXCHANGE"`
+
+	Lanes      []*PriorityLane
+	Categories map[string]string
+}
+
+func NewPriorityConfig(c *Configurator) *PriorityConfig {
+	cfg := PriorityConfig{
+		Categories: make(map[string]string),
+	}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[PriorityConfig] %+v\n", err)
+	}
+
+	if cfg.PauseThreshold <= 0 || cfg.PauseThreshold > 1 {
+		cfg.PauseThreshold = 0.8
+	}
+
+	if cfg.Exchange == "" {
+		cfg.Exchange = "notifications.lanes"
+	}
+
+	if cfg.MaxPriority < 0 || cfg.MaxPriority > 255 {
+		cfg.MaxPriority = 0
+	}
+
+	pausable := make(map[string]bool)
+	for _, name := range strings.Split(cfg.PausableLanes, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			pausable[name] = true
+		}
+	}
+
+	for _, rule := range strings.Split(cfg.Rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		lane, err := parsePriorityLane(rule)
+		if err != nil {
+			log.Errorf("[PriorityConfig] Skip invalid lane %q: %v", rule, err)
+			continue
+		}
+		lane.Pausable = pausable[lane.Name]
+		cfg.Lanes = append(cfg.Lanes, lane)
+	}
+
+	if len(cfg.Lanes) == 0 {
+		return &cfg
+	}
+
+	if cfg.Lane(cfg.DefaultLane) == nil {
+		cfg.DefaultLane = cfg.Lanes[len(cfg.Lanes)-1].Name
+	}
+
+	for _, rule := range strings.Split(cfg.CategoryRules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		category, lane, ok := strings.Cut(rule, "=")
+		if !ok || category == "" || cfg.Lane(lane) == nil {
+			log.Errorf("[PriorityConfig] Skip invalid category rule %q", rule)
+			continue
+		}
+		cfg.Categories[category] = lane
+	}
+
+	return &cfg
+}
+
+// Enabled notifications are consumed through priority lanes
+func (c *PriorityConfig) Enabled() bool {
+	return len(c.Lanes) > 0
+}
+
+// Lane returns lane by name, nil if there is no such lane
+func (c *PriorityConfig) Lane(name string) *PriorityLane {
+	for _, lane := range c.Lanes {
+		if lane.Name == name {
+			return lane
+		}
+	}
+
+	return nil
+}
+
+func parsePriorityLane(rule string) (*PriorityLane, error) {
+	name, value, ok := strings.Cut(rule, "=")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("expected name=workers")
+	}
+
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		return nil, fmt.Errorf("invalid workers %q", value)
+	}
+
+	return &PriorityLane{
+		Name:    name,
+		Workers: workers,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type RealtimeConfig struct {
+	// JWTSecret HS256 secret of client tokens, token "sub" claim must be equal to subscriber ID
+	JWTSecret string `env:"REALTIME_JWT_SECRET"`
+	// AllowAnonymous allow subscribe without token (for local development only!)
+	AllowAnonymous bool `env:"REALTIME_ALLOW_ANONYMOUS"`
+	// Exchange fanout exchange used to deliver events to every instance
+	Exchange     string        `env:"REALTIME_EXCHANGE"`
+	PingInterval time.Duration `env:"REALTIME_PING_INTERVAL"`
+	// BufferSize events buffered per connection, slow clients miss events above it
+	BufferSize int `env:"REALTIME_BUFFER_SIZE"`
+}
+
+func NewRealtimeConfig(c *Configurator) *RealtimeConfig {
+	cfg := RealtimeConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[RealtimeConfig] %+v\n", err)
+	}
+
+	if cfg.JWTSecret == "" && !cfg.AllowAnonymous {
+		log.Warn("[RealtimeConfig] JWT secret not provided, realtime connections will be rejected")
+	}
+
+	if cfg.Exchange == "" {
+		cfg.Exchange = "notifications.realtime"
+	}
+
+	if cfg.PingInterval == 0 {
+		cfg.PingInterval = 30 * time.Second
+	}
+
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = 16
+	}
+
+	return &cfg
+}
+
+// Enabled check realtime connections could be accepted, otherwise events have nobody to be delivered to
+func (c *RealtimeConfig) Enabled() bool {
+	return c.JWTSecret != "" || c.AllowAnonymous
+}
@@ -0,0 +1,164 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultProvider provider configured with unprefixed env vars (e.g. SMS_BASE_URL)
+const DefaultProvider = "default"
+
+// ProviderRoute provider of channel, providers with lower Priority tried first and
+// traffic shared by Weight between providers of the same priority
+type ProviderRoute struct {
+	Name     string
+	Weight   int
+	Priority int
+}
+
+// RoutingRule destinations matching Pattern (phone prefix or email domain) routed to Provider first
+type RoutingRule struct {
+	Pattern  string
+	Provider string
+}
+
+type RoutingConfig struct {
+	// SMSProviders comma separated SMS gateways in name=weight[:priority] format, e.g. main=3,backup=1:1.
+	// Gateway settings read from env vars prefixed with its name, e.g. BACKUP_SMS_BASE_URL
+	SMSProviders string `env:"SMS_PROVIDERS"`
+	// SMSRules comma separated phone prefix routes in prefix=provider format, e.g. +7=backup
+	SMSRules string `env:"SMS_ROUTING_RULES"`
+	// SMTPProviders comma separated email providers in the same format, e.g. BACKUP_SMTP_HOST
+	SMTPProviders string `env:"SMTP_PROVIDERS"`
+	// SMTPRules comma separated email domain routes in domain=provider format, e.g. gmail.com=backup
+	SMTPRules string `env:"SMTP_ROUTING_RULES"`
+
+	SMS         []*ProviderRoute
+	SMSRouting  []*RoutingRule
+	SMTP        []*ProviderRoute
+	SMTPRouting []*RoutingRule
+}
+
+func NewRoutingConfig(c *Configurator) *RoutingConfig {
+	cfg := RoutingConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[RoutingConfig] %+v\n", err)
+	}
+
+	cfg.SMS = parseProviderRoutes(cfg.SMSProviders)
+	cfg.SMSRouting = parseRoutingRules(cfg.SMSRules, cfg.SMS)
+	cfg.SMTP = parseProviderRoutes(cfg.SMTPProviders)
+	cfg.SMTPRouting = parseRoutingRules(cfg.SMTPRules, cfg.SMTP)
+
+	return &cfg
+}
+
+// parseProviderRoutes returns configured providers, single default one if nothing configured
+func parseProviderRoutes(value string) []*ProviderRoute {
+	var routes []*ProviderRoute
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		route, err := parseProviderRoute(item)
+		if err != nil {
+			log.Errorf("[RoutingConfig] Skip invalid provider %q: %v", item, err)
+			continue
+		}
+		routes = append(routes, route)
+	}
+
+	if len(routes) == 0 {
+		routes = append(routes, &ProviderRoute{
+			Name:   DefaultProvider,
+			Weight: 1,
+		})
+	}
+
+	return routes
+}
+
+func parseProviderRoute(item string) (*ProviderRoute, error) {
+	name, value, hasWeight := strings.Cut(item, "=")
+	if name == "" {
+		return nil, fmt.Errorf("expected name=weight[:priority]")
+	}
+
+	route := &ProviderRoute{
+		Name:   name,
+		Weight: 1,
+	}
+
+	if !hasWeight {
+		return route, nil
+	}
+
+	weightValue, priorityValue, hasPriority := strings.Cut(value, ":")
+
+	weight, err := strconv.Atoi(weightValue)
+	if err != nil || weight < 0 {
+		return nil, fmt.Errorf("invalid weight %q", weightValue)
+	}
+	route.Weight = weight
+
+	if hasPriority {
+		priority, err := strconv.Atoi(priorityValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority %q", priorityValue)
+		}
+		route.Priority = priority
+	}
+
+	return route, nil
+}
+
+func parseRoutingRules(value string, routes []*ProviderRoute) []*RoutingRule {
+	var rules []*RoutingRule
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		pattern, provider, ok := strings.Cut(item, "=")
+		if !ok || pattern == "" || !hasProviderRoute(routes, provider) {
+			log.Errorf("[RoutingConfig] Skip invalid routing rule %q", item)
+			continue
+		}
+
+		rules = append(rules, &RoutingRule{
+			Pattern:  pattern,
+			Provider: provider,
+		})
+	}
+
+	return rules
+}
+
+func hasProviderRoute(routes []*ProviderRoute, name string) bool {
+	for _, route := range routes {
+		if route.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// providerEnvOptions env options of named provider settings, default provider read without prefix
+func providerEnvOptions(name string) env.Options {
+	if name == DefaultProvider {
+		return env.Options{}
+	}
+
+	return env.Options{Prefix: strings.ToUpper(name) + "_"}
+}
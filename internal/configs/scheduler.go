@@ -0,0 +1,51 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type SchedulerConfig struct {
+	// PollInterval how often store checked for due notifications
+	PollInterval time.Duration `env:"SCHEDULER_POLL_INTERVAL"`
+	// LockTimeout claimed notification released to other instances if not sent within it
+	LockTimeout time.Duration `env:"SCHEDULER_LOCK_TIMEOUT"`
+	// BatchSize max notifications dispatched per poll
+	BatchSize int `env:"SCHEDULER_BATCH_SIZE"`
+	// MaxAttempts failed notification dispatched at most this many times before it is dropped
+	MaxAttempts int `env:"SCHEDULER_MAX_ATTEMPTS"`
+	// RetryBackoff failed notification dispatched again after attempts times this delay
+	RetryBackoff time.Duration `env:"SCHEDULER_RETRY_BACKOFF"`
+}
+
+func NewSchedulerConfig(c *Configurator) *SchedulerConfig {
+	cfg := SchedulerConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[SchedulerConfig] %+v\n", err)
+	}
+
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 10 * time.Second
+	}
+
+	if cfg.LockTimeout == 0 {
+		cfg.LockTimeout = 5 * time.Minute
+	}
+
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 100
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Minute
+	}
+
+	return &cfg
+}
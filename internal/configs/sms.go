@@ -20,3 +20,14 @@ func NewSMSConfig(c *Configurator) *SMSConfig {
 
 	return &cfg
 }
+
+// NewSMSProviderConfig read settings of named SMS gateway, e.g. BACKUP_SMS_BASE_URL for "backup"
+func NewSMSProviderConfig(name string) *SMSConfig {
+	cfg := SMSConfig{}
+
+	if err := env.Parse(&cfg, providerEnvOptions(name)); err != nil {
+		log.Printf("[SMSConfig] %s: %+v\n", name, err)
+	}
+
+	return &cfg
+}
@@ -22,3 +22,14 @@ func NewSMTPConfig(c *Configurator) *SMTPConfig {
 
 	return &cfg
 }
+
+// NewSMTPProviderConfig read settings of named email provider, e.g. BACKUP_SMTP_HOST for "backup"
+func NewSMTPProviderConfig(name string) *SMTPConfig {
+	cfg := SMTPConfig{}
+
+	if err := env.Parse(&cfg, providerEnvOptions(name)); err != nil {
+		log.Printf("[SMTPConfig] %s: %+v\n", name, err)
+	}
+
+	return &cfg
+}
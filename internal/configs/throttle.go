@@ -0,0 +1,94 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProviderLimit token bucket of provider: Rate recipients per second with bursts up to Burst
+type ProviderLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type ThrottleConfig struct {
+	// Rules comma separated limits in provider=rate[:burst] format, e.g. fcm=500,sms=10:20,smtp=5. SMS and SMTP
+	// gateways are throttled separately, by own limit (e.g. sms:twilio=5) or limit of channel
+	Rules string `env:"THROTTLE_LIMITS"`
+	// Penalty provider paused for if it throttled us without Retry-After
+	Penalty time.Duration `env:"THROTTLE_PENALTY"`
+	// MinRateFactor lowest fraction of configured rate adaptive slow-down could reach
+	MinRateFactor float64 `env:"THROTTLE_MIN_RATE_FACTOR"`
+	Limits        map[string]*ProviderLimit
+}
+
+func NewThrottleConfig(c *Configurator) *ThrottleConfig {
+	cfg := ThrottleConfig{
+		Limits: make(map[string]*ProviderLimit),
+	}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[ThrottleConfig] %+v\n", err)
+	}
+
+	if cfg.Penalty == 0 {
+		cfg.Penalty = time.Second
+	}
+
+	if cfg.MinRateFactor <= 0 || cfg.MinRateFactor > 1 {
+		cfg.MinRateFactor = 0.1
+	}
+
+	for _, rule := range strings.Split(cfg.Rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		provider, limit, err := parseProviderLimit(rule)
+		if err != nil {
+			log.Errorf("[ThrottleConfig] Skip invalid rule %q: %v", rule, err)
+			continue
+		}
+		cfg.Limits[provider] = limit
+	}
+
+	return &cfg
+}
+
+func parseProviderLimit(rule string) (string, *ProviderLimit, error) {
+	provider, value, ok := strings.Cut(rule, "=")
+	if !ok || provider == "" {
+		return "", nil, fmt.Errorf("expected provider=rate[:burst]")
+	}
+
+	rateValue, burstValue, hasBurst := strings.Cut(value, ":")
+
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate <= 0 {
+		return "", nil, fmt.Errorf("invalid rate %q", rateValue)
+	}
+
+	limit := &ProviderLimit{
+		Rate:  rate,
+		Burst: int(rate),
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	if hasBurst {
+		burst, err := strconv.Atoi(burstValue)
+		if err != nil || burst < 1 {
+			return "", nil, fmt.Errorf("invalid burst %q", burstValue)
+		}
+		limit.Burst = burst
+	}
+
+	return provider, limit, nil
+}
@@ -0,0 +1,53 @@
+package configs
+
+import (
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+// Samplers of traces, names follow OTEL_TRACES_SAMPLER values
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedAlwaysOn     = "parentbased_always_on"
+	SamplerParentBasedAlwaysOff    = "parentbased_always_off"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
+)
+
+type TracingConfig struct {
+	ServiceName string `env:"OTEL_SERVICE_NAME"`
+	// Endpoint OTLP/HTTP collector URL, e.g. http://otel-collector:4318. Spans are not exported if empty,
+	// trace context is still propagated
+	Endpoint string `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	// Sampler one of always_on, always_off, traceidratio, parentbased_always_on, parentbased_always_off,
+	// parentbased_traceidratio
+	Sampler string `env:"OTEL_TRACES_SAMPLER"`
+	// SamplerArg sampling ratio of traceidratio samplers
+	SamplerArg float64 `env:"OTEL_TRACES_SAMPLER_ARG" envDefault:"1"`
+	// Mongo trace Mongo commands
+	Mongo bool `env:"OTEL_MONGO_ENABLED" envDefault:"true"`
+}
+
+func NewTracingConfig(c *Configurator) *TracingConfig {
+	cfg := TracingConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[TracingConfig] %+v\n", err)
+	}
+
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "gNotifier"
+	}
+
+	if cfg.Sampler == "" {
+		cfg.Sampler = SamplerParentBasedAlwaysOn
+	}
+
+	return &cfg
+}
+
+// Enabled spans are exported
+func (c *TracingConfig) Enabled() bool {
+	return c.Endpoint != ""
+}
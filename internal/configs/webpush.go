@@ -0,0 +1,44 @@
+package configs
+
+import (
+	"github.com/caarlos0/env/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+type WebPushConfig struct {
+	// VAPIDPublicKey uncompressed P-256 public key (base64url)
+	VAPIDPublicKey string `env:"WEBPUSH_VAPID_PUBLIC_KEY"`
+	// VAPIDPrivateKey P-256 private key scalar (base64url)
+	VAPIDPrivateKey string `env:"WEBPUSH_VAPID_PRIVATE_KEY"`
+	// Subject contact of application server (mailto: or https: URL)
+	Subject       string `env:"WEBPUSH_SUBJECT"`
+	TTL           uint   `env:"WEBPUSH_TTL"`
+	MaxRetry      int    `env:"WEBPUSH_MAX_RETRY"`
+	MaxConcurrent int    `env:"WEBPUSH_MAX_CONCURRENT"`
+}
+
+func NewWebPushConfig(c *Configurator) *WebPushConfig {
+	cfg := WebPushConfig{}
+
+	if err := env.Parse(&cfg); err != nil {
+		log.Printf("[WebPushConfig] %+v\n", err)
+	}
+
+	if cfg.VAPIDPrivateKey == "" {
+		log.Warn("[WebPushConfig] VAPID keys not provided, web push disabled")
+	}
+
+	if cfg.TTL == 0 {
+		cfg.TTL = 86400
+	}
+
+	if cfg.MaxRetry == 0 {
+		cfg.MaxRetry = 5
+	}
+
+	if cfg.MaxConcurrent == 0 {
+		cfg.MaxConcurrent = 10
+	}
+
+	return &cfg
+}
@@ -8,7 +8,24 @@ var ConfigSet = wire.NewSet(
 	NewAMQPConfig,
 	NewFCMConfig,
 	NewAPNConfig,
+	NewWebPushConfig,
+	NewHMSConfig,
 	NewSMSConfig,
 	NewSMTPConfig,
 	NewMongoConfig,
+	NewRealtimeConfig,
+	NewPreferencesConfig,
+	NewSchedulerConfig,
+	NewIdempotencyConfig,
+	NewFrequencyConfig,
+	NewThrottleConfig,
+	NewDigestConfig,
+	NewRoutingConfig,
+	NewBreakerConfig,
+	NewHealthConfig,
+	NewTracingConfig,
+	NewLogConfig,
+	NewPriorityConfig,
+	NewEventsConfig,
+	NewKafkaConfig,
 )
@@ -1,7 +1,6 @@
 package domain
 
 import (
-	"errors"
 	"regexp"
 )
 
@@ -16,19 +15,19 @@ func ValidateEmailNotification(d *EmailNotification) error {
 
 	if d.Email == "" {
 		msg = "[EmailNotification] Email must defined"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	re := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
 	submatch := re.FindStringSubmatch(d.Email)
 	if len(submatch) < 2 {
 		msg = "[SMSNotification] Email incorrect format"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	if d.Subject == "" || d.Message == "" {
 		msg = "[SMSNotification] Provide Subject and Message"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	return nil
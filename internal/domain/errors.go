@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InvalidTokensError returned by push adapters when provider reports that tokens (or web push endpoints)
+// are expired or unregistered, so caller should prune them from the tokens store. Delivered is count of
+// tokens notification was delivered to, Err is error of other tokens if their delivery failed too
+type InvalidTokensError struct {
+	Tokens    []string
+	Delivered int
+	Err       error
+}
+
+func (e *InvalidTokensError) Error() string {
+	msg := fmt.Sprintf("[PushNotification] Invalid tokens: %s, delivered: %d", strings.Join(e.Tokens, ", "), e.Delivered)
+	if e.Err != nil {
+		msg += ", error: " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *InvalidTokensError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError returned by adapters if notification is not valid, provider is not called then
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ProviderError returned by adapters when provider answered with unsuccessful status, StatusCode is HTTP
+// status (or SMTP reply code) of the answer
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("[%s] Provider responded %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// RateLimitError returned by adapters when provider throttles requests (e.g. HTTP 429),
+// RetryAfter is zero if provider did not tell when to retry
+type RateLimitError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("[%s] Rate limited, retry after %s", e.Provider, e.RetryAfter)
+	}
+
+	return fmt.Sprintf("[%s] Rate limited", e.Provider)
+}
+
+// CircuitOpenError returned instead of calling provider while its circuit breaker is open (or circuits of
+// every provider of channel), RetryAfter is time left till provider probed again if known
+type CircuitOpenError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("[%s] Circuit is open, retry after %s", e.Provider, e.RetryAfter)
+	}
+
+	return fmt.Sprintf("[%s] Circuit is open", e.Provider)
+}
+
+// IsRetryable check notification failed with err could be sent by next attempt. Invalid notification and
+// invalid tokens fail the same way every time, unless delivery to other tokens failed with retryable error
+func IsRetryable(err error) bool {
+	var (
+		tokens  *InvalidTokensError
+		invalid *ValidationError
+	)
+
+	if errors.As(err, &tokens) {
+		return tokens.Err != nil && IsRetryable(tokens.Err)
+	}
+
+	return !errors.As(err, &invalid)
+}
+
+// ParseRetryAfter parse Retry-After header in seconds or HTTP-date format
+// ref: https://www.rfc-editor.org/rfc/rfc9110#field.retry-after
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
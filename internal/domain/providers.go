@@ -0,0 +1,11 @@
+package domain
+
+// Providers notifications delivered through
+const (
+	ProviderSMTP    = "smtp"
+	ProviderSMS     = "sms"
+	ProviderFCM     = "fcm"
+	ProviderAPNs    = "apns"
+	ProviderWebPush = "webpush"
+	ProviderHMS     = "hms"
+)
@@ -1,8 +1,8 @@
 package domain
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"strings"
 
 	"github.com/appleboy/go-fcm"
@@ -11,6 +11,8 @@ import (
 const (
 	PlatFormIos = iota + 1
 	PlatFormAndroid
+	PlatFormWeb
+	PlatformHuawei
 )
 
 type AnyData map[string]interface{}
@@ -75,6 +77,18 @@ type PushNotification struct {
 
 	// ref: https://github.com/sideshow/apns2/blob/54928d6193dfe300b6b88dad72b7e2ae138d4f0a/payload/builder.go#L7-L24
 	InterruptionLevel string `json:"interruption_level,omitempty"`
+
+	// Ctx context of request notification sent for, carries correlation ID to adapter logs
+	Ctx context.Context `json:"-"`
+}
+
+// GetContext returns context of request, background one if not set
+func (n *PushNotification) GetContext() context.Context {
+	if n.Ctx != nil {
+		return n.Ctx
+	}
+
+	return context.Background()
 }
 
 // ToBytes Bytes for queue message
@@ -104,24 +118,24 @@ func ValidatePushNotification(d *PushNotification) error {
 	// ignore send topic mesaage from FCM
 	if !d.IsTopic() && len(d.Tokens) == 0 && d.To == "" {
 		msg = "[PushNotification] The message must specify at least one registration ID"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	if len(d.Tokens) == PlatFormIos && d.Tokens[0] == "" {
 		msg = "[PushNotification] The token must not be empty"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	if d.Platform == PlatFormAndroid && len(d.Tokens) > 1000 {
 		msg = "[PushNotification] The message may specify at most 1000 registration IDs"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	// ref: https://firebase.google.com/docs/cloud-messaging/http-server-ref
 	if d.Platform == PlatFormAndroid && d.TimeToLive != nil && *d.TimeToLive > uint(2419200) {
 		msg = "[PushNotification] The message's TimeToLive field must be an integer " +
 			"between 0 and 2419200 (4 weeks)"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	return nil
@@ -1,7 +1,6 @@
 package domain
 
 import (
-	"errors"
 	"strconv"
 )
 
@@ -15,17 +14,17 @@ func ValidateSMSNotification(d *SMSNotification) error {
 
 	if d.Phone == "" {
 		msg = "[SMSNotification] Phone number must defined"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	if len(d.Phone) != 11 {
 		msg = "[SMSNotification] Phone number must 11 digits"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	if _, err := strconv.ParseInt(d.Phone, 10, 64); err != nil {
 		msg = "[SMSNotification] Parse error"
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	return nil
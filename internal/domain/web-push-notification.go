@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+)
+
+// Urgency values of Web Push message
+// ref: https://www.rfc-editor.org/rfc/rfc8030#section-5.3
+const (
+	WebPushUrgencyVeryLow = "very-low"
+	WebPushUrgencyLow     = "low"
+	WebPushUrgencyNormal  = "normal"
+	WebPushUrgencyHigh    = "high"
+)
+
+var webPushTopicRe = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// WebPushKeys browser PushSubscription keys (base64url encoded)
+type WebPushKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// WebPushSubscription is a browser PushSubscription object
+type WebPushSubscription struct {
+	Endpoint string      `json:"endpoint"`
+	Keys     WebPushKeys `json:"keys"`
+}
+
+type WebPushNotification struct {
+	ID            string                 `json:"notif_id,omitempty"`
+	Subscriptions []*WebPushSubscription `json:"subscriptions"`
+	Title         string                 `json:"title,omitempty"`
+	Message       string                 `json:"message,omitempty"`
+	Image         string                 `json:"image,omitempty"`
+	Data          AnyData                `json:"data,omitempty"`
+	TTL           *uint                  `json:"ttl,omitempty"`
+	Urgency       string                 `json:"urgency,omitempty"`
+	Topic         string                 `json:"topic,omitempty"`
+	Retry         int                    `json:"retry,omitempty"`
+	// Ctx context of request notification sent for, carries correlation ID to adapter logs
+	Ctx context.Context `json:"-"`
+}
+
+// GetContext returns context of request, background one if not set
+func (n *WebPushNotification) GetContext() context.Context {
+	if n.Ctx != nil {
+		return n.Ctx
+	}
+
+	return context.Background()
+}
+
+// Payload is a message delivered to service worker push event
+func (n *WebPushNotification) Payload() ([]byte, error) {
+	return json.Marshal(&struct {
+		ID    string  `json:"id,omitempty"`
+		Title string  `json:"title,omitempty"`
+		Body  string  `json:"body,omitempty"`
+		Image string  `json:"image,omitempty"`
+		Data  AnyData `json:"data,omitempty"`
+	}{
+		ID:    n.ID,
+		Title: n.Title,
+		Body:  n.Message,
+		Image: n.Image,
+		Data:  n.Data,
+	})
+}
+
+func ValidateWebPushNotification(d *WebPushNotification) error {
+	var msg string
+
+	if len(d.Subscriptions) == 0 {
+		msg = "[WebPushNotification] The message must specify at least one subscription"
+		return &ValidationError{Message: msg}
+	}
+
+	for _, s := range d.Subscriptions {
+		if s.Endpoint == "" || s.Keys.P256dh == "" || s.Keys.Auth == "" {
+			msg = "[WebPushNotification] Subscription must have endpoint, p256dh and auth keys"
+			return &ValidationError{Message: msg}
+		}
+	}
+
+	switch d.Urgency {
+	case "", WebPushUrgencyVeryLow, WebPushUrgencyLow, WebPushUrgencyNormal, WebPushUrgencyHigh:
+	default:
+		msg = "[WebPushNotification] Unknown urgency " + d.Urgency
+		return &ValidationError{Message: msg}
+	}
+
+	// ref: https://www.rfc-editor.org/rfc/rfc8030#section-5.4
+	if len(d.Topic) > 32 || !webPushTopicRe.MatchString(d.Topic) {
+		msg = "[WebPushNotification] Topic must be at most 32 url-safe base64 characters"
+		return &ValidationError{Message: msg}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package dtos
+
+import (
+	"errors"
+	"net/mail"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+)
+
+type UpdateContactsReqDto struct {
+	SubscriberID string    `json:"-"`
+	Email        string    `json:"email,omitempty"`
+	Phone        string    `json:"phone,omitempty"`
+	Error        error     `json:"-"`
+	TimeReqStart time.Time `json:"-"`
+}
+
+func (r *UpdateContactsReqDto) Validate() bool {
+	if len(r.SubscriberID) == 0 {
+		r.Error = errors.New("[UpdateContactsReqDto] Error pass sub_id param")
+		return false
+	}
+
+	if r.Email != "" {
+		if _, err := mail.ParseAddress(r.Email); err != nil {
+			r.Error = errors.New("[UpdateContactsReqDto] Invalid email " + r.Email)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *UpdateContactsReqDto) HasError() bool {
+	return r.Error != nil
+}
+
+func (r *UpdateContactsReqDto) ToModel() *models.ContactsModel {
+	return &models.ContactsModel{
+		SubID: r.SubscriberID,
+		Email: r.Email,
+		Phone: r.Phone,
+	}
+}
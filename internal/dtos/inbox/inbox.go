@@ -0,0 +1,64 @@
+package dtos
+
+import (
+	"errors"
+	"time"
+)
+
+type ListInboxReqDto struct {
+	SubscriberID string    `query:"-"`
+	Status       string    `query:"status"`
+	Cursor       string    `query:"cursor"`
+	Limit        int64     `query:"limit"`
+	Error        error     `json:"-"`
+	TimeReqStart time.Time `json:"-"`
+}
+
+func (r *ListInboxReqDto) Validate() bool {
+	if len(r.SubscriberID) == 0 {
+		r.Error = errors.New("[ListInboxReqDto] Error pass sub_id param")
+		return false
+	}
+
+	switch r.Status {
+	case "", "all", "unread", "read", "archived":
+	default:
+		r.Error = errors.New("[ListInboxReqDto] Unknown status " + r.Status)
+		return false
+	}
+
+	return true
+}
+
+func (r *ListInboxReqDto) HasError() bool {
+	return r.Error != nil
+}
+
+// InboxItemsReqDto selects subscriber inbox items, all items affected if IDs are empty
+type InboxItemsReqDto struct {
+	SubscriberID string    `json:"-"`
+	IDs          []string  `json:"ids"`
+	All          bool      `json:"all"`
+	Read         *bool     `json:"read,omitempty"`
+	Error        error     `json:"-"`
+	TimeReqStart time.Time `json:"-"`
+}
+
+func (r *InboxItemsReqDto) Validate() bool {
+	if len(r.SubscriberID) == 0 {
+		r.Error = errors.New("[InboxItemsReqDto] Error pass sub_id param")
+		return false
+	}
+
+	// protect from occasional update of whole inbox
+	if len(r.IDs) == 0 && !r.All {
+		r.Error = errors.New("[InboxItemsReqDto] Error pass ids or all param")
+		return false
+	}
+
+	return true
+}
+
+func (r *InboxItemsReqDto) HasError() bool {
+	return r.Error != nil
+}
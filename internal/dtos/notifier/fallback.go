@@ -0,0 +1,85 @@
+package dtos
+
+import (
+	"errors"
+	"time"
+)
+
+// Conditions notification falls back to next channel on
+const (
+	FallbackOnNoRecipient = "no_recipient"
+	FallbackOnFailed      = "failed"
+	FallbackOnNotOpened   = "not_opened"
+)
+
+type FallbackChannelDto struct {
+	Type string `json:"type"`
+	// Platform of push channel
+	Platform string `json:"platform,omitempty"`
+}
+
+type FallbackSettingDto struct {
+	// Channels ordered channels tried after notification type channel, addresses resolved from subscriber contacts
+	Channels []*FallbackChannelDto `json:"channels"`
+	// On conditions to move on to next channel, no_recipient and failed if empty
+	On []string `json:"on,omitempty"`
+	// OpenTimeout minutes delivered notification should be opened within (not_opened condition)
+	OpenTimeout int `json:"open_timeout,omitempty"`
+	// Step index of channel to try (0 is type channel), set by pipeline when chain resumed
+	Step int `json:"step,omitempty"`
+	// AwaitOpen chain resumed to check notification delivered on previous step was opened
+	AwaitOpen bool `json:"await_open,omitempty"`
+}
+
+func (f *FallbackSettingDto) Validate() error {
+	if len(f.Channels) == 0 {
+		return errors.New("[NotifierReqDto] Error pass fallback channels")
+	}
+
+	for _, c := range f.Channels {
+		if c == nil || (c.Type != "sms" && c.Type != "email" && c.Type != "push") {
+			return errors.New("[NotifierReqDto] Error fallback channel type")
+		}
+		if c.Type == "push" && c.Platform == "" {
+			return errors.New("[NotifierReqDto] Error pass fallback push platform")
+		}
+	}
+
+	for _, on := range f.On {
+		switch on {
+		case FallbackOnNoRecipient, FallbackOnFailed:
+		case FallbackOnNotOpened:
+			if f.OpenTimeout <= 0 {
+				return errors.New("[NotifierReqDto] Error pass fallback open_timeout")
+			}
+		default:
+			return errors.New("[NotifierReqDto] Unknown fallback condition " + on)
+		}
+	}
+
+	return nil
+}
+
+// FallsBackOn check notification should move on to next channel on condition
+func (f *FallbackSettingDto) FallsBackOn(condition string) bool {
+	if len(f.On) == 0 {
+		return condition == FallbackOnNoRecipient || condition == FallbackOnFailed
+	}
+
+	for _, on := range f.On {
+		if on == condition {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsLast check current step is the last channel of chain
+func (f *FallbackSettingDto) IsLast() bool {
+	return f.Step >= len(f.Channels)
+}
+
+func (f *FallbackSettingDto) GetOpenTimeout() time.Duration {
+	return time.Duration(f.OpenTimeout) * time.Minute
+}
@@ -1,18 +1,39 @@
 package dtos
 
 import (
+	"context"
 	"errors"
 	"time"
 )
 
-type NotifierResendRequestDto struct {
-	Req     NotifierPayloadDto `json:"request"`
-	Error   string             `json:"error"`
-	TimeReq string             `json:"time_req"`
-}
-
 type NotifierPayloadDto struct {
-	Type         string `json:"type"`
+	NotifID string `json:"notif_id,omitempty"`
+	// IdempotencyKey duplicates with the same key (or notif_id if empty) are not sent again
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	Type           string `json:"type"`
+	// SubscriberID is a unique ID tokens and inbox stored with (push_settings.to used if empty)
+	SubscriberID string `json:"sub_id,omitempty"`
+	Category     string `json:"category,omitempty"`
+	// Priority lane notification consumed through, e.g. high. Lane of category or default one is used if empty
+	Priority string `json:"priority,omitempty"`
+	// Inbox store notification to subscriber inbox
+	Inbox bool `json:"inbox,omitempty"`
+	// Realtime deliver notification to subscriber live connections (inbox items are delivered anyway)
+	Realtime bool `json:"realtime,omitempty"`
+	// BypassPreferences deliver notification regardless subscriber preferences (e.g. OTP, password reset)
+	BypassPreferences bool `json:"bypass_preferences,omitempty"`
+	// SendAt deliver notification at this time instead of right away
+	SendAt *time.Time `json:"send_at,omitempty"`
+	// DigestKey notifications with the same key collected per subscriber and sent as one digest
+	DigestKey string `json:"digest_key,omitempty"`
+	// Fallback channels tried one by one if notification was not delivered through type channel
+	Fallback     *FallbackSettingDto `json:"fallback,omitempty"`
+	InboxSetting struct {
+		Title     string      `json:"title,omitempty"`
+		Body      string      `json:"body,omitempty"`
+		Data      interface{} `json:"data,omitempty"`
+		ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+	} `json:"inbox_settings,omitempty"`
 	EmailSetting struct {
 		Email    string      `json:"email"`
 		Subject  string      `json:"subject"`
@@ -32,10 +53,91 @@ type NotifierPayloadDto struct {
 		Message  string      `json:"message,omitempty"`
 		Template string      `json:"template,omitempty"`
 		Data     interface{} `json:"data,omitempty"`
+		TTL      *uint       `json:"ttl,omitempty"`
+		Urgency  string      `json:"urgency,omitempty"`
+		Topic    string      `json:"topic,omitempty"`
 	} `json:"push_settings,omitempty"`
 	Data         interface{} `json:"data"`
 	Error        error       `json:"-"`
 	TimeReqStart time.Time   `json:"-"`
+	// Ctx carries trace of request (consumed message) through pipeline
+	Ctx context.Context `json:"-"`
+	// RetryIf tells failures transport retries itself, they are not final so no failed event is published
+	RetryIf func(err error) bool `json:"-"`
+}
+
+// GetContext returns context of request, background one if request is not traced
+func (r *NotifierPayloadDto) GetContext() context.Context {
+	if r.Ctx != nil {
+		return r.Ctx
+	}
+
+	return context.Background()
+}
+
+// GetIdempotencyKey returns key notification deduplicated by, empty if producer passed neither key nor ID
+func (r *NotifierPayloadDto) GetIdempotencyKey() string {
+	if r.IdempotencyKey != "" {
+		return r.IdempotencyKey
+	}
+
+	return r.NotifID
+}
+
+// GetSubscriberID returns subscriber whom notification addressed to
+func (r *NotifierPayloadDto) GetSubscriberID() string {
+	if r.SubscriberID != "" {
+		return r.SubscriberID
+	}
+
+	return r.PushSetting.To
+}
+
+// GetInboxContent returns title, body and data of inbox item, falls back to channel settings
+func (r *NotifierPayloadDto) GetInboxContent() (title, body string, data interface{}) {
+	title, body, data = r.InboxSetting.Title, r.InboxSetting.Body, r.InboxSetting.Data
+
+	switch {
+	case r.IsPush():
+		if title == "" {
+			title = r.PushSetting.Title
+		}
+		if body == "" {
+			body = r.PushSetting.Message
+		}
+		if data == nil {
+			data = r.PushSetting.Data
+		}
+	case r.IsEmail():
+		if title == "" {
+			title = r.EmailSetting.Subject
+		}
+		if body == "" {
+			body = r.EmailSetting.Text
+		}
+		if data == nil {
+			data = r.EmailSetting.Data
+		}
+	case r.IsSms():
+		if body == "" {
+			body = r.PhoneSetting.Text
+		}
+	}
+
+	if data == nil {
+		data = r.Data
+	}
+
+	return title, body, data
+}
+
+func (r *NotifierPayloadDto) ValidateSubscriber() bool {
+	if (r.Inbox || r.Realtime) && len(r.GetSubscriberID()) == 0 {
+		r.Error = errors.New("[NotifierReqDto] Error pass sub_id param for inbox or realtime")
+		return false
+	}
+
+	return true
 }
 
 func (r *NotifierPayloadDto) IsSms() bool {
@@ -62,6 +164,14 @@ func (r *NotifierPayloadDto) IsForIOS() bool {
 	return r.Type == "push" && r.PushSetting.Platform == "IOS"
 }
 
+func (r *NotifierPayloadDto) IsForWeb() bool {
+	return r.Type == "push" && r.PushSetting.Platform == "WEB"
+}
+
+func (r *NotifierPayloadDto) IsForHuawei() bool {
+	return r.Type == "push" && r.PushSetting.Platform == "HUAWEI"
+}
+
 func (r *NotifierPayloadDto) WithTemplate() bool {
 	return r.EmailSetting.Template != "" || r.PushSetting.Template != ""
 }
@@ -108,6 +218,19 @@ func (r *NotifierPayloadDto) ValidatePush() bool {
 	return true
 }
 
+func (r *NotifierPayloadDto) ValidateFallback() bool {
+	if r.Fallback == nil {
+		return true
+	}
+
+	if err := r.Fallback.Validate(); err != nil {
+		r.Error = err
+		return false
+	}
+
+	return true
+}
+
 func (r *NotifierPayloadDto) HasError() bool {
 	return r.Error != nil
 }
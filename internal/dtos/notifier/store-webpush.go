@@ -0,0 +1,42 @@
+package dtos
+
+import (
+	"errors"
+	"time"
+)
+
+// StoreWebPushReqDto holds browser PushSubscription (result of PushSubscription.toJSON())
+type StoreWebPushReqDto struct {
+	SubscriberID string `json:"sub_id"`
+	Subscription struct {
+		Endpoint       string `json:"endpoint"`
+		ExpirationTime *int64 `json:"expirationTime,omitempty"`
+		Keys           struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	} `json:"subscription"`
+	Error        error     `json:"-"`
+	TimeReqStart time.Time `json:"-"`
+}
+
+func (r *StoreWebPushReqDto) Validate() bool {
+	if len(r.SubscriberID) == 0 {
+		r.Error = errors.New("[StoreWebPushReqDto] Error pass sub_id param")
+		return false
+	}
+	if len(r.Subscription.Endpoint) == 0 {
+		r.Error = errors.New("[StoreWebPushReqDto] Error pass subscription endpoint")
+		return false
+	}
+	if len(r.Subscription.Keys.P256dh) == 0 || len(r.Subscription.Keys.Auth) == 0 {
+		r.Error = errors.New("[StoreWebPushReqDto] Error pass subscription keys")
+		return false
+	}
+
+	return true
+}
+
+func (r *StoreWebPushReqDto) HasError() bool {
+	return r.Error != nil
+}
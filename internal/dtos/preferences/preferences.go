@@ -0,0 +1,92 @@
+package dtos
+
+import (
+	"errors"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+)
+
+type UpdatePreferencesReqDto struct {
+	SubscriberID string     `json:"-"`
+	Muted        bool       `json:"muted"`
+	MutedUntil   *time.Time `json:"muted_until,omitempty"`
+	// Categories enabled channels per category, "*" matches any category or channel
+	Categories map[string]map[string]bool `json:"categories"`
+	// Timezone IANA name, e.g. Europe/Berlin
+	Timezone     string                    `json:"timezone,omitempty"`
+	QuietHours   []*models.QuietHoursModel `json:"quiet_hours,omitempty"`
+	Error        error                     `json:"-"`
+	TimeReqStart time.Time                 `json:"-"`
+}
+
+func (r *UpdatePreferencesReqDto) Validate() bool {
+	if len(r.SubscriberID) == 0 {
+		r.Error = errors.New("[UpdatePreferencesReqDto] Error pass sub_id param")
+		return false
+	}
+
+	for category, channels := range r.Categories {
+		if len(category) == 0 {
+			r.Error = errors.New("[UpdatePreferencesReqDto] Empty category")
+			return false
+		}
+
+		for channel := range channels {
+			switch channel {
+			case models.PreferencesAny, models.ChannelEmail, models.ChannelSMS, models.ChannelPush, models.ChannelInbox:
+			default:
+				r.Error = errors.New("[UpdatePreferencesReqDto] Unknown channel " + channel)
+				return false
+			}
+		}
+	}
+
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		r.Error = errors.New("[UpdatePreferencesReqDto] Unknown timezone " + r.Timezone)
+		return false
+	}
+
+	for _, w := range r.QuietHours {
+		if w == nil {
+			r.Error = errors.New("[UpdatePreferencesReqDto] Empty quiet hours")
+			return false
+		}
+
+		_, _, startErr := models.ParseClock(w.Start)
+		_, _, endErr := models.ParseClock(w.End)
+		if startErr != nil || endErr != nil {
+			r.Error = errors.New("[UpdatePreferencesReqDto] Quiet hours must be in HH:MM format")
+			return false
+		}
+
+		for _, d := range w.Days {
+			if d < time.Sunday || d > time.Saturday {
+				r.Error = errors.New("[UpdatePreferencesReqDto] Quiet hours day must be from 0 (Sunday) to 6")
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (r *UpdatePreferencesReqDto) HasError() bool {
+	return r.Error != nil
+}
+
+func (r *UpdatePreferencesReqDto) ToModel() *models.PreferencesModel {
+	categories := r.Categories
+	if categories == nil {
+		categories = map[string]map[string]bool{}
+	}
+
+	return &models.PreferencesModel{
+		SubID:      r.SubscriberID,
+		Muted:      r.Muted,
+		MutedUntil: r.MutedUntil,
+		Categories: categories,
+		Timezone:   r.Timezone,
+		QuietHours: r.QuietHours,
+	}
+}
@@ -0,0 +1,106 @@
+package dtos
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+)
+
+type RecurringReqDto struct {
+	ID       string               `json:"-"`
+	Name     string               `json:"name"`
+	Cron     string               `json:"cron"`
+	Timezone string               `json:"timezone,omitempty"`
+	Template string               `json:"template,omitempty"`
+	Audience models.AudienceModel `json:"audience"`
+	// Notification request every occurrence built from (sub_id and notif_id set per occurrence)
+	Notification json.RawMessage `json:"notification"`
+	StartAt      *time.Time      `json:"start_at,omitempty"`
+	EndAt        *time.Time      `json:"end_at,omitempty"`
+	Error        error           `json:"-"`
+	TimeReqStart time.Time       `json:"-"`
+}
+
+func (r *RecurringReqDto) Validate() bool {
+	if len(r.Name) == 0 {
+		r.Error = errors.New("[RecurringReqDto] Error pass name param")
+		return false
+	}
+
+	if len(r.Cron) == 0 {
+		r.Error = errors.New("[RecurringReqDto] Error pass cron param")
+		return false
+	}
+
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		r.Error = errors.New("[RecurringReqDto] Unknown timezone " + r.Timezone)
+		return false
+	}
+
+	if len(r.Audience.SubIDs) == 0 && len(r.Audience.Segment) == 0 {
+		r.Error = errors.New("[RecurringReqDto] Error pass audience sub_ids or segment")
+		return false
+	}
+
+	if r.StartAt != nil && r.EndAt != nil && !r.EndAt.After(*r.StartAt) {
+		r.Error = errors.New("[RecurringReqDto] End date must be after start date")
+		return false
+	}
+
+	notification := notifierDtos.NotifierPayloadDto{}
+	if err := json.Unmarshal(r.Notification, &notification); err != nil {
+		r.Error = errors.New("[RecurringReqDto] Error pass notification param")
+		return false
+	}
+
+	if !notification.ValidateType() {
+		r.Error = notification.Error
+		return false
+	}
+
+	return true
+}
+
+func (r *RecurringReqDto) HasError() bool {
+	return r.Error != nil
+}
+
+func (r *RecurringReqDto) ToModel() *models.RecurringScheduleModel {
+	return &models.RecurringScheduleModel{
+		Name:     r.Name,
+		Cron:     r.Cron,
+		Timezone: r.Timezone,
+		Template: r.Template,
+		Audience: r.Audience,
+		Payload:  string(r.Notification),
+		StartAt:  r.StartAt,
+		EndAt:    r.EndAt,
+		Status:   models.RecurringStatusActive,
+	}
+}
+
+type ListRecurringReqDto struct {
+	Status       string    `query:"status"`
+	Cursor       string    `query:"cursor"`
+	Limit        int64     `query:"limit"`
+	Error        error     `json:"-"`
+	TimeReqStart time.Time `json:"-"`
+}
+
+func (r *ListRecurringReqDto) Validate() bool {
+	switch r.Status {
+	case "", models.RecurringStatusActive, models.RecurringStatusPaused, models.RecurringStatusCompleted:
+	default:
+		r.Error = errors.New("[ListRecurringReqDto] Unknown status " + r.Status)
+		return false
+	}
+
+	return true
+}
+
+func (r *ListRecurringReqDto) HasError() bool {
+	return r.Error != nil
+}
@@ -0,0 +1,57 @@
+package dtos
+
+import (
+	"errors"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+)
+
+type ListScheduleReqDto struct {
+	SubscriberID string    `query:"sub_id"`
+	Reason       string    `query:"reason"`
+	Cursor       string    `query:"cursor"`
+	Limit        int64     `query:"limit"`
+	Error        error     `json:"-"`
+	TimeReqStart time.Time `json:"-"`
+}
+
+func (r *ListScheduleReqDto) Validate() bool {
+	switch r.Reason {
+	case "", models.ReasonSendAt, models.ReasonQuietHours:
+	default:
+		r.Error = errors.New("[ListScheduleReqDto] Unknown reason " + r.Reason)
+		return false
+	}
+
+	return true
+}
+
+func (r *ListScheduleReqDto) HasError() bool {
+	return r.Error != nil
+}
+
+type RescheduleReqDto struct {
+	NotifID      string     `json:"-"`
+	SendAt       *time.Time `json:"send_at"`
+	Error        error      `json:"-"`
+	TimeReqStart time.Time  `json:"-"`
+}
+
+func (r *RescheduleReqDto) Validate() bool {
+	if len(r.NotifID) == 0 {
+		r.Error = errors.New("[RescheduleReqDto] Error pass notif_id param")
+		return false
+	}
+
+	if r.SendAt == nil || r.SendAt.IsZero() {
+		r.Error = errors.New("[RescheduleReqDto] Error pass send_at param")
+		return false
+	}
+
+	return true
+}
+
+func (r *RescheduleReqDto) HasError() bool {
+	return r.Error != nil
+}
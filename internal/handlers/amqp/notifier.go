@@ -1,165 +1,195 @@
 package handlers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"html/template"
 	"time"
 
-	"github.com/WildEgor/gNotifier/internal/adapters"
 	"github.com/WildEgor/gNotifier/internal/domain"
 	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/services/events"
+	"github.com/WildEgor/gNotifier/internal/services/logging"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
 	log "github.com/sirupsen/logrus"
 	"github.com/wagslane/go-rabbitmq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxCircuitPause longest delay of notification while provider circuit is open
+const maxCircuitPause = 30 * time.Second
+
+// notifierReply result of notification sent to reply_to queue of caller
+type notifierReply struct {
+	*pipeline.Result
+	Error string `json:"error,omitempty"`
+}
+
 type NotifierHandler struct {
-	smtpAdapter adapters.ISMTPAdapter
-	smsAdapter  adapters.ISMSAdapter
-	fcmAdapter  adapters.IFCMAdapter
-	apnAdapter  adapters.IAPNAdapter
+	pipeline *pipeline.Pipeline
+	events   *events.Publisher
 }
 
 func NewNotifierHandler(
-	smtpAdapter adapters.ISMTPAdapter,
-	smsAdapter adapters.ISMSAdapter,
-	fcmAdapter adapters.IFCMAdapter,
-	apnAdapter adapters.IAPNAdapter,
+	pipeline *pipeline.Pipeline,
+	events *events.Publisher,
 ) *NotifierHandler {
 	return &NotifierHandler{
-		smtpAdapter: smtpAdapter,
-		smsAdapter:  smsAdapter,
-		fcmAdapter:  fcmAdapter,
-		apnAdapter:  apnAdapter,
+		pipeline: pipeline,
+		events:   events,
 	}
 }
 
-func (h *NotifierHandler) Handle(d rabbitmq.Delivery) rabbitmq.Action {
-	notifierRequest := h.parse(d.Body)
+// Handle run delivery through pipeline. Notification failed because of open provider circuit is returned
+// to queue after returned delay, so queue is not drained into failures. Invalid messages and other failures
+// are discarded, see discard
+func (h *NotifierHandler) Handle(d rabbitmq.Delivery) (rabbitmq.Action, time.Duration) {
+	ctx := logging.WithCorrelationID(context.Background(), correlationID(d))
+
+	// continue trace of producer passed in message headers
+	ctx, span := tracing.Tracer().Start(
+		tracing.ExtractAMQP(ctx, d.Headers),
+		d.Exchange+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", d.Exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", d.RoutingKey),
+			attribute.String("messaging.message.id", d.MessageId),
+		),
+	)
+	defer span.End()
+
+	notifierRequest := h.parse(ctx, d.Body)
 	if notifierRequest.HasError() {
-		log.Error("[NotifierHandler] error: ", notifierRequest.Error.Error())
-		return h.tryResend(notifierRequest)
+		log.WithContext(ctx).Error("[NotifierHandler] error: ", notifierRequest.Error.Error())
+		h.reply(ctx, d, &pipeline.Result{
+			NotifID: notifierRequest.NotifID,
+			Status:  models.StatusFailed,
+		}, notifierRequest.Error)
+		return h.discard(notifierRequest, notifierRequest.Error), 0
 	}
 
-	log.Debug("[NotifierHandler] consumed: %v\n", notifierRequest)
+	// notifications failed because of open circuit are retried, see pause
+	notifierRequest.RetryIf = isCircuitOpen
 
-	// Handle email notification
-	if notifierRequest.IsEmail() {
-		notification := domain.EmailNotification{
-			Email:   notifierRequest.EmailSetting.Email,
-			Message: notifierRequest.EmailSetting.Text,
-		}
+	log.WithContext(ctx).WithFields(log.Fields{
+		"notif_id": notifierRequest.NotifID,
+		"type":     notifierRequest.Type,
+	}).Debug("[NotifierHandler] consumed")
 
-		if notifierRequest.WithTemplate() {
-			msg, err := h.parseTemplate(notifierRequest)
-			if err != nil {
-				log.Error("[NotifierHandler] template parse error: ", err.Error())
-				return rabbitmq.Ack
-			}
-			notification.Message = msg
-		}
+	res := h.pipeline.Process(notifierRequest)
+	span.SetAttributes(
+		attribute.String("notification.id", res.NotifID),
+		attribute.String("notification.status", res.Status),
+	)
 
-		if err := h.smtpAdapter.Send(&notification); err != nil {
-			log.Error("[NotifierHandler] Failed send to: ", notifierRequest.EmailSetting.Email)
-			return h.tryResend(notifierRequest)
-		}
+	if res.Duplicate {
+		log.WithContext(ctx).Debugf("[NotifierHandler] duplicate of %s acknowledged", res.NotifID)
+		h.reply(ctx, d, res, nil)
+		return rabbitmq.Ack, 0
 	}
 
-	if notifierRequest.IsSms() {
-		notification := domain.SMSNotification{
-			Phone:   notifierRequest.PhoneSetting.Number,
-			Message: notifierRequest.PhoneSetting.Text,
+	if res.IsFailed() {
+		var open *domain.CircuitOpenError
+		if errors.As(res.Error, &open) {
+			// notification processed again, so caller gets reply of next attempt
+			h.pipeline.Requeued(notifierRequest, res)
+			return rabbitmq.NackRequeue, h.pause(ctx, open)
 		}
 
-		if err := h.smsAdapter.Send(&notification); err != nil {
-			log.Error("[NotifierHandler] Failed send to: ", notifierRequest.PhoneSetting.Number)
-			return h.tryResend(notifierRequest)
-		}
+		h.reply(ctx, d, res, res.Error)
+		return h.discard(notifierRequest, res.Error), 0
 	}
 
-	if notifierRequest.IsPush() {
+	log.WithContext(ctx).Debugf("[NotifierHandler] notification %s %s: %s", res.NotifID, res.Status, res.Reason)
+	h.reply(ctx, d, res, nil)
 
-		// TODO: find tokens by To and Platform as sub_id in mongodb and use only tokens updated_at or created_at >= now() - 30 days!
-		notification := domain.PushNotification{
-			ID:      "", // TODO
-			To:      "",
-			Tokens:  []string{""},
-			Topic:   notifierRequest.PushSetting.To,
-			Message: notifierRequest.PushSetting.Message,
-			Title:   notifierRequest.PushSetting.Title,
-		}
-
-		if notifierRequest.WithTemplate() {
-			msg, err := h.parseTemplate(notifierRequest)
-			if err != nil {
-				//
-			}
-			notification.Message = msg
-		}
-
-		if notifierRequest.IsForAndroid() {
-			notification.Platform = domain.PlatFormAndroid
+	return rabbitmq.Ack, 0
+}
 
-			if err := h.fcmAdapter.Send(&notification); err != nil {
-				// TODO
-			}
-		}
+// reply send result of notification to caller waiting on reply_to queue, so single request could be
+// made RPC-style. Reply is correlated by correlation_id of message
+func (h *NotifierHandler) reply(ctx context.Context, d rabbitmq.Delivery, res *pipeline.Result, err error) {
+	if d.ReplyTo == "" {
+		return
+	}
 
-		if notifierRequest.IsForIOS() {
-			notification.Platform = domain.PlatFormIos
-			if err := h.apnAdapter.Send(&notification); err != nil {
-				// TODO
-			}
-		}
+	reply := notifierReply{Result: res}
+	if err != nil {
+		reply.Error = err.Error()
 	}
 
-	return rabbitmq.Ack
+	if err := h.events.Reply(ctx, d.ReplyTo, d.CorrelationId, reply); err != nil {
+		log.WithContext(ctx).Warnf("[NotifierHandler] Failed reply to %s: %v", d.ReplyTo, err)
+	}
 }
 
-func (h *NotifierHandler) parse(b []byte) *notifierDtos.NotifierPayloadDto {
+func (h *NotifierHandler) parse(ctx context.Context, b []byte) *notifierDtos.NotifierPayloadDto {
+	_, span := tracing.Tracer().Start(ctx, "parse")
+
 	req := notifierDtos.NotifierPayloadDto{
 		TimeReqStart: time.Now(),
+		Ctx:          ctx,
 	}
 	if err := json.Unmarshal(b, &req); err != nil {
 		req.Error = err
 	}
 
 	req.ValidateType()
+	req.ValidateSubscriber()
+	req.ValidateFallback()
 
 	if req.IsSms() {
 		req.ValidateSms()
 	}
 
+	tracing.End(span, req.Error)
+
 	return &req
 }
 
-func (h *NotifierHandler) tryResend(req *notifierDtos.NotifierPayloadDto) rabbitmq.Action {
-	log.Error("[NotifierHandler] Error: %v\n", req.Error)
-	reqRes := notifierDtos.NotifierResendRequestDto{
-		Req:     *req,
-		Error:   req.Error.Error(),
-		TimeReq: time.Now().Sub(req.TimeReqStart).String(),
+// pause returns how long notification waits while provider circuit is open before it is consumed again.
+// Fallback chains route such notifications to other channels before
+func (h *NotifierHandler) pause(ctx context.Context, open *domain.CircuitOpenError) time.Duration {
+	wait := open.RetryAfter
+	if wait <= 0 || wait > maxCircuitPause {
+		wait = maxCircuitPause
 	}
-	// TODO: resend to error queue
 
-	time.Sleep(time.Millisecond * 18)
-	log.Debug("[NotifierHandler] execute task: ", time.Now().Sub(req.TimeReqStart).String(), reqRes)
-	return rabbitmq.Ack
+	log.WithContext(ctx).Warnf("[NotifierHandler] %v, notification delayed for %s", open, wait)
+
+	return wait
 }
 
-func (h *NotifierHandler) parseTemplate(req *notifierDtos.NotifierPayloadDto) (msg string, err error) {
-	tml, err := template.ParseFiles(req.EmailSetting.Template)
-	if err != nil {
-		req.Error = err
-		return "", errors.New("[NotifierHandler] Cannot parse template")
+func isCircuitOpen(err error) bool {
+	var open *domain.CircuitOpenError
+	return errors.As(err, &open)
+}
+
+// discard reject invalid message or failed notification, so it is moved to dead letter exchange of queue
+// if one is set in AMQP_QUEUE_ARGS (x-dead-letter-exchange) and dropped otherwise
+func (h *NotifierHandler) discard(req *notifierDtos.NotifierPayloadDto, err error) rabbitmq.Action {
+	log.WithContext(req.GetContext()).WithFields(log.Fields{
+		"notif_id": req.NotifID,
+		"duration": time.Since(req.TimeReqStart).String(),
+	}).Errorf("[NotifierHandler] notification discarded: %v", err)
+
+	return rabbitmq.NackDiscard
+}
+
+// correlationID of message is its ID or correlation ID set by producer, generated if message has neither
+func correlationID(d rabbitmq.Delivery) string {
+	if d.MessageId != "" {
+		return d.MessageId
 	}
 
-	buf := new(bytes.Buffer)
-	if err = tml.Execute(buf, req.Data); err != nil {
-		req.Error = err
-		return "", errors.New("[NotifierHandler] Cannot parse template")
+	if d.CorrelationId != "" {
+		return d.CorrelationId
 	}
 
-	return buf.String(), nil
+	return logging.NewCorrelationID()
 }
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GetContactsHandler struct {
+	contactsRepo mongo.IContactsRepository
+}
+
+func NewGetContactsHandler(
+	contactsRepo mongo.IContactsRepository,
+) *GetContactsHandler {
+	return &GetContactsHandler{
+		contactsRepo: contactsRepo,
+	}
+}
+
+// Handle Get subscriber contacts fallback channels use
+func (h *GetContactsHandler) Handle(ctx *fiber.Ctx) error {
+	subID := ctx.Params("sub_id")
+
+	contacts, err := h.contactsRepo.Find(ctx.UserContext(), subID)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[GetContactsHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot get contacts",
+			},
+		})
+	}
+
+	if contacts == nil {
+		contacts = &models.ContactsModel{
+			SubID: subID,
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": contacts,
+	})
+}
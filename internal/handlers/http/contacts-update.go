@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/contacts"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type UpdateContactsHandler struct {
+	contactsRepo mongo.IContactsRepository
+}
+
+func NewUpdateContactsHandler(
+	contactsRepo mongo.IContactsRepository,
+) *UpdateContactsHandler {
+	return &UpdateContactsHandler{
+		contactsRepo: contactsRepo,
+	}
+}
+
+// Handle Replace subscriber contacts
+func (h *UpdateContactsHandler) Handle(ctx *fiber.Ctx) error {
+	req := h.parseReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[UpdateContactsHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	contacts, err := h.contactsRepo.Upsert(ctx.UserContext(), req.ToModel())
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[UpdateContactsHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot update contacts",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": contacts,
+	})
+}
+
+func (h *UpdateContactsHandler) parseReq(ctx *fiber.Ctx) *dtos.UpdateContactsReqDto {
+	req := dtos.UpdateContactsReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		req.Error = err
+		return &req
+	}
+	req.SubscriberID = ctx.Params("sub_id")
+
+	req.Validate()
+
+	return &req
+}
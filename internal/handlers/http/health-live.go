@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthLiveHandler struct{}
+
+func NewHealthLiveHandler() *HealthLiveHandler {
+	return &HealthLiveHandler{}
+}
+
+// Handle Liveness probe: process serves requests. Dependencies are not checked here, so their outage
+// does not make orchestrator restart the service
+func (h *HealthLiveHandler) Handle(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"status":    "OK",
+			"timestamp": time.Now(),
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/adapters"
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthReadyHandler struct {
+	ha *adapters.HealthCheckAdapter
+}
+
+func NewHealthReadyHandler(
+	ha *adapters.HealthCheckAdapter,
+) *HealthReadyHandler {
+	return &HealthReadyHandler{
+		ha: ha,
+	}
+}
+
+// Handle Readiness probe: critical checks passed and consumer is attached, served from cached check results
+func (h *HealthReadyHandler) Handle(ctx *fiber.Ctx) error {
+	ready, reasons := h.ha.Ready()
+	if !ready {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"isOk": false,
+			"data": reasons,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"status":    "OK",
+			"timestamp": time.Now(),
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ArchiveInboxHandler struct {
+	inboxRepo mongo.IInboxRepository
+}
+
+func NewArchiveInboxHandler(
+	inboxRepo mongo.IInboxRepository,
+) *ArchiveInboxHandler {
+	return &ArchiveInboxHandler{
+		inboxRepo: inboxRepo,
+	}
+}
+
+// Handle Archive inbox items in bulk
+func (h *ArchiveInboxHandler) Handle(ctx *fiber.Ctx) error {
+	req := parseInboxItemsReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[ArchiveInboxHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	count, err := h.inboxRepo.Archive(ctx.UserContext(), req.SubscriberID, req.IDs)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[ArchiveInboxHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot archive items",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"modified": count,
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeleteInboxHandler struct {
+	inboxRepo mongo.IInboxRepository
+}
+
+func NewDeleteInboxHandler(
+	inboxRepo mongo.IInboxRepository,
+) *DeleteInboxHandler {
+	return &DeleteInboxHandler{
+		inboxRepo: inboxRepo,
+	}
+}
+
+// Handle Delete inbox items in bulk
+func (h *DeleteInboxHandler) Handle(ctx *fiber.Ctx) error {
+	req := parseInboxItemsReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[DeleteInboxHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	count, err := h.inboxRepo.Delete(ctx.UserContext(), req.SubscriberID, req.IDs)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[DeleteInboxHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot delete items",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"deleted": count,
+		},
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/inbox"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ListInboxHandler struct {
+	inboxRepo mongo.IInboxRepository
+}
+
+func NewListInboxHandler(
+	inboxRepo mongo.IInboxRepository,
+) *ListInboxHandler {
+	return &ListInboxHandler{
+		inboxRepo: inboxRepo,
+	}
+}
+
+// Handle List subscriber inbox with cursor pagination (newest first)
+func (h *ListInboxHandler) Handle(ctx *fiber.Ctx) error {
+	req := h.parseReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[ListInboxHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	items, next, err := h.inboxRepo.List(ctx.UserContext(), &models.InboxFilter{
+		SubID:  req.SubscriberID,
+		Status: req.Status,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[ListInboxHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot list inbox",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"items":       items,
+			"next_cursor": next,
+		},
+	})
+}
+
+func (h *ListInboxHandler) parseReq(ctx *fiber.Ctx) *dtos.ListInboxReqDto {
+	req := dtos.ListInboxReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := ctx.QueryParser(&req); err != nil {
+		req.Error = err
+		return &req
+	}
+	req.SubscriberID = ctx.Params("sub_id")
+
+	req.Validate()
+
+	return &req
+}
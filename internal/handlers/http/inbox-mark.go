@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/inbox"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MarkInboxHandler struct {
+	inboxRepo mongo.IInboxRepository
+}
+
+func NewMarkInboxHandler(
+	inboxRepo mongo.IInboxRepository,
+) *MarkInboxHandler {
+	return &MarkInboxHandler{
+		inboxRepo: inboxRepo,
+	}
+}
+
+// Handle Mark inbox items as read (or unread if "read": false passed) in bulk
+func (h *MarkInboxHandler) Handle(ctx *fiber.Ctx) error {
+	req := parseInboxItemsReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[MarkInboxHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	read := true
+	if req.Read != nil {
+		read = *req.Read
+	}
+
+	count, err := h.inboxRepo.MarkRead(ctx.UserContext(), req.SubscriberID, req.IDs, read)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[MarkInboxHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot mark items",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"modified": count,
+		},
+	})
+}
+
+func parseInboxItemsReq(ctx *fiber.Ctx) *dtos.InboxItemsReqDto {
+	req := dtos.InboxItemsReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		req.Error = err
+		return &req
+	}
+	req.SubscriberID = ctx.Params("sub_id")
+
+	req.Validate()
+
+	return &req
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type InboxUnreadHandler struct {
+	inboxRepo mongo.IInboxRepository
+}
+
+func NewInboxUnreadHandler(
+	inboxRepo mongo.IInboxRepository,
+) *InboxUnreadHandler {
+	return &InboxUnreadHandler{
+		inboxRepo: inboxRepo,
+	}
+}
+
+// Handle Count unread (and not archived) items of subscriber inbox
+func (h *InboxUnreadHandler) Handle(ctx *fiber.Ctx) error {
+	count, err := h.inboxRepo.CountUnread(ctx.UserContext(), ctx.Params("sub_id"))
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[InboxUnreadHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot count unread",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"unread": count,
+		},
+	})
+}
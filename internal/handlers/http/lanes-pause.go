@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/services/priority"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PauseLaneHandler struct {
+	lanes *priority.Lanes
+}
+
+func NewPauseLaneHandler(
+	lanes *priority.Lanes,
+) *PauseLaneHandler {
+	return &PauseLaneHandler{
+		lanes: lanes,
+	}
+}
+
+// Handle Pause priority lane, its notifications stay in queue till resumed. The highest lane could not be paused
+func (h *PauseLaneHandler) Handle(ctx *fiber.Ctx) error {
+	if err := h.lanes.Pause(ctx.Params("name")); err != nil {
+		log.WithContext(ctx.UserContext()).Error("[PauseLaneHandler] error: ", err.Error())
+
+		status := fiber.StatusNotFound
+		if errors.Is(err, priority.ErrLaneNotPausable) {
+			status = fiber.StatusConflict
+		}
+
+		return ctx.Status(status).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": h.lanes.Stats()[ctx.Params("name")],
+	})
+}
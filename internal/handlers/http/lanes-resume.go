@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/services/priority"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ResumeLaneHandler struct {
+	lanes *priority.Lanes
+}
+
+func NewResumeLaneHandler(
+	lanes *priority.Lanes,
+) *ResumeLaneHandler {
+	return &ResumeLaneHandler{
+		lanes: lanes,
+	}
+}
+
+// Handle Resume priority lane paused by operator
+func (h *ResumeLaneHandler) Handle(ctx *fiber.Ctx) error {
+	if err := h.lanes.Resume(ctx.Params("name")); err != nil {
+		log.WithContext(ctx.UserContext()).Error("[ResumeLaneHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": h.lanes.Stats()[ctx.Params("name")],
+	})
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/WildEgor/gNotifier/internal/services/priority"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LanesStatsHandler struct {
+	lanes *priority.Lanes
+}
+
+func NewLanesStatsHandler(
+	lanes *priority.Lanes,
+) *LanesStatsHandler {
+	return &LanesStatsHandler{
+		lanes: lanes,
+	}
+}
+
+// Handle Get priority lanes stats: workers, busy ones and whether lane is paused
+func (h *LanesStatsHandler) Handle(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": h.lanes.Stats(),
+	})
+}
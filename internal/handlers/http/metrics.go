@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+type MetricsHandler struct {
+	collector *metrics.Collector
+	handler   fasthttp.RequestHandler
+}
+
+func NewMetricsHandler(
+	collector *metrics.Collector,
+) *MetricsHandler {
+	return &MetricsHandler{
+		collector: collector,
+		handler:   fasthttpadaptor.NewFastHTTPHandler(promhttp.Handler()),
+	}
+}
+
+// Handle Get metrics in Prometheus exposition format
+func (h *MetricsHandler) Handle(ctx *fiber.Ctx) error {
+	h.handler(ctx.Context())
+
+	return nil
+}
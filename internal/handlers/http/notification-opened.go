@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationOpenedHandler struct {
+	statusesRepo mongo.IStatusesRepository
+}
+
+func NewNotificationOpenedHandler(
+	statusesRepo mongo.IStatusesRepository,
+) *NotificationOpenedHandler {
+	return &NotificationOpenedHandler{
+		statusesRepo: statusesRepo,
+	}
+}
+
+// Handle Track notification opened by recipient (e.g. push tapped), it stops fallback waiting for open
+func (h *NotificationOpenedHandler) Handle(ctx *fiber.Ctx) error {
+	status, err := h.statusesRepo.MarkOpened(ctx.UserContext(), ctx.Params("notif_id"))
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[NotificationOpenedHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot mark notification opened",
+			},
+		})
+	}
+
+	if status == nil {
+		return notificationNotFound(ctx)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": status,
+	})
+}
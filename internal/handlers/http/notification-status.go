@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationStatusHandler struct {
+	statusesRepo mongo.IStatusesRepository
+}
+
+func NewNotificationStatusHandler(
+	statusesRepo mongo.IStatusesRepository,
+) *NotificationStatusHandler {
+	return &NotificationStatusHandler{
+		statusesRepo: statusesRepo,
+	}
+}
+
+// Handle Get notification status, channel it was delivered through and history of attempts
+func (h *NotificationStatusHandler) Handle(ctx *fiber.Ctx) error {
+	status, err := h.statusesRepo.FindByNotifID(ctx.UserContext(), ctx.Params("notif_id"))
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[NotificationStatusHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot get status",
+			},
+		})
+	}
+
+	if status == nil {
+		return notificationNotFound(ctx)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": status,
+	})
+}
+
+func notificationNotFound(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"isOk": false,
+		"data": fiber.Map{
+			"message": "Notification not found",
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GetPreferencesHandler struct {
+	preferencesRepo mongo.IPreferencesRepository
+}
+
+func NewGetPreferencesHandler(
+	preferencesRepo mongo.IPreferencesRepository,
+) *GetPreferencesHandler {
+	return &GetPreferencesHandler{
+		preferencesRepo: preferencesRepo,
+	}
+}
+
+// Handle Get subscriber notification preferences (everything enabled if never set)
+func (h *GetPreferencesHandler) Handle(ctx *fiber.Ctx) error {
+	subID := ctx.Params("sub_id")
+
+	prefs, err := h.preferencesRepo.Find(ctx.UserContext(), subID)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[GetPreferencesHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot get preferences",
+			},
+		})
+	}
+
+	if prefs == nil {
+		prefs = &models.PreferencesModel{
+			SubID:      subID,
+			Categories: map[string]map[string]bool{},
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": prefs,
+	})
+}
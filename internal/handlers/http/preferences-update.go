@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/preferences"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type UpdatePreferencesHandler struct {
+	preferencesRepo mongo.IPreferencesRepository
+}
+
+func NewUpdatePreferencesHandler(
+	preferencesRepo mongo.IPreferencesRepository,
+) *UpdatePreferencesHandler {
+	return &UpdatePreferencesHandler{
+		preferencesRepo: preferencesRepo,
+	}
+}
+
+// Handle Replace subscriber notification preferences
+func (h *UpdatePreferencesHandler) Handle(ctx *fiber.Ctx) error {
+	req := h.parseReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[UpdatePreferencesHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	prefs, err := h.preferencesRepo.Upsert(ctx.UserContext(), req.ToModel())
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[UpdatePreferencesHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot update preferences",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": prefs,
+	})
+}
+
+func (h *UpdatePreferencesHandler) parseReq(ctx *fiber.Ctx) *dtos.UpdatePreferencesReqDto {
+	req := dtos.UpdatePreferencesReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		req.Error = err
+		return &req
+	}
+	req.SubscriberID = ctx.Params("sub_id")
+
+	req.Validate()
+
+	return &req
+}
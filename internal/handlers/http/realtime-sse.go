@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RealtimeSSEHandler struct {
+	hub *realtime.Hub
+}
+
+func NewRealtimeSSEHandler(
+	hub *realtime.Hub,
+) *RealtimeSSEHandler {
+	return &RealtimeSSEHandler{
+		hub: hub,
+	}
+}
+
+// Handle Authorize client and stream subscriber events as Server-Sent Events
+func (h *RealtimeSSEHandler) Handle(ctx *fiber.Ctx) error {
+	subID := ctx.Query("sub_id")
+	if err := h.hub.Authorize(realtimeToken(ctx), subID); err != nil {
+		log.WithContext(ctx.UserContext()).Error("[RealtimeSSEHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Unauthorized",
+			},
+		})
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		sub := h.hub.Subscribe(subID)
+		defer sub.Close()
+
+		ticker := time.NewTicker(h.hub.PingInterval())
+		defer ticker.Stop()
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				b, err := json.Marshal(e)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", e.ID, b)
+			case <-ticker.C:
+				// comment line keeps connection alive and detects closed clients
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+const realtimeWriteTimeout = 10 * time.Second
+
+type RealtimeWSHandler struct {
+	hub     *realtime.Hub
+	upgrade fiber.Handler
+}
+
+func NewRealtimeWSHandler(
+	hub *realtime.Hub,
+) *RealtimeWSHandler {
+	h := &RealtimeWSHandler{
+		hub: hub,
+	}
+	h.upgrade = websocket.New(h.serve)
+
+	return h
+}
+
+// Handle Authorize client and upgrade connection to WebSocket, subscriber events sent as JSON messages
+func (h *RealtimeWSHandler) Handle(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	subID := ctx.Query("sub_id")
+	if err := h.hub.Authorize(realtimeToken(ctx), subID); err != nil {
+		log.WithContext(ctx.UserContext()).Error("[RealtimeWSHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Unauthorized",
+			},
+		})
+	}
+
+	ctx.Locals("sub_id", subID)
+
+	return h.upgrade(ctx)
+}
+
+func (h *RealtimeWSHandler) serve(c *websocket.Conn) {
+	subID, _ := c.Locals("sub_id").(string)
+
+	sub := h.hub.Subscribe(subID)
+	defer sub.Close()
+
+	// read pump detects closed connection (client messages are ignored)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(h.hub.PingInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				_ = c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(realtimeWriteTimeout))
+				return
+			}
+			_ = c.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			if err := c.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.WriteControl(websocket.PingMessage, nil, time.Now().Add(realtimeWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// realtimeToken browsers cannot set headers for WebSocket and EventSource, so token accepted from query too
+func realtimeToken(ctx *fiber.Ctx) string {
+	if auth := ctx.Get(fiber.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	return ctx.Query("token")
+}
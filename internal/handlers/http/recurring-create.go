@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/recurring"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/scheduler"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateRecurringHandler struct {
+	recurringRepo mongo.IRecurringRepository
+}
+
+func NewCreateRecurringHandler(
+	recurringRepo mongo.IRecurringRepository,
+) *CreateRecurringHandler {
+	return &CreateRecurringHandler{
+		recurringRepo: recurringRepo,
+	}
+}
+
+// Handle Create recurring schedule
+func (h *CreateRecurringHandler) Handle(ctx *fiber.Ctx) error {
+	req := parseRecurringReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[CreateRecurringHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	m := req.ToModel()
+
+	next, err := scheduler.NextRun(m, time.Now())
+	if err != nil || next == nil {
+		log.WithContext(ctx.UserContext()).Error("[CreateRecurringHandler] error: no next occurrence ", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Invalid cron or schedule has no occurrences",
+			},
+		})
+	}
+	m.NextRunAt = next
+
+	item, err := h.recurringRepo.Create(ctx.UserContext(), m)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[CreateRecurringHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot create schedule",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"isOk": true,
+		"data": item,
+	})
+}
+
+func parseRecurringReq(ctx *fiber.Ctx) *dtos.RecurringReqDto {
+	req := dtos.RecurringReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		req.Error = err
+		return &req
+	}
+	req.ID = ctx.Params("id")
+
+	req.Validate()
+
+	return &req
+}
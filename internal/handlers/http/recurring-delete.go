@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeleteRecurringHandler struct {
+	recurringRepo mongo.IRecurringRepository
+}
+
+func NewDeleteRecurringHandler(
+	recurringRepo mongo.IRecurringRepository,
+) *DeleteRecurringHandler {
+	return &DeleteRecurringHandler{
+		recurringRepo: recurringRepo,
+	}
+}
+
+// Handle Delete recurring schedule
+func (h *DeleteRecurringHandler) Handle(ctx *fiber.Ctx) error {
+	deleted, err := h.recurringRepo.Delete(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[DeleteRecurringHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot delete schedule",
+			},
+		})
+	}
+
+	if !deleted {
+		return recurringNotFound(ctx)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"deleted": deleted,
+		},
+	})
+}
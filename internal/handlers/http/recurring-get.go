@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GetRecurringHandler struct {
+	recurringRepo mongo.IRecurringRepository
+}
+
+func NewGetRecurringHandler(
+	recurringRepo mongo.IRecurringRepository,
+) *GetRecurringHandler {
+	return &GetRecurringHandler{
+		recurringRepo: recurringRepo,
+	}
+}
+
+// Handle Get recurring schedule
+func (h *GetRecurringHandler) Handle(ctx *fiber.Ctx) error {
+	item, err := h.recurringRepo.FindByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[GetRecurringHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot get schedule",
+			},
+		})
+	}
+
+	if item == nil {
+		return recurringNotFound(ctx)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": item,
+	})
+}
+
+func recurringNotFound(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"isOk": false,
+		"data": fiber.Map{
+			"message": "Schedule not found",
+		},
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/recurring"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ListRecurringHandler struct {
+	recurringRepo mongo.IRecurringRepository
+}
+
+func NewListRecurringHandler(
+	recurringRepo mongo.IRecurringRepository,
+) *ListRecurringHandler {
+	return &ListRecurringHandler{
+		recurringRepo: recurringRepo,
+	}
+}
+
+// Handle List recurring schedules with cursor pagination
+func (h *ListRecurringHandler) Handle(ctx *fiber.Ctx) error {
+	req := h.parseReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[ListRecurringHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	items, next, err := h.recurringRepo.List(ctx.UserContext(), &models.RecurringFilter{
+		Status: req.Status,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[ListRecurringHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot list schedules",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"items":       items,
+			"next_cursor": next,
+		},
+	})
+}
+
+func (h *ListRecurringHandler) parseReq(ctx *fiber.Ctx) *dtos.ListRecurringReqDto {
+	req := dtos.ListRecurringReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := ctx.QueryParser(&req); err != nil {
+		req.Error = err
+		return &req
+	}
+
+	req.Validate()
+
+	return &req
+}
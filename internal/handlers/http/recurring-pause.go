@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PauseRecurringHandler struct {
+	recurringRepo mongo.IRecurringRepository
+}
+
+func NewPauseRecurringHandler(
+	recurringRepo mongo.IRecurringRepository,
+) *PauseRecurringHandler {
+	return &PauseRecurringHandler{
+		recurringRepo: recurringRepo,
+	}
+}
+
+// Handle Pause recurring schedule, occurrences are not fired till resumed
+func (h *PauseRecurringHandler) Handle(ctx *fiber.Ctx) error {
+	return setRecurringStatus(ctx, h.recurringRepo, "[PauseRecurringHandler]", func(m *models.RecurringScheduleModel) error {
+		m.Status = models.RecurringStatusPaused
+		return nil
+	})
+}
+
+// setRecurringStatus apply change to schedule unless it completed
+func setRecurringStatus(
+	ctx *fiber.Ctx,
+	recurringRepo mongo.IRecurringRepository,
+	prefix string,
+	change func(m *models.RecurringScheduleModel) error,
+) error {
+	m, err := recurringRepo.FindByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error(prefix+" error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot update schedule",
+			},
+		})
+	}
+
+	if m == nil {
+		return recurringNotFound(ctx)
+	}
+
+	if m.Status == models.RecurringStatusCompleted {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Schedule is completed",
+			},
+		})
+	}
+
+	if err := change(m); err != nil {
+		log.WithContext(ctx.UserContext()).Error(prefix+" error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot update schedule",
+			},
+		})
+	}
+
+	m.UpdatedAt = time.Now()
+
+	item, err := recurringRepo.Update(ctx.UserContext(), m)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error(prefix+" error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot update schedule",
+			},
+		})
+	}
+
+	if item == nil {
+		return recurringNotFound(ctx)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": item,
+	})
+}
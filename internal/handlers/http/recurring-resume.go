@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/scheduler"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ResumeRecurringHandler struct {
+	recurringRepo mongo.IRecurringRepository
+}
+
+func NewResumeRecurringHandler(
+	recurringRepo mongo.IRecurringRepository,
+) *ResumeRecurringHandler {
+	return &ResumeRecurringHandler{
+		recurringRepo: recurringRepo,
+	}
+}
+
+// Handle Resume paused recurring schedule, occurrences missed while paused are not fired
+func (h *ResumeRecurringHandler) Handle(ctx *fiber.Ctx) error {
+	return setRecurringStatus(ctx, h.recurringRepo, "[ResumeRecurringHandler]", func(m *models.RecurringScheduleModel) error {
+		next, err := scheduler.NextRun(m, time.Now())
+		if err != nil {
+			return err
+		}
+
+		m.Status = models.RecurringStatusActive
+		m.NextRunAt = next
+		if next == nil {
+			m.Status = models.RecurringStatusCompleted
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/scheduler"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UpdateRecurringHandler struct {
+	recurringRepo mongo.IRecurringRepository
+}
+
+func NewUpdateRecurringHandler(
+	recurringRepo mongo.IRecurringRepository,
+) *UpdateRecurringHandler {
+	return &UpdateRecurringHandler{
+		recurringRepo: recurringRepo,
+	}
+}
+
+// Handle Replace recurring schedule, paused schedule stays paused
+func (h *UpdateRecurringHandler) Handle(ctx *fiber.Ctx) error {
+	req := parseRecurringReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[UpdateRecurringHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	current, err := h.recurringRepo.FindByID(ctx.UserContext(), req.ID)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[UpdateRecurringHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot update schedule",
+			},
+		})
+	}
+
+	if current == nil {
+		return recurringNotFound(ctx)
+	}
+
+	m := req.ToModel()
+	m.ID, _ = primitive.ObjectIDFromHex(req.ID)
+	if current.Status == models.RecurringStatusPaused {
+		m.Status = models.RecurringStatusPaused
+	}
+
+	next, err := scheduler.NextRun(m, time.Now())
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[UpdateRecurringHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Invalid cron",
+			},
+		})
+	}
+
+	m.NextRunAt = next
+	if next == nil {
+		m.Status = models.RecurringStatusCompleted
+	}
+
+	item, err := h.recurringRepo.Update(ctx.UserContext(), m)
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[UpdateRecurringHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot update schedule",
+			},
+		})
+	}
+
+	if item == nil {
+		return recurringNotFound(ctx)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": item,
+	})
+}
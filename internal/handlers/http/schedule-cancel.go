@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CancelScheduleHandler struct {
+	scheduleRepo mongo.IScheduleRepository
+	statusesRepo mongo.IStatusesRepository
+}
+
+func NewCancelScheduleHandler(
+	scheduleRepo mongo.IScheduleRepository,
+	statusesRepo mongo.IStatusesRepository,
+) *CancelScheduleHandler {
+	return &CancelScheduleHandler{
+		scheduleRepo: scheduleRepo,
+		statusesRepo: statusesRepo,
+	}
+}
+
+// Handle Cancel pending notification
+func (h *CancelScheduleHandler) Handle(ctx *fiber.Ctx) error {
+	item, err := h.scheduleRepo.Cancel(ctx.UserContext(), ctx.Params("notif_id"))
+	if err != nil {
+		return scheduleErrorResponse(ctx, "[CancelScheduleHandler]", err)
+	}
+
+	if item == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Scheduled notification not found",
+			},
+		})
+	}
+
+	if err := h.statusesRepo.Save(ctx.UserContext(), &models.NotificationStatusModel{
+		NotifID: item.NotifID,
+		SubID:   item.SubID,
+		Status:  models.StatusCancelled,
+		Reason:  item.Reason,
+	}); err != nil {
+		log.WithContext(ctx.UserContext()).Error("[CancelScheduleHandler] Failed save status of: ", item.NotifID, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": item,
+	})
+}
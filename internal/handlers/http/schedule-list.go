@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/schedule"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ListScheduleHandler struct {
+	scheduleRepo mongo.IScheduleRepository
+}
+
+func NewListScheduleHandler(
+	scheduleRepo mongo.IScheduleRepository,
+) *ListScheduleHandler {
+	return &ListScheduleHandler{
+		scheduleRepo: scheduleRepo,
+	}
+}
+
+// Handle List pending (scheduled or deferred) notifications with cursor pagination
+func (h *ListScheduleHandler) Handle(ctx *fiber.Ctx) error {
+	req := h.parseReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[ListScheduleHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	items, next, err := h.scheduleRepo.List(ctx.UserContext(), &models.ScheduleFilter{
+		SubID:  req.SubscriberID,
+		Reason: req.Reason,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[ListScheduleHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot list scheduled notifications",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": fiber.Map{
+			"items":       items,
+			"next_cursor": next,
+		},
+	})
+}
+
+func (h *ListScheduleHandler) parseReq(ctx *fiber.Ctx) *dtos.ListScheduleReqDto {
+	req := dtos.ListScheduleReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := ctx.QueryParser(&req); err != nil {
+		req.Error = err
+		return &req
+	}
+
+	req.Validate()
+
+	return &req
+}
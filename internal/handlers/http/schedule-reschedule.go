@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/schedule"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RescheduleHandler struct {
+	scheduleRepo mongo.IScheduleRepository
+}
+
+func NewRescheduleHandler(
+	scheduleRepo mongo.IScheduleRepository,
+) *RescheduleHandler {
+	return &RescheduleHandler{
+		scheduleRepo: scheduleRepo,
+	}
+}
+
+// Handle Move pending notification to another time
+func (h *RescheduleHandler) Handle(ctx *fiber.Ctx) error {
+	req := h.parseReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[RescheduleHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	item, err := h.scheduleRepo.Reschedule(ctx.UserContext(), req.NotifID, *req.SendAt)
+	if err != nil {
+		return scheduleErrorResponse(ctx, "[RescheduleHandler]", err)
+	}
+
+	if item == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Scheduled notification not found",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": item,
+	})
+}
+
+func (h *RescheduleHandler) parseReq(ctx *fiber.Ctx) *dtos.RescheduleReqDto {
+	req := dtos.RescheduleReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		req.Error = err
+		return &req
+	}
+	req.NotifID = ctx.Params("notif_id")
+
+	req.Validate()
+
+	return &req
+}
+
+func scheduleErrorResponse(ctx *fiber.Ctx, prefix string, err error) error {
+	log.WithContext(ctx.UserContext()).Error(prefix+" error: ", err.Error())
+
+	if errors.Is(err, mongo.ErrScheduleLocked) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Notification is being sent",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"isOk": false,
+		"data": fiber.Map{
+			"message": "Cannot update scheduled notification",
+		},
+	})
+}
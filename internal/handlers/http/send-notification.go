@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
+	"github.com/gofiber/fiber/v2"
+)
+
+// idempotencyHeader overrides idempotency_key of body
+const idempotencyHeader = "Idempotency-Key"
+
+type SendNotificationHandler struct {
+	pipeline *pipeline.Pipeline
+}
+
+func NewSendNotificationHandler(
+	pipeline *pipeline.Pipeline,
+) *SendNotificationHandler {
+	return &SendNotificationHandler{
+		pipeline: pipeline,
+	}
+}
+
+// Handle Send notification synchronously. Duplicates get result of original request
+func (h *SendNotificationHandler) Handle(ctx *fiber.Ctx) error {
+	req := h.parseReq(ctx)
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[SendNotificationHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	res := h.pipeline.Process(req)
+	if errors.Is(res.Error, mongo.ErrScheduleExists) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"isOk": false,
+			"data": res,
+		})
+	}
+
+	if res.IsFailed() {
+		log.WithContext(ctx.UserContext()).Error("[SendNotificationHandler] error: ", res.Error)
+		return ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"isOk": false,
+			"data": res,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": res,
+	})
+}
+
+func (h *SendNotificationHandler) parseReq(ctx *fiber.Ctx) *notifierDtos.NotifierPayloadDto {
+	_, span := tracing.Tracer().Start(ctx.UserContext(), "parse")
+
+	req := notifierDtos.NotifierPayloadDto{
+		TimeReqStart: time.Now(),
+		Ctx:          ctx.UserContext(),
+	}
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		req.Error = err
+		tracing.End(span, err)
+		return &req
+	}
+
+	if key := ctx.Get(idempotencyHeader); key != "" {
+		req.IdempotencyKey = key
+	}
+
+	req.ValidateType()
+	req.ValidateSubscriber()
+	req.ValidateFallback()
+
+	if req.IsSms() {
+		req.ValidateSms()
+	}
+
+	tracing.End(span, req.Error)
+
+	return &req
+}
@@ -25,11 +25,11 @@ func NewStoreTokenHandler(
 
 // Handle Store any ANDROID or IOS tokens (array of objects) with SubscriberID (could be unique userID for example)
 func (h *StoreTokenHandler) Handle(ctx *fiber.Ctx) error {
-	log.Debug("[StoreTokenHandler] consumed: %v\n", string(ctx.Body()))
+	log.WithContext(ctx.UserContext()).Debugf("[StoreTokenHandler] consumed: %v", string(ctx.Body()))
 
 	req := h.parseReq(ctx.Body())
 	if req.HasError() {
-		log.Error("[StoreTokenHandler] error: ", req.Error.Error())
+		log.WithContext(ctx.UserContext()).Error("[StoreTokenHandler] error: ", req.Error.Error())
 		ctx.Status(400).JSON(fiber.Map{
 			"isOk": false,
 			"data": fiber.Map{
@@ -38,7 +38,7 @@ func (h *StoreTokenHandler) Handle(ctx *fiber.Ctx) error {
 		})
 	}
 
-	_, err := h.tokensRepo.UpsertToken(&models.SubTokenCreateModel{
+	_, err := h.tokensRepo.UpsertToken(ctx.UserContext(), &models.SubTokenCreateModel{
 		SubID: req.SubscriberID,
 		Token: &models.TokenModel{
 			Token:    req.Token,
@@ -47,7 +47,7 @@ func (h *StoreTokenHandler) Handle(ctx *fiber.Ctx) error {
 	})
 
 	if err != nil {
-		log.Error("[StoreTokenHandler] error: ", err.Error())
+		log.WithContext(ctx.UserContext()).Error("[StoreTokenHandler] error: ", err.Error())
 		ctx.Status(400).JSON(fiber.Map{
 			"isOk": false,
 			"data": fiber.Map{
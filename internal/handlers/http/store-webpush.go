@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/gofiber/fiber/v2"
+)
+
+type StoreWebPushHandler struct {
+	tokensRepo mongo.ITokensRepository
+}
+
+func NewStoreWebPushHandler(
+	tokensRepo mongo.ITokensRepository,
+) *StoreWebPushHandler {
+	return &StoreWebPushHandler{
+		tokensRepo: tokensRepo,
+	}
+}
+
+// Handle Store browser PushSubscription with SubscriberID alongside FCM/APNs tokens (endpoint used as token)
+func (h *StoreWebPushHandler) Handle(ctx *fiber.Ctx) error {
+	log.WithContext(ctx.UserContext()).Debugf("[StoreWebPushHandler] consumed: %v", string(ctx.Body()))
+
+	req := h.parseReq(ctx.Body())
+	if req.HasError() {
+		log.WithContext(ctx.UserContext()).Error("[StoreWebPushHandler] error: ", req.Error.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Validation error",
+			},
+		})
+	}
+
+	_, err := h.tokensRepo.UpsertToken(ctx.UserContext(), &models.SubTokenCreateModel{
+		SubID: req.SubscriberID,
+		Token: &models.TokenModel{
+			Token:    req.Subscription.Endpoint,
+			Platform: models.PlatformWeb,
+			Keys: &models.WebPushKeysModel{
+				P256dh: req.Subscription.Keys.P256dh,
+				Auth:   req.Subscription.Keys.Auth,
+			},
+		},
+	})
+	if err != nil {
+		log.WithContext(ctx.UserContext()).Error("[StoreWebPushHandler] error: ", err.Error())
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot save subscription",
+			},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+	})
+}
+
+func (h *StoreWebPushHandler) parseReq(b []byte) *dtos.StoreWebPushReqDto {
+	req := dtos.StoreWebPushReqDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := json.Unmarshal(b, &req); err != nil {
+		req.Error = err
+		return &req
+	}
+
+	req.Validate()
+
+	return &req
+}
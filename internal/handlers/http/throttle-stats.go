@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ThrottleStatsHandler struct {
+	throttler *throttle.Throttler
+}
+
+func NewThrottleStatsHandler(
+	throttler *throttle.Throttler,
+) *ThrottleStatsHandler {
+	return &ThrottleStatsHandler{
+		throttler: throttler,
+	}
+}
+
+// Handle Get providers throttling stats: limits, current adaptive rates and time spent throttled
+func (h *ThrottleStatsHandler) Handle(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+		"data": h.throttler.Stats(),
+	})
+}
@@ -24,12 +24,12 @@ func NewUnsubTokenHandler(
 
 // Handle Unsubscribe token from subscriberID
 func (h *UnsubTokenHandler) Handle(ctx *fiber.Ctx) error {
-	log.Debug("[UnsubTokenHandler] consumed: %v\n", string(ctx.Body()))
+	log.WithContext(ctx.UserContext()).Debugf("[UnsubTokenHandler] consumed: %v", string(ctx.Body()))
 
 	req := h.parseReq(ctx.Body())
 	if req.HasError() {
-		log.Error("[UnsubTokenHandler] error: ", req.Error.Error())
-		ctx.Status(400).JSON(fiber.Map{
+		log.WithContext(ctx.UserContext()).Error("[UnsubTokenHandler] error: ", req.Error.Error())
+		return ctx.Status(400).JSON(fiber.Map{
 			"isOk": false,
 			"data": fiber.Map{
 				"message": "Validation error",
@@ -37,9 +37,19 @@ func (h *UnsubTokenHandler) Handle(ctx *fiber.Ctx) error {
 		})
 	}
 
-	// TODO: delete token from sub
+	if err := h.tokensRepo.DeleteTokens(ctx.UserContext(), req.SubscriberID, []string{req.Token}); err != nil {
+		log.WithContext(ctx.UserContext()).Error("[UnsubTokenHandler] error: ", err.Error())
+		return ctx.Status(400).JSON(fiber.Map{
+			"isOk": false,
+			"data": fiber.Map{
+				"message": "Cannot delete token",
+			},
+		})
+	}
 
-	return nil
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"isOk": true,
+	})
 }
 
 func (h *UnsubTokenHandler) parseReq(b []byte) *dtos.UnsubTokenReqDto {
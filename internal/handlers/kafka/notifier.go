@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/services/logging"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
+	"github.com/segmentio/kafka-go"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Action what transport does with message after it is handled
+type Action int
+
+const (
+	// Commit message is processed, its offset is committed
+	Commit Action = iota
+	// Retry message is republished to retry topic
+	Retry
+	// DeadLetter message is republished to dead letter topic
+	DeadLetter
+)
+
+type NotifierHandler struct {
+	pipeline  *pipeline.Pipeline
+	logConfig *configs.LogConfig
+}
+
+func NewNotifierHandler(
+	pipeline *pipeline.Pipeline,
+	logConfig *configs.LogConfig,
+) *NotifierHandler {
+	return &NotifierHandler{
+		pipeline:  pipeline,
+		logConfig: logConfig,
+	}
+}
+
+// Handle run message through pipeline. Notification without notif_id and idempotency key is deduplicated by
+// key of message, so redelivered message is not sent twice. Failed notification is retried unless it is
+// the last attempt or failure is not retryable, invalid message and notifications not retried are dead-lettered
+func (h *NotifierHandler) Handle(m kafka.Message, key string, lastAttempt bool) (Action, error) {
+	ctx := logging.WithCorrelationID(context.Background(), h.correlationID(m))
+
+	// continue trace of producer passed in message headers
+	ctx, span := tracing.Tracer().Start(
+		tracing.ExtractKafka(ctx, m.Headers),
+		m.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", m.Topic),
+			attribute.Int("messaging.kafka.destination.partition", m.Partition),
+			attribute.Int64("messaging.kafka.message.offset", m.Offset),
+			attribute.String("messaging.kafka.message.key", string(m.Key)),
+		),
+	)
+	defer span.End()
+
+	notifierRequest := h.parse(ctx, m.Value)
+	if notifierRequest.HasError() {
+		log.WithContext(ctx).Error("[KafkaNotifierHandler] error: ", notifierRequest.Error.Error())
+		return DeadLetter, notifierRequest.Error
+	}
+
+	if notifierRequest.GetIdempotencyKey() == "" {
+		notifierRequest.IdempotencyKey = key
+	}
+
+	// retried failures are not final, see Requeued
+	notifierRequest.RetryIf = func(err error) bool {
+		return !lastAttempt && domain.IsRetryable(err)
+	}
+
+	log.WithContext(ctx).WithFields(log.Fields{
+		"notif_id": notifierRequest.NotifID,
+		"type":     notifierRequest.Type,
+	}).Debug("[KafkaNotifierHandler] consumed")
+
+	res := h.pipeline.Process(notifierRequest)
+	span.SetAttributes(
+		attribute.String("notification.id", res.NotifID),
+		attribute.String("notification.status", res.Status),
+	)
+
+	if res.Duplicate {
+		log.WithContext(ctx).Debugf("[KafkaNotifierHandler] duplicate of %s committed", res.NotifID)
+		return Commit, nil
+	}
+
+	if res.IsFailed() {
+		err := res.Error
+		if err == nil {
+			err = errors.New(res.Reason)
+		}
+
+		if lastAttempt {
+			log.WithContext(ctx).Errorf("[KafkaNotifierHandler] notification %s failed, retries exhausted: %v", res.NotifID, err)
+			return DeadLetter, err
+		}
+
+		if !domain.IsRetryable(err) {
+			log.WithContext(ctx).Errorf("[KafkaNotifierHandler] notification %s failed, not retryable: %v", res.NotifID, err)
+			return DeadLetter, err
+		}
+
+		h.pipeline.Requeued(notifierRequest, res)
+		return Retry, err
+	}
+
+	log.WithContext(ctx).Debugf("[KafkaNotifierHandler] notification %s %s: %s", res.NotifID, res.Status, res.Reason)
+
+	return Commit, nil
+}
+
+func (h *NotifierHandler) parse(ctx context.Context, b []byte) *notifierDtos.NotifierPayloadDto {
+	_, span := tracing.Tracer().Start(ctx, "parse")
+
+	req := notifierDtos.NotifierPayloadDto{
+		TimeReqStart: time.Now(),
+		Ctx:          ctx,
+	}
+	if err := json.Unmarshal(b, &req); err != nil {
+		req.Error = err
+	}
+
+	req.ValidateType()
+	req.ValidateSubscriber()
+	req.ValidateFallback()
+
+	if req.IsSms() {
+		req.ValidateSms()
+	}
+
+	tracing.End(span, req.Error)
+
+	return &req
+}
+
+// correlationID of message is taken from the same header as of HTTP requests, generated if message has none
+func (h *NotifierHandler) correlationID(m kafka.Message) string {
+	for _, header := range m.Headers {
+		if header.Key == h.logConfig.CorrelationHeader && len(header.Value) > 0 {
+			return string(header.Value)
+		}
+	}
+
+	return logging.NewCorrelationID()
+}
@@ -3,11 +3,45 @@ package handlers
 import (
 	amqp_handlers "github.com/WildEgor/gNotifier/internal/handlers/amqp"
 	http_handlers "github.com/WildEgor/gNotifier/internal/handlers/http"
+	kafka_handlers "github.com/WildEgor/gNotifier/internal/handlers/kafka"
 	"github.com/google/wire"
 )
 
 var HandlersSet = wire.NewSet(
 	amqp_handlers.NewNotifierHandler,
+	kafka_handlers.NewNotifierHandler,
 	http_handlers.NewStoreTokenHandler,
 	http_handlers.NewUnsubTokenHandler,
+	http_handlers.NewStoreWebPushHandler,
+	http_handlers.NewListInboxHandler,
+	http_handlers.NewInboxUnreadHandler,
+	http_handlers.NewMarkInboxHandler,
+	http_handlers.NewArchiveInboxHandler,
+	http_handlers.NewDeleteInboxHandler,
+	http_handlers.NewRealtimeWSHandler,
+	http_handlers.NewRealtimeSSEHandler,
+	http_handlers.NewGetPreferencesHandler,
+	http_handlers.NewUpdatePreferencesHandler,
+	http_handlers.NewListScheduleHandler,
+	http_handlers.NewRescheduleHandler,
+	http_handlers.NewCancelScheduleHandler,
+	http_handlers.NewCreateRecurringHandler,
+	http_handlers.NewListRecurringHandler,
+	http_handlers.NewGetRecurringHandler,
+	http_handlers.NewUpdateRecurringHandler,
+	http_handlers.NewDeleteRecurringHandler,
+	http_handlers.NewPauseRecurringHandler,
+	http_handlers.NewResumeRecurringHandler,
+	http_handlers.NewSendNotificationHandler,
+	http_handlers.NewThrottleStatsHandler,
+	http_handlers.NewLanesStatsHandler,
+	http_handlers.NewPauseLaneHandler,
+	http_handlers.NewResumeLaneHandler,
+	http_handlers.NewGetContactsHandler,
+	http_handlers.NewUpdateContactsHandler,
+	http_handlers.NewNotificationStatusHandler,
+	http_handlers.NewNotificationOpenedHandler,
+	http_handlers.NewHealthLiveHandler,
+	http_handlers.NewHealthReadyHandler,
+	http_handlers.NewMetricsHandler,
 )
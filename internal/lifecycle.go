@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	log "github.com/sirupsen/logrus"
+)
+
+// disconnectTimeout how long storage is given to close connections after notifications are drained
+const disconnectTimeout = 5 * time.Second
+
+// Run start HTTP server, consumers and background workers and block till SIGINT/SIGTERM or HTTP server
+// failure, then shut everything down
+func (s *Server) Run() error {
+	// Checks and token counts run in background, health and metrics endpoints serve cached results
+	s.healthCheckAdapter.Start()
+	s.metricsCollector.Start()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.App.Listen(":" + s.AppConfig.Port)
+	}()
+
+	if err := s.amqpRouter.SetupRoutes(); err != nil {
+		s.Shutdown()
+		return err
+	}
+
+	if s.AppConfig.HasTransport(configs.TransportKafka) {
+		if err := s.kafkaRouter.SetupRoutes(); err != nil {
+			s.Shutdown()
+			return err
+		}
+	}
+
+	// Release scheduled and deferred notifications, fire recurring ones, send digests
+	s.dispatcher.Start()
+	s.recurringScheduler.Start()
+	s.digestFlusher.Start()
+
+	log.Infof("[App] Application is running on %v port...", s.AppConfig.Port)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	var err error
+	select {
+	case sig := <-sigs:
+		log.Infof("[App] Received %s, shutting down", sig)
+	case err = <-listenErr:
+		err = fmt.Errorf("[App] HTTP server failed: %w", err)
+	}
+
+	s.Shutdown()
+
+	return err
+}
+
+// Shutdown stop intake, wait for notifications in progress till AppConfig.ShutdownTimeout and close
+// connections. Notifications left in progress on timeout are redelivered by broker or released by scheduler
+// lock timeout
+func (s *Server) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.AppConfig.ShutdownTimeout)
+	defer cancel()
+
+	// Listener is closed at once, requests in progress are finished
+	httpDone := make(chan error, 1)
+	go func() {
+		httpDone <- s.App.ShutdownWithTimeout(s.AppConfig.ShutdownTimeout)
+	}()
+
+	var drain sync.WaitGroup
+	drain.Add(3)
+	go func() {
+		defer drain.Done()
+		if err := s.amqpRouter.Drain(ctx); err != nil {
+			log.Warn("[App] ", err)
+		}
+	}()
+	go func() {
+		defer drain.Done()
+		if err := s.kafkaRouter.Drain(ctx); err != nil {
+			log.Warn("[App] ", err)
+		}
+	}()
+	go func() {
+		defer drain.Done()
+		s.dispatcher.Close()
+		s.recurringScheduler.Close()
+		s.digestFlusher.Close()
+	}()
+
+	drained := make(chan struct{})
+	go func() {
+		drain.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Warn("[App] Notifications in progress are not finished till shutdown timeout")
+	}
+
+	// Live streams never end on their own, so HTTP server would wait for them till timeout
+	s.realtimeHub.Close()
+	if err := <-httpDone; err != nil {
+		log.Warn("[App] Failed shutdown HTTP server: ", err)
+	}
+
+	s.kafkaRouter.Close()
+	// Status events of Kafka notifications are published through AMQP, so it is closed after Kafka
+	s.amqpRouter.Close()
+	s.healthCheckAdapter.Close()
+	s.metricsCollector.Close()
+	s.apnAdapter.Close()
+	s.tracingProvider.Close()
+
+	disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), disconnectTimeout)
+	defer disconnectCancel()
+
+	if err := s.mongoClient.Disconnect(disconnectCtx); err != nil {
+		log.Error("[App] Failed disconnect Mongo: ", err)
+	}
+
+	log.Info("[App] Application stopped")
+}
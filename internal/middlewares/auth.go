@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"strings"
+
+	"github.com/WildEgor/gNotifier/internal/services/opens"
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+type AuthConfig struct {
+	// AdminToken static token of backend services and operators, grants access to every route
+	AdminToken string
+	// SubscriberSecret HS256 secret of subscriber JWTs, subscriber tokens are rejected if empty. Anonymous realtime
+	// access (local development) never applies here
+	SubscriberSecret string
+	// SubIDParam route param or SubIDField JSON body field holding subscriber ID, route is admin only if both empty
+	SubIDParam string
+	SubIDField string
+	// OpenTokens verifies per-notification token passed in token query param for notification of NotifIDParam
+	OpenTokens   *opens.Signer
+	NotifIDParam string
+}
+
+// Auth allow request with admin token or, on subscriber routes, with JWT issued for subscriber of route.
+// Token is passed in Authorization: Bearer header
+func Auth(cfg *AuthConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		token := bearerToken(ctx)
+
+		if cfg.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.AdminToken)) == 1 {
+			return ctx.Next()
+		}
+
+		if cfg.SubIDParam != "" || cfg.SubIDField != "" {
+			err := realtime.VerifyToken(cfg.SubscriberSecret, token, subIDOf(ctx, cfg))
+			if err == nil {
+				return ctx.Next()
+			}
+			log.WithContext(ctx.UserContext()).Debug("[Auth] error: ", err.Error())
+		}
+
+		if cfg.OpenTokens != nil && cfg.OpenTokens.Verify(ctx.Params(cfg.NotifIDParam), ctx.Query("token")) {
+			return ctx.Next()
+		}
+
+		return unauthorized(ctx)
+	}
+}
+
+func subIDOf(ctx *fiber.Ctx, cfg *AuthConfig) string {
+	if cfg.SubIDParam != "" {
+		return ctx.Params(cfg.SubIDParam)
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(ctx.Body(), &fields); err != nil {
+		return ""
+	}
+
+	var subID string
+	if err := json.Unmarshal(fields[cfg.SubIDField], &subID); err != nil {
+		return ""
+	}
+
+	return subID
+}
+
+func unauthorized(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"isOk": false,
+		"data": fiber.Map{
+			"message": "Unauthorized",
+		},
+	})
+}
+
+func bearerToken(ctx *fiber.Ctx) string {
+	auth := ctx.Get(fiber.HeaderAuthorization)
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimPrefix(auth, "Bearer ")
+}
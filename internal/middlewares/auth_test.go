@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testAdminToken = "admin-token"
+	testSecret     = "subscriber-secret"
+)
+
+func signToken(t *testing.T, secret, subID string, exp *time.Time) string {
+	t.Helper()
+
+	claims := jwt.RegisteredClaims{Subject: subID}
+	if exp != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*exp)
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
+
+func TestAuth(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	admin := &AuthConfig{AdminToken: testAdminToken}
+	byParam := &AuthConfig{AdminToken: testAdminToken, SubscriberSecret: testSecret, SubIDParam: "sub_id"}
+	byField := &AuthConfig{AdminToken: testAdminToken, SubscriberSecret: testSecret, SubIDField: "sub_id"}
+	noSecret := &AuthConfig{AdminToken: testAdminToken, SubIDParam: "sub_id"}
+
+	tests := []struct {
+		name   string
+		cfg    *AuthConfig
+		subID  string
+		body   string
+		token  string
+		status int
+	}{
+		{name: "admin token", cfg: admin, subID: "u1", token: testAdminToken, status: fiber.StatusOK},
+		{name: "no token", cfg: admin, subID: "u1", status: fiber.StatusUnauthorized},
+		{name: "wrong admin token", cfg: admin, subID: "u1", token: "guess", status: fiber.StatusUnauthorized},
+		{name: "subscriber token on admin route", cfg: admin, subID: "u1", token: signToken(t, testSecret, "u1", &future), status: fiber.StatusUnauthorized},
+		{name: "admin token on subscriber route", cfg: byParam, subID: "u1", token: testAdminToken, status: fiber.StatusOK},
+		{name: "subscriber token", cfg: byParam, subID: "u1", token: signToken(t, testSecret, "u1", &future), status: fiber.StatusOK},
+		{name: "token of another subscriber", cfg: byParam, subID: "u2", token: signToken(t, testSecret, "u1", &future), status: fiber.StatusUnauthorized},
+		{name: "token without expiration", cfg: byParam, subID: "u1", token: signToken(t, testSecret, "u1", nil), status: fiber.StatusUnauthorized},
+		{name: "expired token", cfg: byParam, subID: "u1", token: signToken(t, testSecret, "u1", &past), status: fiber.StatusUnauthorized},
+		{name: "token of another secret", cfg: byParam, subID: "u1", token: signToken(t, "other", "u1", &future), status: fiber.StatusUnauthorized},
+		{name: "secret not configured", cfg: noSecret, subID: "u1", token: signToken(t, "", "u1", &future), status: fiber.StatusUnauthorized},
+		{name: "subscriber of body", cfg: byField, body: `{"sub_id":"u1","token":"t"}`, token: signToken(t, testSecret, "u1", &future), status: fiber.StatusOK},
+		{name: "another subscriber of body", cfg: byField, body: `{"sub_id":"u2","token":"t"}`, token: signToken(t, testSecret, "u1", &future), status: fiber.StatusUnauthorized},
+		{name: "body without subscriber", cfg: byField, body: `{"token":"t"}`, token: signToken(t, testSecret, "", &future), status: fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/:sub_id?", Auth(tt.cfg), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest(fiber.MethodPost, "/"+tt.subID, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+			}
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+
+			if res.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.status)
+			}
+		})
+	}
+}
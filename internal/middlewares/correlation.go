@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/WildEgor/gNotifier/internal/services/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Correlation take correlation ID from header (or generate one) and return it in the same header,
+// handlers log with ctx.UserContext() to get it in every line
+func Correlation(header string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id := ctx.Get(header)
+		if id == "" {
+			id = logging.NewCorrelationID()
+		}
+
+		ctx.Set(header, id)
+		ctx.SetUserContext(logging.WithCorrelationID(ctx.UserContext(), id))
+
+		return ctx.Next()
+	}
+}
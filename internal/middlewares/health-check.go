@@ -14,7 +14,7 @@ type HealthCheckConfig struct {
 	HealthCheckAdapter *adapters.HealthCheckAdapter
 }
 
-// HealthCheck Example how we can use middleware
+// HealthCheck serves the last results of background health checks, checks are not run per request
 func HealthCheck(cfg *HealthCheckConfig) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		methodName := ctx.Method()
@@ -22,12 +22,17 @@ func HealthCheck(cfg *HealthCheckConfig) fiber.Handler {
 
 		if (methodName == "GET" || methodName == "HEAD") && strings.EqualFold(path, cfg.Endpoint) {
 			if cfg.HealthCheckAdapter != nil {
-				info := cfg.HealthCheckAdapter.Measure(ctx.Context())
+				info := cfg.HealthCheckAdapter.Measure()
 
 				if info.Status != adapters.StatusOK {
 					return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 						"isOk": false,
-						"data": info.Failures,
+						"data": fiber.Map{
+							"status":    info.Status,
+							"timestamp": info.Timestamp,
+							"failures":  info.Failures,
+							"checks":    info.Checks,
+						},
 					})
 				}
 
@@ -37,6 +42,7 @@ func HealthCheck(cfg *HealthCheckConfig) fiber.Handler {
 						"status":    info.Status,
 						"timestamp": info.Timestamp,
 						"message":   info.ComponentInfo.Name,
+						"checks":    info.Checks,
 					},
 				})
 			}
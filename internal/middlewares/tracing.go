@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fiberCarrier adapts request and response headers to propagation.TextMapCarrier
+type fiberCarrier struct {
+	ctx *fiber.Ctx
+}
+
+func (c fiberCarrier) Get(key string) string {
+	return c.ctx.Get(key)
+}
+
+func (c fiberCarrier) Set(key, value string) {
+	c.ctx.Set(key, value)
+}
+
+func (c fiberCarrier) Keys() []string {
+	var keys []string
+	c.ctx.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
+
+// Tracing continue trace of caller passed in W3C headers (traceparent, tracestate) with server span,
+// handlers take span context from ctx.UserContext(). Requests with path prefixed by one of skip
+// (probes, metrics scrapes) are not traced
+func Tracing(skip ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		for _, prefix := range skip {
+			if strings.HasPrefix(ctx.Path(), prefix) {
+				return ctx.Next()
+			}
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), fiberCarrier{ctx: ctx})
+
+		spanCtx, span := tracing.Tracer().Start(
+			parent,
+			ctx.Method()+" "+ctx.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", ctx.Method()),
+				attribute.String("http.target", string(ctx.Request().RequestURI())),
+			),
+		)
+		defer span.End()
+
+		ctx.SetUserContext(spanCtx)
+
+		err := ctx.Next()
+
+		// route is known only after request matched
+		span.SetName(ctx.Method() + " " + ctx.Route().Path)
+		status := ctx.Response().StatusCode()
+		span.SetAttributes(
+			attribute.String("http.route", ctx.Route().Path),
+			attribute.Int("http.status_code", status),
+		)
+		if err != nil {
+			span.RecordError(err)
+		}
+		if err != nil || status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+
+		return err
+	}
+}
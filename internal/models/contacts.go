@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContactsModel subscriber addresses fallback channels resolved with
+type ContactsModel struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	SubID     string             `bson:"sub_id" json:"sub_id"`
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	Phone     string             `bson:"phone,omitempty" json:"phone,omitempty"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DigestItemModel is a single notification collected to digest
+type DigestItemModel struct {
+	NotifID string `bson:"notif_id" json:"notif_id"`
+	Title   string `bson:"title,omitempty" json:"title,omitempty"`
+	Body    string `bson:"body,omitempty" json:"body,omitempty"`
+	// Data json encoded notification data
+	Data      string    `bson:"data,omitempty" json:"data,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// DigestModel collects subscriber notifications with the same digest key and channel till flushed as one message
+type DigestModel struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubID    string             `bson:"sub_id" json:"sub_id"`
+	Key      string             `bson:"key" json:"key"`
+	Channel  string             `bson:"channel" json:"channel"`
+	Category string             `bson:"category,omitempty" json:"category,omitempty"`
+	// Payload json encoded first notification, digest sent with its channel settings
+	Payload string `bson:"payload" json:"payload"`
+	// Items first collected notifications (up to max items)
+	Items []*DigestItemModel `bson:"items" json:"items"`
+	// NotifIDs every collected notification
+	NotifIDs []string  `bson:"notif_ids" json:"notif_ids"`
+	Count    int       `bson:"count" json:"count"`
+	FlushAt  time.Time `bson:"flush_at" json:"flush_at"`
+	// Flushing digest closed for new items and claimed by flusher till LockedUntil
+	Flushing    bool       `bson:"flushing" json:"flushing"`
+	LockedUntil *time.Time `bson:"locked_until,omitempty" json:"-"`
+	// Attempts how many times digest was claimed for flush
+	Attempts  int       `bson:"attempts" json:"attempts"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuses of idempotency record
+const (
+	IdempotencyProcessing = "processing"
+	IdempotencyDone       = "done"
+)
+
+// IdempotencyModel remembers notification processed by key, so duplicates are not sent again
+type IdempotencyModel struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Key    string             `bson:"key" json:"key"`
+	Status string             `bson:"status" json:"status"`
+	// Result of original processing
+	NotifID       string    `bson:"notif_id" json:"notif_id"`
+	ResultStatus  string    `bson:"result_status,omitempty" json:"result_status,omitempty"`
+	ResultChannel string    `bson:"result_channel,omitempty" json:"result_channel,omitempty"`
+	ResultReason  string    `bson:"result_reason,omitempty" json:"result_reason,omitempty"`
+	ExpiresAt     time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
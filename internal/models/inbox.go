@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Inbox items states
+const (
+	InboxStatusAll      = "all"
+	InboxStatusUnread   = "unread"
+	InboxStatusRead     = "read"
+	InboxStatusArchived = "archived"
+)
+
+type InboxItemModel struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubID      string             `bson:"sub_id" json:"sub_id"`
+	NotifID    string             `bson:"notif_id,omitempty" json:"notif_id,omitempty"`
+	Title      string             `bson:"title" json:"title"`
+	Body       string             `bson:"body" json:"body"`
+	Data       interface{}        `bson:"data,omitempty" json:"data,omitempty"`
+	Category   string             `bson:"category,omitempty" json:"category,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ReadAt     *time.Time         `bson:"read_at" json:"read_at"`
+	ArchivedAt *time.Time         `bson:"archived_at" json:"archived_at"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+type InboxFilter struct {
+	SubID  string
+	Status string
+	// Cursor is ID of last item of previous page
+	Cursor string
+	Limit  int64
+}
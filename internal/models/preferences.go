@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PreferencesAny matches any category or channel in preferences
+const PreferencesAny = "*"
+
+// Channels preferences could be set for
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+	ChannelInbox = "inbox"
+)
+
+type PreferencesModel struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	SubID string             `bson:"sub_id" json:"sub_id"`
+	// Muted disables every channel of every category (till MutedUntil if set)
+	Muted      bool       `bson:"muted" json:"muted"`
+	MutedUntil *time.Time `bson:"muted_until,omitempty" json:"muted_until,omitempty"`
+	// Categories enabled channels per category, e.g. {"marketing": {"sms": false}, "*": {"email": true}}
+	Categories map[string]map[string]bool `bson:"categories" json:"categories"`
+	// Timezone IANA name quiet hours evaluated in (UTC if empty)
+	Timezone   string             `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	QuietHours []*QuietHoursModel `bson:"quiet_hours,omitempty" json:"quiet_hours,omitempty"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// QuietHoursModel is a daily window non-urgent notifications deferred within, e.g. 22:00-08:00
+type QuietHoursModel struct {
+	// Start and End local time in HH:MM format, window ends next day if End is not after Start
+	Start string `bson:"start" json:"start"`
+	End   string `bson:"end" json:"end"`
+	// Days of week window starts on (0 - Sunday), every day if empty
+	Days []time.Weekday `bson:"days,omitempty" json:"days,omitempty"`
+}
+
+// Allows check channel of category is enabled by subscriber, returns reason if not.
+// The most specific rule wins: category+channel, category+*, *+channel, *+*. Enabled by default.
+func (p *PreferencesModel) Allows(category, channel string) (bool, string) {
+	if p.IsMuted(time.Now()) {
+		return false, ReasonMuted
+	}
+
+	if category == "" {
+		category = PreferencesAny
+	}
+
+	for _, key := range [][2]string{
+		{category, channel},
+		{category, PreferencesAny},
+		{PreferencesAny, channel},
+		{PreferencesAny, PreferencesAny},
+	} {
+		if enabled, ok := p.Categories[key[0]][key[1]]; ok {
+			if !enabled {
+				return false, ReasonCategoryDisabled
+			}
+			return true, ""
+		}
+	}
+
+	return true, ""
+}
+
+func (p *PreferencesModel) IsMuted(now time.Time) bool {
+	return p.Muted && (p.MutedUntil == nil || p.MutedUntil.After(now))
+}
+
+// QuietUntil returns end of quiet hours window now falls into
+func (p *PreferencesModel) QuietUntil(now time.Time) (time.Time, bool) {
+	if len(p.QuietHours) == 0 {
+		return time.Time{}, false
+	}
+
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+
+	var until time.Time
+	for _, w := range p.QuietHours {
+		// overnight window could start yesterday
+		for _, offset := range []int{-1, 0} {
+			start, end, ok := w.Window(local.AddDate(0, 0, offset))
+			if !ok || local.Before(start) || !local.Before(end) {
+				continue
+			}
+			if end.After(until) {
+				until = end
+			}
+		}
+	}
+
+	return until, !until.IsZero()
+}
+
+// Window returns bounds of window starting on day, false if window is not active that day or malformed
+func (q *QuietHoursModel) Window(day time.Time) (time.Time, time.Time, bool) {
+	startH, startM, err := ParseClock(q.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endH, endM, err := ParseClock(q.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	if len(q.Days) > 0 {
+		active := false
+		for _, d := range q.Days {
+			if d == day.Weekday() {
+				active = true
+				break
+			}
+		}
+		if !active {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, startH, startM, 0, 0, day.Location())
+	end := time.Date(y, m, d, endH, endM, 0, 0, day.Location())
+	if !end.After(start) {
+		end = time.Date(y, m, d+1, endH, endM, 0, 0, day.Location())
+	}
+
+	return start, end, true
+}
+
+// ParseClock parse HH:MM time of day
+func ParseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return t.Hour(), t.Minute(), nil
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursWindow(t *testing.T) {
+	// 2024-03-06 is Wednesday
+	day := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		window     QuietHoursModel
+		start, end time.Time
+		ok         bool
+	}{
+		{
+			name:   "same day",
+			window: QuietHoursModel{Start: "13:00", End: "14:30"},
+			start:  time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC),
+			end:    time.Date(2024, 3, 6, 14, 30, 0, 0, time.UTC),
+			ok:     true,
+		},
+		{
+			name:   "overnight ends next day",
+			window: QuietHoursModel{Start: "22:00", End: "08:00"},
+			start:  time.Date(2024, 3, 6, 22, 0, 0, 0, time.UTC),
+			end:    time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC),
+			ok:     true,
+		},
+		{
+			name:   "equal bounds is whole day",
+			window: QuietHoursModel{Start: "09:00", End: "09:00"},
+			start:  time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC),
+			end:    time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC),
+			ok:     true,
+		},
+		{
+			name:   "active day",
+			window: QuietHoursModel{Start: "22:00", End: "08:00", Days: []time.Weekday{time.Monday, time.Wednesday}},
+			start:  time.Date(2024, 3, 6, 22, 0, 0, 0, time.UTC),
+			end:    time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC),
+			ok:     true,
+		},
+		{
+			name:   "inactive day",
+			window: QuietHoursModel{Start: "22:00", End: "08:00", Days: []time.Weekday{time.Saturday, time.Sunday}},
+		},
+		{
+			name:   "malformed start",
+			window: QuietHoursModel{Start: "25:00", End: "08:00"},
+		},
+		{
+			name:   "malformed end",
+			window: QuietHoursModel{Start: "22:00", End: "8am"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := tt.window.Window(day)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Fatalf("window = [%v, %v), want [%v, %v)", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestPreferencesQuietUntil(t *testing.T) {
+	night := &QuietHoursModel{Start: "22:00", End: "08:00"}
+	lunch := &QuietHoursModel{Start: "13:00", End: "14:00"}
+	weekend := &QuietHoursModel{Start: "00:00", End: "00:00", Days: []time.Weekday{time.Saturday, time.Sunday}}
+
+	tests := []struct {
+		name     string
+		timezone string
+		windows  []*QuietHoursModel
+		now      time.Time
+		want     time.Time
+	}{
+		{
+			name:    "no quiet hours",
+			now:     time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC),
+			windows: nil,
+		},
+		{
+			name:    "before midnight",
+			windows: []*QuietHoursModel{night},
+			now:     time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "after midnight window started yesterday",
+			windows: []*QuietHoursModel{night},
+			now:     time.Date(2024, 3, 7, 3, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "start inclusive",
+			windows: []*QuietHoursModel{lunch},
+			now:     time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC),
+			want:    time.Date(2024, 3, 6, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "end exclusive",
+			windows: []*QuietHoursModel{lunch},
+			now:     time.Date(2024, 3, 6, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "outside windows",
+			windows: []*QuietHoursModel{night, lunch},
+			now:     time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "overlapping windows take latest end",
+			windows: []*QuietHoursModel{night, weekend},
+			// Saturday
+			now:  time.Date(2024, 3, 9, 7, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "evaluated in subscriber timezone",
+			timezone: "Europe/Moscow",
+			windows:  []*QuietHoursModel{night},
+			// 23:00 in Moscow
+			now:  time.Date(2024, 3, 6, 20, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 7, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "unknown timezone falls back to UTC",
+			timezone: "Mars/Olympus",
+			windows:  []*QuietHoursModel{night},
+			now:      time.Date(2024, 3, 6, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PreferencesModel{Timezone: tt.timezone, QuietHours: tt.windows}
+
+			until, ok := p.QuietUntil(tt.now)
+			if ok != !tt.want.IsZero() {
+				t.Fatalf("ok = %v, want %v", ok, !tt.want.IsZero())
+			}
+			if !until.Equal(tt.want) {
+				t.Fatalf("until = %v, want %v", until, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuses of recurring schedule
+const (
+	RecurringStatusActive    = "active"
+	RecurringStatusPaused    = "paused"
+	RecurringStatusCompleted = "completed"
+)
+
+// SegmentAll audience segment of every subscriber with stored tokens
+const SegmentAll = "all"
+
+// AudienceModel is either explicit subscriber IDs or segment: "all" or token platform (e.g. "ANDROID")
+type AudienceModel struct {
+	SubIDs  []string `bson:"sub_ids,omitempty" json:"sub_ids,omitempty"`
+	Segment string   `bson:"segment,omitempty" json:"segment,omitempty"`
+}
+
+// RecurringScheduleModel is a periodic notification, every occurrence sent to whole audience
+type RecurringScheduleModel struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name string             `bson:"name" json:"name"`
+	// Cron standard 5 fields expression or descriptor (e.g. @daily)
+	Cron string `bson:"cron" json:"cron"`
+	// Timezone IANA name cron evaluated in (UTC if empty)
+	Timezone string `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	// Template overrides template of notification
+	Template string        `bson:"template,omitempty" json:"template,omitempty"`
+	Audience AudienceModel `bson:"audience" json:"audience"`
+	// Payload json encoded notification request every occurrence built from
+	Payload   string     `bson:"payload" json:"payload"`
+	StartAt   *time.Time `bson:"start_at,omitempty" json:"start_at,omitempty"`
+	EndAt     *time.Time `bson:"end_at,omitempty" json:"end_at,omitempty"`
+	Status    string     `bson:"status" json:"status"`
+	NextRunAt *time.Time `bson:"next_run_at,omitempty" json:"next_run_at,omitempty"`
+	LastRunAt *time.Time `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	// LockedUntil occurrence claimed by scheduler instance till this time
+	LockedUntil *time.Time `bson:"locked_until,omitempty" json:"-"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+type RecurringFilter struct {
+	Status string
+	// Cursor is ID of last item of previous page
+	Cursor string
+	Limit  int64
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduledNotificationModel is a notification stored to be sent later
+type ScheduledNotificationModel struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	NotifID string             `bson:"notif_id" json:"notif_id"`
+	SubID   string             `bson:"sub_id,omitempty" json:"sub_id,omitempty"`
+	// Reason why notification delayed (e.g. quiet_hours)
+	Reason string    `bson:"reason" json:"reason"`
+	SendAt time.Time `bson:"send_at" json:"send_at"`
+	// Payload json encoded notification request
+	Payload string `bson:"payload" json:"payload"`
+	// LockedUntil notification claimed by dispatcher till this time
+	LockedUntil *time.Time `bson:"locked_until,omitempty" json:"-"`
+	Attempts    int        `bson:"attempts" json:"attempts"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+type ScheduleFilter struct {
+	SubID  string
+	Reason string
+	// Cursor is ID of last item of previous page
+	Cursor string
+	Limit  int64
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification statuses
+const (
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+	// StatusDeferred notification stored and will be sent later
+	StatusDeferred = "deferred"
+	// StatusScheduled notification stored to be sent at send_at
+	StatusScheduled = "scheduled"
+	StatusCancelled = "cancelled"
+	// StatusProcessing notification is being processed right now (returned for duplicates only)
+	StatusProcessing = "processing"
+	// StatusRateLimited notification rejected by frequency cap
+	StatusRateLimited = "rate_limited"
+	// StatusBatched notification collected to digest
+	StatusBatched = "batched"
+	// StatusOpened recipient opened delivered notification
+	StatusOpened = "opened"
+)
+
+// Reasons of skipped notifications
+const (
+	ReasonMuted            = "muted"
+	ReasonCategoryDisabled = "category_disabled"
+	ReasonNoRecipient      = "no_recipient"
+	ReasonQuietHours       = "quiet_hours"
+	ReasonSendAt           = "send_at"
+	ReasonDigest           = "digest"
+	// ReasonAwaitOpen notification waits to be opened before falling back to next channel
+	ReasonAwaitOpen = "await_open"
+)
+
+// NotificationStatusHistoryModel is a single status change of notification
+type NotificationStatusHistoryModel struct {
+	Status    string    `bson:"status" json:"status"`
+	Channel   string    `bson:"channel,omitempty" json:"channel,omitempty"`
+	Reason    string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// NotificationStatusModel holds last status of notification along with history of changes
+type NotificationStatusModel struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	NotifID  string             `bson:"notif_id" json:"notif_id"`
+	SubID    string             `bson:"sub_id,omitempty" json:"sub_id,omitempty"`
+	Category string             `bson:"category,omitempty" json:"category,omitempty"`
+	Status   string             `bson:"status" json:"status"`
+	Channel  string             `bson:"channel,omitempty" json:"channel,omitempty"`
+	Reason   string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Error    string             `bson:"error,omitempty" json:"error,omitempty"`
+	// DeliveredChannel channel notification was finally delivered through (fallback chains try several)
+	DeliveredChannel string                            `bson:"delivered_channel,omitempty" json:"delivered_channel,omitempty"`
+	OpenedAt         *time.Time                        `bson:"opened_at,omitempty" json:"opened_at,omitempty"`
+	History          []*NotificationStatusHistoryModel `bson:"history" json:"history"`
+	CreatedAt        time.Time                         `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time                         `bson:"updated_at" json:"updated_at"`
+}
@@ -6,17 +6,32 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Platforms of stored tokens
+const (
+	PlatformIOS     = "IOS"
+	PlatformAndroid = "ANDROID"
+	PlatformWeb     = "WEB"
+	PlatformHuawei  = "HUAWEI"
+)
+
+// WebPushKeysModel keys of browser PushSubscription, token holds subscription endpoint
+type WebPushKeysModel struct {
+	P256dh string `bson:"p256dh" json:"p256dh"`
+	Auth   string `bson:"auth" json:"auth"`
+}
+
 type TokenModel struct {
-	Platform  string    `json:"platform"`
-	Token     string    `json:"token"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Platform  string            `bson:"platform" json:"platform"`
+	Token     string            `bson:"token" json:"token"`
+	Keys      *WebPushKeysModel `bson:"keys,omitempty" json:"keys,omitempty"`
+	CreatedAt time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time         `bson:"updated_at" json:"updated_at"`
 }
 
 type SubTokenModel struct {
 	ID     primitive.ObjectID `bson:"_id" json:"id,omitempty"`
-	SubID  string             `json:"sub_id"`
-	Tokens []*TokenModel      `json:"tokens"`
+	SubID  string             `bson:"sub_id" json:"sub_id"`
+	Tokens []*TokenModel      `bson:"tokens" json:"tokens"`
 }
 
 type SubTokenCreateModel struct {
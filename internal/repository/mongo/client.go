@@ -1,21 +1,37 @@
 package mongo
 
 import (
+	"context"
+
 	"github.com/WildEgor/gNotifier/internal/configs"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func NewMongoClient(
 	cfg *configs.MongoConfig,
+	tracingConfig *configs.TracingConfig,
 ) (*mongo.Client, error) {
 	opts := options.Client()
 	opts.Hosts = append(opts.Hosts, cfg.GetHost())
-	opts.Auth = &options.Credential{
-		Username: cfg.Username,
-		Password: cfg.Password,
+	if cfg.Username != "" {
+		opts.Auth = &options.Credential{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}
+	}
+
+	// commands traced as children of span of passed context, as root spans otherwise
+	if tracingConfig.Enabled() && tracingConfig.Mongo {
+		opts.Monitor = otelmongo.NewMonitor()
 	}
-	return mongo.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	// Connect does not block on server selection, so unavailable mongo not prevent start
+	return mongo.Connect(ctx, opts)
 }
 
 func NewMongoDatabase(
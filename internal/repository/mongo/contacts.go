@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const contactsCollectionName string = "notification_contacts"
+
+type IContactsRepository interface {
+	Find(ctx context.Context, subID string) (*models.ContactsModel, error)
+	Upsert(ctx context.Context, m *models.ContactsModel) (*models.ContactsModel, error)
+}
+
+type ContactsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewContactsRepository(db *mongo.Database) (*ContactsRepository, error) {
+	r := &ContactsRepository{
+		collection: db.Collection(contactsCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sub_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Error("[ContactsRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+// Find returns subscriber contacts or nil if subscriber never set them
+func (r *ContactsRepository) Find(ctx context.Context, subID string) (*models.ContactsModel, error) {
+	var result *models.ContactsModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res := r.collection.FindOne(ctx, bson.M{"sub_id": subID})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Upsert replace subscriber contacts
+func (r *ContactsRepository) Upsert(ctx context.Context, m *models.ContactsModel) (*models.ContactsModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	m.UpdatedAt = time.Now()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"sub_id": m.SubID,
+	}, bson.M{
+		"$set": bson.M{
+			"email":      m.Email,
+			"phone":      m.Phone,
+			"updated_at": m.UpdatedAt,
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Find(ctx, m.SubID)
+}
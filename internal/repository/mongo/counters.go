@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const countersCollectionName string = "notification_counters"
+
+type ICountersRepository interface {
+	Reserve(ctx context.Context, key string, limit int64, expiresAt time.Time) (bool, error)
+	Release(ctx context.Context, key string) error
+}
+
+type CountersRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCountersRepository(db *mongo.Database) (*CountersRepository, error) {
+	r := &CountersRepository{
+		collection: db.Collection(countersCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// counters of past windows removed by mongo itself
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		log.Error("[CountersRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+// Reserve atomically increment counter (created if not exists) if it is below limit. Counter at limit does
+// not match filter, so upsert tries to insert it again and fails on unique key
+func (r *CountersRepository) Reserve(ctx context.Context, key string, limit int64, expiresAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	// first duplicate could be concurrent insert of new counter, second one means counter is at limit
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := r.collection.UpdateOne(ctx, bson.M{
+			"key":   key,
+			"count": bson.M{"$lt": limit},
+		}, bson.M{
+			"$inc":         bson.M{"count": 1},
+			"$setOnInsert": bson.M{"expires_at": expiresAt},
+		}, options.Update().SetUpsert(true))
+		if err == nil {
+			return true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// Release decrement counter of reservation not used
+func (r *CountersRepository) Release(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"key":   key,
+		"count": bson.M{"$gt": 0},
+	}, bson.M{
+		"$inc": bson.M{"count": -1},
+	})
+
+	return err
+}
@@ -0,0 +1,180 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const digestsCollectionName string = "notification_digests"
+
+type IDigestsRepository interface {
+	Append(ctx context.Context, d *models.DigestModel, item *models.DigestItemModel, maxItems int) (*models.DigestModel, error)
+	FlushNow(ctx context.Context, id primitive.ObjectID) error
+	ClaimDue(ctx context.Context, now time.Time, lock time.Duration) (*models.DigestModel, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type DigestsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDigestsRepository(db *mongo.Database) (*DigestsRepository, error) {
+	r := &DigestsRepository{
+		collection: db.Collection(digestsCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// single open digest per subscriber, key and channel
+			Keys: bson.D{{Key: "sub_id", Value: 1}, {Key: "key", Value: 1}, {Key: "channel", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"flushing": false}),
+		},
+		{
+			Keys: bson.D{{Key: "flush_at", Value: 1}},
+		},
+	})
+	if err != nil {
+		log.Error("[DigestsRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+// Append add item to open digest of subscriber, digest opened with d fields if there is no one.
+// Returns digest with item added
+func (r *DigestsRepository) Append(ctx context.Context, d *models.DigestModel, item *models.DigestItemModel, maxItems int) (*models.DigestModel, error) {
+	var result *models.DigestModel
+
+	now := time.Now()
+	item.CreatedAt = now
+
+	filter := bson.M{
+		"sub_id":   d.SubID,
+		"key":      d.Key,
+		"channel":  d.Channel,
+		"flushing": false,
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"category":   d.Category,
+			"payload":    d.Payload,
+			"flush_at":   d.FlushAt,
+			"created_at": now,
+		},
+		"$push": bson.M{
+			"items":     bson.M{"$each": []*models.DigestItemModel{item}, "$slice": maxItems},
+			"notif_ids": item.NotifID,
+		},
+		"$inc": bson.M{"count": 1},
+		"$set": bson.M{"updated_at": now},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	// concurrent upserts of the same digest race for unique index, loser appends to created one
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		err = func() error {
+			ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+			defer cancel()
+
+			return r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+		}()
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FlushNow make open digest due, so flusher sends it on next poll
+func (r *DigestsRepository) FlushNow(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":      id,
+		"flushing": false,
+	}, bson.M{
+		"$set": bson.M{
+			"flush_at":   now,
+			"updated_at": now,
+		},
+	})
+
+	return err
+}
+
+// ClaimDue lock the most overdue digest for caller, so other instances skip it.
+// Lock expires if caller died before delete, so digest sent at least once
+func (r *DigestsRepository) ClaimDue(ctx context.Context, now time.Time, lock time.Duration) (*models.DigestModel, error) {
+	return r.claim(ctx, bson.M{
+		"flush_at": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"flushing": false},
+			{"locked_until": bson.M{"$lte": now}},
+		},
+	}, now, lock, bson.D{{Key: "flush_at", Value: 1}})
+}
+
+func (r *DigestsRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+
+	return err
+}
+
+func (r *DigestsRepository) claim(ctx context.Context, query bson.M, now time.Time, lock time.Duration, sort bson.D) (*models.DigestModel, error) {
+	var result *models.DigestModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if sort != nil {
+		opts.SetSort(sort)
+	}
+
+	res := r.collection.FindOneAndUpdate(ctx, query, bson.M{
+		"$set": bson.M{
+			"flushing":     true,
+			"locked_until": now.Add(lock),
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}, opts)
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
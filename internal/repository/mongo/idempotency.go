@@ -0,0 +1,137 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyCollectionName string = "notification_idempotency"
+
+type IIdempotencyRepository interface {
+	Begin(ctx context.Context, key, notifID string, ttl, lock time.Duration) (*models.IdempotencyModel, error)
+	Complete(ctx context.Context, m *models.IdempotencyModel, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *mongo.Database) (*IdempotencyRepository, error) {
+	r := &IdempotencyRepository{
+		collection: db.Collection(idempotencyCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// forgotten keys removed by mongo itself
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		log.Error("[IdempotencyRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+// Begin mark key as processing by caller. If key already seen, nil error and existing record returned,
+// nil record means caller owns the key. Abandoned (locked for too long) keys are taken over
+func (r *IdempotencyRepository) Begin(ctx context.Context, key, notifID string, ttl, lock time.Duration) (*models.IdempotencyModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	_, err := r.collection.InsertOne(ctx, &models.IdempotencyModel{
+		Key:       key,
+		Status:    models.IdempotencyProcessing,
+		NotifID:   notifID,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err == nil {
+		return nil, nil
+	}
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	// take over key if its owner died
+	res := r.collection.FindOneAndUpdate(ctx, bson.M{
+		"key":        key,
+		"status":     models.IdempotencyProcessing,
+		"updated_at": bson.M{"$lte": now.Add(-lock)},
+	}, bson.M{
+		"$set": bson.M{
+			"notif_id":   notifID,
+			"expires_at": now.Add(ttl),
+			"updated_at": now,
+		},
+	})
+	if err := res.Err(); err == nil {
+		return nil, nil
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	var existing *models.IdempotencyModel
+	if err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&existing); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// Complete store result of processing to be returned for duplicates
+func (r *IdempotencyRepository) Complete(ctx context.Context, m *models.IdempotencyModel, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"key": m.Key,
+	}, bson.M{
+		"$set": bson.M{
+			"status":         models.IdempotencyDone,
+			"notif_id":       m.NotifID,
+			"result_status":  m.ResultStatus,
+			"result_channel": m.ResultChannel,
+			"result_reason":  m.ResultReason,
+			"expires_at":     now.Add(ttl),
+			"updated_at":     now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}, options.Update().SetUpsert(true))
+
+	return err
+}
+
+// Release forget key, so notification could be processed again (e.g. after failure)
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"key": key})
+
+	return err
+}
@@ -0,0 +1,223 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	inboxCollectionName string = "notification_inbox"
+	inboxDefaultLimit   int64  = 20
+	inboxMaxLimit       int64  = 100
+)
+
+type IInboxRepository interface {
+	Create(ctx context.Context, m *models.InboxItemModel) (*models.InboxItemModel, error)
+	List(ctx context.Context, f *models.InboxFilter) ([]*models.InboxItemModel, string, error)
+	CountUnread(ctx context.Context, subID string) (int64, error)
+	MarkRead(ctx context.Context, subID string, ids []string, read bool) (int64, error)
+	Archive(ctx context.Context, subID string, ids []string) (int64, error)
+	Delete(ctx context.Context, subID string, ids []string) (int64, error)
+}
+
+type InboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInboxRepository(db *mongo.Database) (*InboxRepository, error) {
+	r := &InboxRepository{
+		collection: db.Collection(inboxCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "sub_id", Value: 1}, {Key: "_id", Value: -1}},
+		},
+		{
+			// expired items removed by mongo itself
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		log.Error("[InboxRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+func (r *InboxRepository) Create(ctx context.Context, m *models.InboxItemModel) (*models.InboxItemModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+
+	res, err := r.collection.InsertOne(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	m.ID = res.InsertedID.(primitive.ObjectID)
+
+	return m, nil
+}
+
+// List returns page of subscriber inbox (newest first) and cursor of next page
+func (r *InboxRepository) List(ctx context.Context, f *models.InboxFilter) ([]*models.InboxItemModel, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = inboxDefaultLimit
+	}
+	if limit > inboxMaxLimit {
+		limit = inboxMaxLimit
+	}
+
+	query := r.subQuery(f.SubID)
+
+	switch f.Status {
+	case models.InboxStatusUnread:
+		query["read_at"] = nil
+		query["archived_at"] = nil
+	case models.InboxStatusRead:
+		query["read_at"] = bson.M{"$ne": nil}
+		query["archived_at"] = nil
+	case models.InboxStatusArchived:
+		query["archived_at"] = bson.M{"$ne": nil}
+	case models.InboxStatusAll:
+	default:
+		query["archived_at"] = nil
+	}
+
+	if f.Cursor != "" {
+		cursorID, err := primitive.ObjectIDFromHex(f.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query["_id"] = bson.M{"$lt": cursorID}
+	}
+
+	// fetch one more item to know if next page exists
+	cur, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := make([]*models.InboxItemModel, 0, limit)
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if int64(len(items)) > limit {
+		items = items[:limit]
+		next = items[limit-1].ID.Hex()
+	}
+
+	return items, next, nil
+}
+
+func (r *InboxRepository) CountUnread(ctx context.Context, subID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	query := r.subQuery(subID)
+	query["read_at"] = nil
+	query["archived_at"] = nil
+
+	return r.collection.CountDocuments(ctx, query)
+}
+
+// MarkRead mark items as read (or unread). All subscriber items affected if ids are empty
+func (r *InboxRepository) MarkRead(ctx context.Context, subID string, ids []string, read bool) (int64, error) {
+	var readAt *time.Time
+	if read {
+		now := time.Now()
+		readAt = &now
+	}
+
+	return r.update(ctx, subID, ids, bson.M{"$set": bson.M{"read_at": readAt}})
+}
+
+func (r *InboxRepository) Archive(ctx context.Context, subID string, ids []string) (int64, error) {
+	return r.update(ctx, subID, ids, bson.M{"$set": bson.M{"archived_at": time.Now()}})
+}
+
+func (r *InboxRepository) Delete(ctx context.Context, subID string, ids []string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	query, err := r.itemsQuery(subID, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.collection.DeleteMany(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
+
+func (r *InboxRepository) update(ctx context.Context, subID string, ids []string, update bson.M) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	query, err := r.itemsQuery(subID, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.collection.UpdateMany(ctx, query, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// subQuery skips items which are expired, but not yet removed by TTL monitor
+func (r *InboxRepository) subQuery(subID string) bson.M {
+	return bson.M{
+		"sub_id": subID,
+		"$or": []bson.M{
+			{"expires_at": nil},
+			{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+}
+
+func (r *InboxRepository) itemsQuery(subID string, ids []string) (bson.M, error) {
+	query := bson.M{"sub_id": subID}
+
+	if len(ids) > 0 {
+		objectIDs := make([]primitive.ObjectID, 0, len(ids))
+		for _, id := range ids {
+			oid, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return nil, err
+			}
+			objectIDs = append(objectIDs, oid)
+		}
+		query["_id"] = bson.M{"$in": objectIDs}
+	}
+
+	return query, nil
+}
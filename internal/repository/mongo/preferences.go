@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const preferencesCollectionName string = "notification_preferences"
+
+type IPreferencesRepository interface {
+	Find(ctx context.Context, subID string) (*models.PreferencesModel, error)
+	Upsert(ctx context.Context, m *models.PreferencesModel) (*models.PreferencesModel, error)
+}
+
+type PreferencesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPreferencesRepository(db *mongo.Database) (*PreferencesRepository, error) {
+	r := &PreferencesRepository{
+		collection: db.Collection(preferencesCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sub_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Error("[PreferencesRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+// Find returns subscriber preferences or nil if subscriber never set them
+func (r *PreferencesRepository) Find(ctx context.Context, subID string) (*models.PreferencesModel, error) {
+	var result *models.PreferencesModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res := r.collection.FindOne(ctx, bson.M{"sub_id": subID})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Upsert replace subscriber preferences
+func (r *PreferencesRepository) Upsert(ctx context.Context, m *models.PreferencesModel) (*models.PreferencesModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	m.UpdatedAt = time.Now()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"sub_id": m.SubID,
+	}, bson.M{
+		"$set": bson.M{
+			"muted":       m.Muted,
+			"muted_until": m.MutedUntil,
+			"categories":  m.Categories,
+			"timezone":    m.Timezone,
+			"quiet_hours": m.QuietHours,
+			"updated_at":  m.UpdatedAt,
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Find(ctx, m.SubID)
+}
@@ -0,0 +1,298 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	recurringCollectionName string = "notification_recurring"
+	recurringDefaultLimit   int64  = 20
+	recurringMaxLimit       int64  = 100
+)
+
+type IRecurringRepository interface {
+	Create(ctx context.Context, m *models.RecurringScheduleModel) (*models.RecurringScheduleModel, error)
+	FindByID(ctx context.Context, id string) (*models.RecurringScheduleModel, error)
+	List(ctx context.Context, f *models.RecurringFilter) ([]*models.RecurringScheduleModel, string, error)
+	Update(ctx context.Context, m *models.RecurringScheduleModel) (*models.RecurringScheduleModel, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	FindDue(ctx context.Context, now time.Time, limit int64) ([]*models.RecurringScheduleModel, error)
+	Claim(ctx context.Context, m *models.RecurringScheduleModel, now time.Time, lock time.Duration) (bool, error)
+	Extend(ctx context.Context, m *models.RecurringScheduleModel, lockedUntil, until time.Time) (bool, error)
+	Advance(ctx context.Context, m *models.RecurringScheduleModel, next *time.Time, firedAt time.Time) (bool, error)
+}
+
+type RecurringRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRecurringRepository(db *mongo.Database) (*RecurringRepository, error) {
+	r := &RecurringRepository{
+		collection: db.Collection(recurringCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_run_at", Value: 1}},
+	})
+	if err != nil {
+		log.Error("[RecurringRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+func (r *RecurringRepository) Create(ctx context.Context, m *models.RecurringScheduleModel) (*models.RecurringScheduleModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+
+	res, err := r.collection.InsertOne(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	m.ID = res.InsertedID.(primitive.ObjectID)
+
+	return m, nil
+}
+
+// FindByID returns schedule or nil if there is no such schedule
+func (r *RecurringRepository) FindByID(ctx context.Context, id string) (*models.RecurringScheduleModel, error) {
+	var result *models.RecurringScheduleModel
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res := r.collection.FindOne(ctx, bson.M{"_id": oid})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// List returns page of schedules (in order they were created) and cursor of next page
+func (r *RecurringRepository) List(ctx context.Context, f *models.RecurringFilter) ([]*models.RecurringScheduleModel, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = recurringDefaultLimit
+	}
+	if limit > recurringMaxLimit {
+		limit = recurringMaxLimit
+	}
+
+	query := bson.M{}
+	if f.Status != "" {
+		query["status"] = f.Status
+	}
+
+	if f.Cursor != "" {
+		cursorID, err := primitive.ObjectIDFromHex(f.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query["_id"] = bson.M{"$gt": cursorID}
+	}
+
+	// fetch one more item to know if next page exists
+	cur, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := make([]*models.RecurringScheduleModel, 0, limit)
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if int64(len(items)) > limit {
+		items = items[:limit]
+		next = items[limit-1].ID.Hex()
+	}
+
+	return items, next, nil
+}
+
+// Update replace schedule, nil returned if there is no such schedule
+func (r *RecurringRepository) Update(ctx context.Context, m *models.RecurringScheduleModel) (*models.RecurringScheduleModel, error) {
+	var result *models.RecurringScheduleModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	m.UpdatedAt = time.Now()
+
+	res := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": m.ID}, bson.M{
+		"$set": bson.M{
+			"name":        m.Name,
+			"cron":        m.Cron,
+			"timezone":    m.Timezone,
+			"template":    m.Template,
+			"audience":    m.Audience,
+			"payload":     m.Payload,
+			"start_at":    m.StartAt,
+			"end_at":      m.EndAt,
+			"status":      m.Status,
+			"next_run_at": m.NextRunAt,
+			"updated_at":  m.UpdatedAt,
+		},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *RecurringRepository) Delete(ctx context.Context, id string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return false, err
+	}
+
+	return res.DeletedCount > 0, nil
+}
+
+// FindDue returns active schedules which occurrence is due
+func (r *RecurringRepository) FindDue(ctx context.Context, now time.Time, limit int64) ([]*models.RecurringScheduleModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	cur, err := r.collection.Find(ctx, bson.M{
+		"status":      models.RecurringStatusActive,
+		"next_run_at": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"locked_until": nil},
+			{"locked_until": bson.M{"$lte": now}},
+		},
+	}, options.Find().
+		SetSort(bson.D{{Key: "next_run_at", Value: 1}}).
+		SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.RecurringScheduleModel, 0, limit)
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Claim lock due occurrence for caller, so other instances skip it while it is fired. Lock expires if
+// caller died before Advance, so occurrence is fired again
+func (r *RecurringRepository) Claim(ctx context.Context, m *models.RecurringScheduleModel, now time.Time, lock time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":         m.ID,
+		"status":      models.RecurringStatusActive,
+		"next_run_at": m.NextRunAt,
+		"$or": []bson.M{
+			{"locked_until": nil},
+			{"locked_until": bson.M{"$lte": now}},
+		},
+	}, bson.M{"$set": bson.M{"locked_until": now.Add(lock)}})
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// Extend prolong claim of occurrence being fired till until. It returns false if claim was lost: it expired
+// and was taken by another instance or schedule was changed meanwhile
+func (r *RecurringRepository) Extend(ctx context.Context, m *models.RecurringScheduleModel, lockedUntil, until time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":          m.ID,
+		"status":       models.RecurringStatusActive,
+		"next_run_at":  m.NextRunAt,
+		"locked_until": lockedUntil,
+	}, bson.M{"$set": bson.M{"locked_until": until}})
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
+// Advance move schedule to next occurrence only if nobody did it before (compare-and-swap by next_run_at)
+// and release claim. Schedule completed if there is no next occurrence
+func (r *RecurringRepository) Advance(ctx context.Context, m *models.RecurringScheduleModel, next *time.Time, firedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	set := bson.M{
+		"next_run_at": next,
+		"last_run_at": firedAt,
+		"updated_at":  time.Now(),
+	}
+	if next == nil {
+		set["status"] = models.RecurringStatusCompleted
+	}
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":         m.ID,
+		"status":      models.RecurringStatusActive,
+		"next_run_at": m.NextRunAt,
+	}, bson.M{"$set": set, "$unset": bson.M{"locked_until": ""}})
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
@@ -0,0 +1,315 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	scheduleCollectionName string = "notification_schedule"
+	scheduleDefaultLimit   int64  = 20
+	scheduleMaxLimit       int64  = 100
+	// scheduleLegacyNotifIndex is non-unique notif_id index replaced by unique one
+	scheduleLegacyNotifIndex string = "notif_id_1"
+)
+
+// ErrScheduleLocked returned if notification is being dispatched right now
+var ErrScheduleLocked = errors.New("[ScheduleRepository] Notification is being dispatched")
+
+// ErrScheduleExists returned if notification with the same notif_id is already stored
+var ErrScheduleExists = errors.New("[ScheduleRepository] Notification is already scheduled")
+
+type IScheduleRepository interface {
+	Create(ctx context.Context, m *models.ScheduledNotificationModel) (*models.ScheduledNotificationModel, error)
+	ClaimDue(ctx context.Context, now time.Time, lock time.Duration) (*models.ScheduledNotificationModel, error)
+	DeleteClaimed(ctx context.Context, m *models.ScheduledNotificationModel) error
+	Retry(ctx context.Context, id primitive.ObjectID, sendAt time.Time) error
+	List(ctx context.Context, f *models.ScheduleFilter) ([]*models.ScheduledNotificationModel, string, error)
+	Reschedule(ctx context.Context, notifID string, sendAt time.Time) (*models.ScheduledNotificationModel, error)
+	Cancel(ctx context.Context, notifID string) (*models.ScheduledNotificationModel, error)
+}
+
+type ScheduleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewScheduleRepository(db *mongo.Database) (*ScheduleRepository, error) {
+	r := &ScheduleRepository{
+		collection: db.Collection(scheduleCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	// unique index has the same keys, so non-unique one must be dropped first
+	if _, err := r.collection.Indexes().DropOne(ctx, scheduleLegacyNotifIndex); err != nil {
+		log.Debug("[ScheduleRepository] Legacy notif_id index not dropped: ", err)
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "send_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "notif_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"notif_id": bson.M{"$gt": ""}}),
+		},
+	})
+	if err != nil {
+		log.Error("[ScheduleRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+// Create store notification. Notification being dispatched (e.g. deferred again by quiet hours) is replaced,
+// ErrScheduleExists returned if it is pending
+func (r *ScheduleRepository) Create(ctx context.Context, m *models.ScheduledNotificationModel) (*models.ScheduledNotificationModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+
+	res, err := r.collection.InsertOne(ctx, m)
+	if err == nil {
+		m.ID = res.InsertedID.(primitive.ObjectID)
+		return m, nil
+	}
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var result *models.ScheduledNotificationModel
+
+	replaced := r.collection.FindOneAndUpdate(ctx, bson.M{
+		"notif_id":     m.NotifID,
+		"locked_until": bson.M{"$gt": now},
+	}, bson.M{
+		"$set": bson.M{
+			"sub_id":     m.SubID,
+			"reason":     m.Reason,
+			"send_at":    m.SendAt,
+			"payload":    m.Payload,
+			"attempts":   0,
+			"updated_at": now,
+		},
+		"$unset": bson.M{"locked_until": ""},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := replaced.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrScheduleExists
+		}
+		return nil, err
+	}
+
+	if err := replaced.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ClaimDue lock the most overdue notification for caller, so other instances skip it.
+// Lock expires if caller died before delete, so notification sent at least once
+func (r *ScheduleRepository) ClaimDue(ctx context.Context, now time.Time, lock time.Duration) (*models.ScheduledNotificationModel, error) {
+	var result *models.ScheduledNotificationModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res := r.collection.FindOneAndUpdate(ctx, bson.M{
+		"send_at": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"locked_until": nil},
+			{"locked_until": bson.M{"$lte": now}},
+		},
+	}, bson.M{
+		"$set": bson.M{
+			"locked_until": now.Add(lock),
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}, options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "send_at", Value: 1}}).
+		SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteClaimed remove notification claimed by ClaimDue, unless it was stored again while being dispatched
+func (r *ScheduleRepository) DeleteClaimed(ctx context.Context, m *models.ScheduledNotificationModel) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": m.ID, "locked_until": m.LockedUntil})
+
+	return err
+}
+
+// Retry release claimed notification and move it to sendAt
+func (r *ScheduleRepository) Retry(ctx context.Context, id primitive.ObjectID, sendAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"send_at":    sendAt,
+			"updated_at": time.Now(),
+		},
+		"$unset": bson.M{"locked_until": ""},
+	})
+
+	return err
+}
+
+// List returns page of pending notifications (in order they were stored) and cursor of next page
+func (r *ScheduleRepository) List(ctx context.Context, f *models.ScheduleFilter) ([]*models.ScheduledNotificationModel, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = scheduleDefaultLimit
+	}
+	if limit > scheduleMaxLimit {
+		limit = scheduleMaxLimit
+	}
+
+	query := bson.M{}
+	if f.SubID != "" {
+		query["sub_id"] = f.SubID
+	}
+	if f.Reason != "" {
+		query["reason"] = f.Reason
+	}
+
+	if f.Cursor != "" {
+		cursorID, err := primitive.ObjectIDFromHex(f.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query["_id"] = bson.M{"$gt": cursorID}
+	}
+
+	// fetch one more item to know if next page exists
+	cur, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := make([]*models.ScheduledNotificationModel, 0, limit)
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if int64(len(items)) > limit {
+		items = items[:limit]
+		next = items[limit-1].ID.Hex()
+	}
+
+	return items, next, nil
+}
+
+// Reschedule move pending notification to another time, nil returned if there is no such notification
+func (r *ScheduleRepository) Reschedule(ctx context.Context, notifID string, sendAt time.Time) (*models.ScheduledNotificationModel, error) {
+	var result *models.ScheduledNotificationModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res := r.collection.FindOneAndUpdate(ctx, r.unlockedQuery(notifID), bson.M{
+		"$set": bson.M{
+			"send_at":    sendAt,
+			"updated_at": time.Now(),
+		},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, r.lockedErr(ctx, notifID)
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Cancel remove pending notification, nil returned if there is no such notification
+func (r *ScheduleRepository) Cancel(ctx context.Context, notifID string) (*models.ScheduledNotificationModel, error) {
+	var result *models.ScheduledNotificationModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res := r.collection.FindOneAndDelete(ctx, r.unlockedQuery(notifID))
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, r.lockedErr(ctx, notifID)
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// unlockedQuery match notification not claimed by dispatcher
+func (r *ScheduleRepository) unlockedQuery(notifID string) bson.M {
+	return bson.M{
+		"notif_id": notifID,
+		"$or": []bson.M{
+			{"locked_until": nil},
+			{"locked_until": bson.M{"$lte": time.Now()}},
+		},
+	}
+}
+
+// lockedErr tells apart missed notification and notification being dispatched
+func (r *ScheduleRepository) lockedErr(ctx context.Context, notifID string) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"notif_id": notifID})
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrScheduleLocked
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const statusesCollectionName string = "notification_statuses"
+
+type IStatusesRepository interface {
+	Save(ctx context.Context, m *models.NotificationStatusModel) error
+	FindByNotifID(ctx context.Context, notifID string) (*models.NotificationStatusModel, error)
+	MarkOpened(ctx context.Context, notifID string) (*models.NotificationStatusModel, error)
+}
+
+type StatusesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewStatusesRepository(db *mongo.Database) (*StatusesRepository, error) {
+	r := &StatusesRepository{
+		collection: db.Collection(statusesCollectionName),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "notif_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Error("[StatusesRepository] Failed create indexes: ", err)
+	}
+
+	return r, nil
+}
+
+// Save set current status of notification and append it to history
+func (r *StatusesRepository) Save(ctx context.Context, m *models.NotificationStatusModel) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	set := bson.M{
+		"status":     m.Status,
+		"channel":    m.Channel,
+		"reason":     m.Reason,
+		"error":      m.Error,
+		"updated_at": now,
+	}
+	if m.SubID != "" {
+		set["sub_id"] = m.SubID
+	}
+	if m.Category != "" {
+		set["category"] = m.Category
+	}
+	if m.Status == models.StatusSent {
+		set["delivered_channel"] = m.Channel
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"notif_id": m.NotifID,
+	}, bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"created_at": now},
+		"$push": bson.M{
+			"history": &models.NotificationStatusHistoryModel{
+				Status:    m.Status,
+				Channel:   m.Channel,
+				Reason:    m.Reason,
+				Error:     m.Error,
+				CreatedAt: now,
+			},
+		},
+	}, options.Update().SetUpsert(true))
+
+	return err
+}
+
+func (r *StatusesRepository) FindByNotifID(ctx context.Context, notifID string) (*models.NotificationStatusModel, error) {
+	var result *models.NotificationStatusModel
+
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	res := r.collection.FindOne(ctx, bson.M{"notif_id": notifID})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := res.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MarkOpened set notification opened (first open only counts), nil returned if notification not found
+func (r *StatusesRepository) MarkOpened(ctx context.Context, notifID string) (*models.NotificationStatusModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"notif_id":  notifID,
+		"opened_at": nil,
+	}, bson.M{
+		"$set": bson.M{
+			"status":     models.StatusOpened,
+			"opened_at":  now,
+			"updated_at": now,
+		},
+		"$push": bson.M{
+			"history": &models.NotificationStatusHistoryModel{
+				Status:    models.StatusOpened,
+				CreatedAt: now,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FindByNotifID(ctx, notifID)
+}
@@ -2,14 +2,16 @@ package mongo
 
 import (
 	"context"
-	"github.com/pkg/errors"
-	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const (
@@ -24,141 +26,267 @@ type TokensFilter struct {
 }
 
 type ITokensRepository interface {
-	FindSub(f *TokensFilter) (*models.SubTokenModel, error)
-	UpsertToken(m *models.SubTokenCreateModel) (*models.SubTokenModel, error)
+	FindSub(ctx context.Context, f *TokensFilter) (*models.SubTokenModel, error)
+	UpsertToken(ctx context.Context, m *models.SubTokenCreateModel) (*models.SubTokenModel, error)
+	DeleteTokens(ctx context.Context, subID string, tokens []string) error
+	EachSubID(ctx context.Context, platform string, fn func(subID string) bool) error
+	CountByPlatform(ctx context.Context) (map[string]int64, error)
 }
 
 type TokensRepository struct {
 	collection *mongo.Collection
 }
 
+// legacySubTokenModel document stored before field names were set explicitly: driver lowercased Go names,
+// and every token was inserted as separate document of subscriber
+type legacySubTokenModel struct {
+	ID     primitive.ObjectID `bson:"_id"`
+	SubID  string             `bson:"subid"`
+	Tokens []*struct {
+		Platform  string    `bson:"platform"`
+		Token     string    `bson:"token"`
+		CreatedAt time.Time `bson:"createdat"`
+		UpdatedAt time.Time `bson:"updatedat"`
+	} `bson:"tokens"`
+}
+
 func NewTokensRepository(db *mongo.Database) (*TokensRepository, error) {
-	return &TokensRepository{
+	r := &TokensRepository{
 		collection: db.Collection(collectionName),
-	}, nil
+	}
+
+	if err := r.migrateLegacy(); err != nil {
+		log.Error("[TokensRepository] Failed migrate legacy tokens: ", err)
+	}
+
+	return r, nil
 }
 
-func (r *TokensRepository) FindSub(f *TokensFilter) (*models.SubTokenModel, error) {
+// migrateLegacy move tokens of legacy documents into subscriber document with current field names. Legacy
+// document is removed only after all its tokens are moved, so interrupted migration is continued on next start
+func (r *TokensRepository) migrateLegacy() error {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, bson.M{"subid": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	migrated := 0
+	for {
+		batchCtx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+		next := cur.Next(batchCtx)
+		cancel()
+		if !next {
+			break
+		}
+
+		var legacy legacySubTokenModel
+		if err := cur.Decode(&legacy); err != nil {
+			return err
+		}
+
+		for _, t := range legacy.Tokens {
+			if t == nil || t.Token == "" {
+				continue
+			}
+
+			if err := r.upsertToken(ctx, legacy.SubID, &models.TokenModel{
+				Platform:  t.Platform,
+				Token:     t.Token,
+				CreatedAt: t.CreatedAt,
+				UpdatedAt: t.UpdatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+
+		deleteCtx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+		_, err := r.collection.DeleteOne(deleteCtx, bson.M{"_id": legacy.ID})
+		cancel()
+		if err != nil {
+			return err
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		log.Infof("[TokensRepository] Migrated %d legacy token documents", migrated)
+	}
+
+	return cur.Err()
+}
+
+func (r *TokensRepository) FindSub(ctx context.Context, f *TokensFilter) (*models.SubTokenModel, error) {
 	var result *models.SubTokenModel
-	var (
-		andQuery []bson.M
-		query    []bson.M
-	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
 	defer cancel()
 
+	query := bson.M{}
+
 	if len(f.Platform) > 0 {
-		query = append(query, bson.M{"platform": bson.M{"$eq": f.Platform}})
+		query["tokens.platform"] = bson.M{"$eq": f.Platform}
 	}
 
 	if len(f.SubId) > 0 {
-		query = append(query, bson.M{"sub_id": bson.M{"$eq": f.SubId}})
+		query["sub_id"] = bson.M{"$eq": f.SubId}
 	}
 
 	if len(f.Token) > 0 {
-		query = append(query, bson.M{"tokens": bson.M{"$in": []string{f.Token}}})
+		query["tokens.token"] = bson.M{"$eq": f.Token}
 	}
 
-	andQuery = append(andQuery, bson.M{"$and": func() []bson.M {
-		if query != nil {
-			if len(query) > 0 {
-				return query
-			}
-		}
-		return []bson.M{}
-	}()})
-
-	err := r.collection.FindOne(ctx, andQuery)
-	if err != nil {
-		if errors.Is(err.Err(), mongo.ErrNoDocuments) {
+	res := r.collection.FindOne(ctx, query)
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
 		}
-		return nil, err.Err()
+		return nil, err
 	}
 
-	dErr := err.Decode(&result)
-	if dErr != nil {
-		return nil, dErr
+	if err := res.Decode(&result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-func (r *TokensRepository) UpsertToken(m *models.SubTokenCreateModel) (*models.SubTokenModel, error) {
-	var existedResult *models.SubTokenModel
-	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+func (r *TokensRepository) UpsertToken(ctx context.Context, m *models.SubTokenCreateModel) (*models.SubTokenModel, error) {
+	now := time.Now()
+
+	if err := r.upsertToken(ctx, m.SubID, &models.TokenModel{
+		Platform:  m.Token.Platform,
+		Token:     m.Token.Token,
+		Keys:      m.Token.Keys,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return r.FindSub(ctx, &TokensFilter{SubId: m.SubID})
+}
+
+// upsertToken refresh already stored token of subscriber or push new one (create subscriber if not exists)
+func (r *TokensRepository) upsertToken(ctx context.Context, subID string, token *models.TokenModel) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
 	defer cancel()
 
-	err := r.collection.FindOne(ctx, bson.M{
-		"$and": bson.M{
-			"sub_id": bson.M{
-				"$eq": m.SubID,
+	updateResult, err := r.collection.UpdateOne(ctx, bson.M{
+		"sub_id":       subID,
+		"tokens.token": token.Token,
+	}, bson.M{
+		"$set": bson.M{
+			"tokens.$.platform": token.Platform,
+			"tokens.$.keys":     token.Keys,
+		},
+		// migrated legacy duplicates never move refresh time back
+		"$max": bson.M{
+			"tokens.$.updated_at": token.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if updateResult.MatchedCount > 0 {
+		return nil
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{
+		"sub_id": subID,
+	}, bson.M{
+		"$push": bson.M{
+			"tokens": token,
+		},
+	}, options.Update().SetUpsert(true))
+
+	return err
+}
+
+// DeleteTokens remove tokens (or web push endpoints) from subscriber
+func (r *TokensRepository) DeleteTokens(ctx context.Context, subID string, tokens []string) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"sub_id": subID,
+	}, bson.M{
+		"$pull": bson.M{
+			"tokens": bson.M{
+				"token": bson.M{"$in": tokens},
 			},
 		},
 	})
+
+	return err
+}
+
+// EachSubID iterate over subscribers having tokens (of platform if passed) till fn returns false
+func (r *TokensRepository) EachSubID(ctx context.Context, platform string, fn func(subID string) bool) error {
+	// audience could be large, so timeout applied per fetched batch rather than whole iteration
+	query := bson.M{"tokens.0": bson.M{"$exists": true}}
+	if len(platform) > 0 {
+		query = bson.M{"tokens.platform": bson.M{"$eq": platform}}
+	}
+
+	cur, err := r.collection.Find(ctx, query, options.Find().SetProjection(bson.M{"sub_id": 1}))
 	if err != nil {
-		if errors.Is(err.Err(), mongo.ErrNoDocuments) {
-		}
-	} else {
-		err := err.Decode(&existedResult)
-		if err != nil {
-			return nil, err
-		}
+		return err
 	}
+	defer cur.Close(ctx)
 
-	if existedResult != nil {
-		findQuery := bson.M{
-			"_id":          existedResult.ID,
-			"tokens.token": m.Token.Token,
-		}
-		var updateQuery bson.M
-		for _, v := range existedResult.Tokens {
-			if strings.EqualFold(v.Token, m.Token.Token) {
-				updateQuery = bson.M{
-					"$set": bson.M{
-						"tokens.$.updated_at": time.Now(),
-					},
-				}
-			}
+	for {
+		batchCtx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+		next := cur.Next(batchCtx)
+		cancel()
+		if !next {
+			break
 		}
 
-		if updateQuery == nil {
-			updateQuery = bson.M{
-				"$push": bson.M{
-					"tokens": &models.TokenModel{
-						Platform:  m.Token.Platform,
-						Token:     m.Token.Token,
-						UpdatedAt: time.Now(),
-						CreatedAt: time.Now(),
-					},
-				},
-			}
+		sub := struct {
+			SubID string `bson:"sub_id"`
+		}{}
+		if err := cur.Decode(&sub); err != nil {
+			return err
 		}
 
-		_, err := r.collection.UpdateOne(ctx, findQuery, updateQuery)
-		if err != nil {
-			return nil, err
+		if !fn(sub.SubID) {
+			return nil
 		}
 	}
 
-	newTokenModel := &models.TokenModel{
-		Platform:  m.Token.Platform,
-		Token:     m.Token.Token,
-		UpdatedAt: time.Now(),
-		CreatedAt: time.Now(),
+	return cur.Err()
+}
+
+// CountByPlatform returns number of stored tokens per platform
+func (r *TokensRepository) CountByPlatform(ctx context.Context) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoQueryTimeout)
+	defer cancel()
+
+	cur, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$unwind", Value: "$tokens"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tokens.platform", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
 	}
+	defer cur.Close(ctx)
 
-	newSubModel := &models.SubTokenModel{
-		SubID:  m.SubID,
-		Tokens: []*models.TokenModel{newTokenModel},
+	var rows []struct {
+		Platform string `bson:"_id"`
+		Count    int64  `bson:"count"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
 	}
 
-	saveResult, er := r.collection.InsertOne(context.TODO(), &newSubModel)
-	if er != nil {
-		return nil, er
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Platform] = row.Count
 	}
 
-	newSubModel.ID = saveResult.InsertedID.(primitive.ObjectID)
-	return newSubModel, nil
+	return counts, nil
 }
@@ -10,4 +10,22 @@ var RepositoriesSet = wire.NewSet(
 	mongo.NewMongoDatabase,
 	mongo.NewTokensRepository,
 	wire.Bind(new(mongo.ITokensRepository), new(*mongo.TokensRepository)),
+	mongo.NewInboxRepository,
+	wire.Bind(new(mongo.IInboxRepository), new(*mongo.InboxRepository)),
+	mongo.NewStatusesRepository,
+	wire.Bind(new(mongo.IStatusesRepository), new(*mongo.StatusesRepository)),
+	mongo.NewPreferencesRepository,
+	wire.Bind(new(mongo.IPreferencesRepository), new(*mongo.PreferencesRepository)),
+	mongo.NewScheduleRepository,
+	wire.Bind(new(mongo.IScheduleRepository), new(*mongo.ScheduleRepository)),
+	mongo.NewRecurringRepository,
+	wire.Bind(new(mongo.IRecurringRepository), new(*mongo.RecurringRepository)),
+	mongo.NewIdempotencyRepository,
+	wire.Bind(new(mongo.IIdempotencyRepository), new(*mongo.IdempotencyRepository)),
+	mongo.NewCountersRepository,
+	wire.Bind(new(mongo.ICountersRepository), new(*mongo.CountersRepository)),
+	mongo.NewDigestsRepository,
+	wire.Bind(new(mongo.IDigestsRepository), new(*mongo.DigestsRepository)),
+	mongo.NewContactsRepository,
+	wire.Bind(new(mongo.IContactsRepository), new(*mongo.ContactsRepository)),
 )
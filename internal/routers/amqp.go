@@ -1,51 +1,99 @@
 package routers
 
 import (
-	"github.com/WildEgor/gNotifier/internal/configs"
+	"context"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/WildEgor/gNotifier/internal/configs"
+
 	"github.com/WildEgor/gNotifier/internal/adapters"
 	handlers "github.com/WildEgor/gNotifier/internal/handlers/amqp"
 	"github.com/WildEgor/gNotifier/internal/services/checks"
+	"github.com/WildEgor/gNotifier/internal/services/events"
+	"github.com/WildEgor/gNotifier/internal/services/priority"
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
 	log "github.com/sirupsen/logrus"
 	"github.com/wagslane/go-rabbitmq"
 )
 
 type AMQPRouter struct {
 	notifierHandler    *handlers.NotifierHandler
+	appConfig          *configs.AppConfig
 	amqpConfig         *configs.AMQPConfig
 	healthCheckAdapter *adapters.HealthCheckAdapter
+	realtimeHub        *realtime.Hub
+	priorityConfig     *configs.PriorityConfig
+	lanes              *priority.Lanes
+	events             *events.Publisher
+	eventsConfig       *configs.EventsConfig
+	realtimeConfig     *configs.RealtimeConfig
 
-	connection       *rabbitmq.Conn
-	notifierConsumer *rabbitmq.Consumer
+	connection *rabbitmq.Conn
+	consumers  []*rabbitmq.Consumer
+	// publisher moves deliveries to priority lanes and delay queues
+	publisher     *rabbitmq.Publisher
+	lanesExchange string
+
+	// mu guards draining, consumers and publisher, so no delivery is taken into work after Drain started waiting
+	mu       sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+	// stop closed by Drain, releases deliveries waiting for paused lane
+	stop chan struct{}
 }
 
 func NewAMQPRouter(
 	notifierHandler *handlers.NotifierHandler,
+	appConfig *configs.AppConfig,
 	amqpConfig *configs.AMQPConfig,
 	healthCheckAdapter *adapters.HealthCheckAdapter,
+	realtimeHub *realtime.Hub,
+	priorityConfig *configs.PriorityConfig,
+	lanes *priority.Lanes,
+	events *events.Publisher,
+	eventsConfig *configs.EventsConfig,
+	realtimeConfig *configs.RealtimeConfig,
 ) *AMQPRouter {
 	return &AMQPRouter{
 		notifierHandler:    notifierHandler,
+		appConfig:          appConfig,
 		amqpConfig:         amqpConfig,
 		healthCheckAdapter: healthCheckAdapter,
+		realtimeHub:        realtimeHub,
+		priorityConfig:     priorityConfig,
+		lanes:              lanes,
+		events:             events,
+		eventsConfig:       eventsConfig,
+		realtimeConfig:     realtimeConfig,
+		stop:               make(chan struct{}),
 	}
 }
 
+// consumerGate readiness gate open while notifications consumers are attached
+const consumerGate = "amqp-consumer"
+
+// SetupRoutes connect to broker and attach consumers. Connection is kept without consumers if notifications
+// are consumed from other transport, because realtime hub and status events are published through it. Broker
+// is not connected at all if neither of them is used
 func (r *AMQPRouter) SetupRoutes() error {
+	consuming := r.appConfig.HasTransport(configs.TransportAMQP)
+	if !consuming && !r.eventsConfig.Enabled && !r.realtimeConfig.Enabled() {
+		log.Info("[AMQPRouter] AMQP is not used by transports, events and realtime, connection skipped")
+		return nil
+	}
+
+	if consuming {
+		r.healthCheckAdapter.SetReady(consumerGate, false)
+	}
+
 	// Add health-check to global HealthCheckAdapter
 	er := r.healthCheckAdapter.Register(adapters.HealthConfig{
 		Name:      "amqp-health-check",
 		Timeout:   10 * time.Second,
 		SkipOnErr: false,
-		Check: checks.NewRabbitMQCheck(&checks.RabbitMQCheckConfig{
-			URI:            r.amqpConfig.URI,
-			DialTimeout:    5 * time.Second,
-			Exchange:       "health-check",
-			Queue:          "health-queue",
-			RoutingKey:     "health-check",
-			ConsumeTimeout: 5 * time.Second,
-		}),
+		Check:     checks.NewRabbitMQCheck(r.publisherOf),
 	})
 	if er != nil {
 		return er
@@ -56,28 +104,237 @@ func (r *AMQPRouter) SetupRoutes() error {
 		rabbitmq.WithConnectionOptionsLogging,
 	)
 	if err != nil {
-		log.Fatal("[AMQPRouter] Failed to connect: ", err)
+		return fmt.Errorf("[AMQPRouter] Failed to connect: %w", err)
 	}
 	r.connection = connection
 
-	notifierConsumer, err := rabbitmq.NewConsumer(
-		connection,
-		r.notifierHandler.Handle,
-		"notifier-queue",
-		rabbitmq.WithConsumerOptionsRoutingKey("notifier.send-notification"),
-		rabbitmq.WithConsumerOptionsExchangeName("notifications"),
-		rabbitmq.WithConsumerOptionsExchangeDeclare,
-	)
+	// Publisher is shared by lanes, delays and health check, so connection is checked without dialing
+	publisher, err := rabbitmq.NewPublisher(connection)
 	if err != nil {
-		log.Fatal("[AMQPRouter] Failed notifyConsumer: ", err)
+		return fmt.Errorf("[AMQPRouter] Failed create publisher: %w", err)
 	}
+	r.mu.Lock()
+	r.publisher = publisher
+	r.mu.Unlock()
 
-	r.notifierConsumer = notifierConsumer
+	// Status events and replies are published from handlers, so publisher is started before consumers
+	if err := r.events.Start(connection); err != nil {
+		log.Error("[AMQPRouter] Failed start events publisher: ", err)
+	}
+
+	if consuming {
+		if err := r.setupConsumers(connection); err != nil {
+			return err
+		}
+		r.healthCheckAdapter.SetReady(consumerGate, true)
+	}
+
+	// Realtime events fan out through own exchange, so every instance reach its live connections
+	if err := r.realtimeHub.Start(connection); err != nil {
+		log.Error("[AMQPRouter] Failed start realtime hub: ", err)
+	}
 
 	return nil
 }
 
+// setupConsumers attach consumers of intake queues and priority lanes
+func (r *AMQPRouter) setupConsumers(connection *rabbitmq.Conn) error {
+	// Lanes are declared before intake consumers, so notifications are never routed to missing queue
+	handler := deliveryHandler(r.notifierHandler.Handle)
+	if r.lanes.Enabled() {
+		if err := r.setupLanes(connection); err != nil {
+			return err
+		}
+		handler = r.routeToLane
+	} else if err := r.declareDelayQueues(r.amqpConfig.Consumers); err != nil {
+		return err
+	}
+
+	for _, cfg := range r.amqpConfig.Consumers {
+		consumer, err := rabbitmq.NewConsumer(
+			connection,
+			r.handle(cfg.Queue, handler),
+			cfg.Queue,
+			consumerOptions(cfg)...,
+		)
+		if err != nil {
+			return fmt.Errorf("[AMQPRouter] Failed create consumer %s: %w", cfg.Name, err)
+		}
+
+		r.addConsumer(consumer)
+	}
+
+	return nil
+}
+
+// Drain detach consumers, so broker stops sending deliveries and returns prefetched ones to queue, and wait
+// for deliveries in progress till ctx is done. Deliveries in progress could not be acknowledged on closed
+// channel, their redelivery is skipped as duplicate by idempotency key
+func (r *AMQPRouter) Drain(ctx context.Context) error {
+	r.healthCheckAdapter.SetReady(consumerGate, false)
+
+	r.mu.Lock()
+	if !r.draining {
+		r.draining = true
+		close(r.stop)
+	}
+	r.mu.Unlock()
+
+	r.closeConsumers()
+
+	done := make(chan struct{})
+	go func() {
+		r.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("[AMQPRouter] Deliveries in progress are not finished: %w", ctx.Err())
+	}
+}
+
+// Close detach consumers and close connection, unacknowledged deliveries are returned to queue by broker
 func (r *AMQPRouter) Close() {
-	defer r.connection.Close()
-	defer r.notifierConsumer.Close()
+	r.healthCheckAdapter.SetReady(consumerGate, false)
+
+	r.closeConsumers()
+
+	if publisher := r.publisherOf(); publisher != nil {
+		publisher.Close()
+	}
+
+	r.realtimeHub.Close()
+	r.events.Close()
+
+	if r.connection != nil {
+		if err := r.connection.Close(); err != nil {
+			log.Error("[AMQPRouter] Failed close connection: ", err)
+		}
+	}
+}
+
+// publisherOf returns publisher of connection, nil till connected
+func (r *AMQPRouter) publisherOf() *rabbitmq.Publisher {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.publisher
+}
+
+func (r *AMQPRouter) addConsumer(consumer *rabbitmq.Consumer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.consumers = append(r.consumers, consumer)
+}
+
+// closeConsumers close channels of consumers, unacknowledged deliveries are returned to queue by broker
+func (r *AMQPRouter) closeConsumers() {
+	r.mu.Lock()
+	consumers := r.consumers
+	r.consumers = nil
+	r.mu.Unlock()
+
+	for _, consumer := range consumers {
+		consumer.Close()
+	}
+}
+
+// handle track delivery while it is in progress and settle it with action of handler. Deliveries are
+// settled here instead of consumer, so Drain returns only after they are acknowledged. Delivery requeued
+// with delay is moved to delay queue of queue, so consumer is not blocked and delivery is not redelivered
+// at once
+func (r *AMQPRouter) handle(queue string, handler deliveryHandler) rabbitmq.Handler {
+	return func(d rabbitmq.Delivery) rabbitmq.Action {
+		r.mu.Lock()
+		draining := r.draining
+		if !draining {
+			r.inFlight.Add(1)
+		}
+		r.mu.Unlock()
+
+		// delivery prefetched before consumer was closed is left unsettled, broker returns it to queue
+		if draining {
+			return rabbitmq.Manual
+		}
+
+		defer r.inFlight.Done()
+
+		action, after := handler(d)
+		if action == rabbitmq.NackRequeue && after > 0 {
+			if err := r.delay(queue, d, after); err != nil {
+				// delivery is held instead, otherwise it is redelivered and fails again right away
+				log.Errorf("[AMQPRouter] Failed delay delivery %s, it is requeued after %s: %v", d.MessageId, after, err)
+				r.hold(after)
+			} else {
+				action = rabbitmq.Ack
+			}
+		}
+
+		var err error
+		switch action {
+		case rabbitmq.Ack:
+			err = d.Ack(false)
+		case rabbitmq.NackDiscard:
+			err = d.Nack(false, false)
+		case rabbitmq.NackRequeue:
+			err = d.Nack(false, true)
+		}
+		if err != nil {
+			if r.isDraining() {
+				log.Debug("[AMQPRouter] Delivery is not settled on closed channel, it is redelivered: ", err)
+			} else {
+				log.Error("[AMQPRouter] Failed settle delivery: ", err)
+			}
+		}
+
+		return rabbitmq.Manual
+	}
+}
+
+// hold wait for delay of delivery which could not be delayed through delay queue, till Drain
+func (r *AMQPRouter) hold(after time.Duration) {
+	timer := time.NewTimer(after)
+	defer timer.Stop()
+
+	select {
+	case <-r.stop:
+	case <-timer.C:
+	}
+}
+
+func (r *AMQPRouter) isDraining() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.draining
+}
+
+// consumerOptions declare exchange, queue and bindings of consumer
+func consumerOptions(cfg *configs.AMQPConsumerConfig) []func(*rabbitmq.ConsumerOptions) {
+	opts := []func(*rabbitmq.ConsumerOptions){
+		rabbitmq.WithConsumerOptionsExchangeName(cfg.Exchange),
+		rabbitmq.WithConsumerOptionsExchangeKind(cfg.ExchangeType),
+		rabbitmq.WithConsumerOptionsExchangeDeclare,
+		rabbitmq.WithConsumerOptionsQueueArgs(cfg.GetQueueArgs()),
+		rabbitmq.WithConsumerOptionsQOSPrefetch(cfg.Prefetch),
+		rabbitmq.WithConsumerOptionsConcurrency(cfg.Concurrency),
+	}
+
+	if cfg.ExchangeDurable {
+		opts = append(opts, rabbitmq.WithConsumerOptionsExchangeDurable)
+	}
+
+	if cfg.QueueDurable {
+		opts = append(opts, rabbitmq.WithConsumerOptionsQueueDurable)
+	}
+
+	for _, key := range cfg.GetRoutingKeys() {
+		opts = append(opts, rabbitmq.WithConsumerOptionsRoutingKey(key))
+	}
+
+	return opts
 }
@@ -0,0 +1,39 @@
+package routers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAMQPRouterHold(t *testing.T) {
+	tests := []struct {
+		name  string
+		after time.Duration
+		drain bool
+		// hold returns within
+		within time.Duration
+	}{
+		{name: "waits for delay", after: 50 * time.Millisecond, within: time.Second},
+		{name: "released by drain", after: time.Hour, drain: true, within: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &AMQPRouter{stop: make(chan struct{})}
+			if tt.drain {
+				close(r.stop)
+			}
+
+			started := time.Now()
+			r.hold(tt.after)
+			elapsed := time.Since(started)
+
+			if elapsed > tt.within {
+				t.Fatalf("hold took %s, want within %s", elapsed, tt.within)
+			}
+			if !tt.drain && elapsed < tt.after {
+				t.Fatalf("hold took %s, want at least %s", elapsed, tt.after)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package routers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/wagslane/go-rabbitmq"
+)
+
+// deliveryHandler handle delivery, NackRequeue with delay returns delivery to its queue after delay
+type deliveryHandler func(d rabbitmq.Delivery) (rabbitmq.Action, time.Duration)
+
+// delayQueueName queue deliveries of queue wait in till their expiration
+func delayQueueName(queue string) string {
+	return queue + ".delay"
+}
+
+// declareDelayQueues declare delay queue of every consumer queue. Delay queues are not consumed: expired
+// deliveries are dead-lettered through default exchange back to queue they came from
+func (r *AMQPRouter) declareDelayQueues(cfgs []*configs.AMQPConsumerConfig) error {
+	conn, err := amqp.Dial(r.amqpConfig.URI)
+	if err != nil {
+		return fmt.Errorf("[AMQPRouter] Failed connect to declare delay queues: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("[AMQPRouter] Failed open channel to declare delay queues: %w", err)
+	}
+	defer ch.Close()
+
+	for _, cfg := range cfgs {
+		_, err := ch.QueueDeclare(delayQueueName(cfg.Queue), cfg.QueueDurable, false, false, false, amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": cfg.Queue,
+		})
+		if err != nil {
+			return fmt.Errorf("[AMQPRouter] Failed declare delay queue of %s: %w", cfg.Queue, err)
+		}
+	}
+
+	return nil
+}
+
+// delay publish copy of delivery to delay queue of queue, it expires back to queue after delay
+func (r *AMQPRouter) delay(queue string, d rabbitmq.Delivery, after time.Duration) error {
+	opts := append(republishOptions(d),
+		rabbitmq.WithPublishOptionsExchange(""),
+		rabbitmq.WithPublishOptionsExpiration(strconv.FormatInt(after.Milliseconds(), 10)),
+	)
+
+	return r.publisher.PublishWithContext(context.Background(), d.Body, []string{delayQueueName(queue)}, opts...)
+}
+
+// republishOptions keep properties of delivery in its copy
+func republishOptions(d rabbitmq.Delivery) []func(*rabbitmq.PublishOptions) {
+	opts := []func(*rabbitmq.PublishOptions){
+		rabbitmq.WithPublishOptionsContentType(d.ContentType),
+		rabbitmq.WithPublishOptionsHeaders(rabbitmq.Table(d.Headers)),
+		rabbitmq.WithPublishOptionsMessageID(d.MessageId),
+		rabbitmq.WithPublishOptionsCorrelationID(d.CorrelationId),
+		rabbitmq.WithPublishOptionsReplyTo(d.ReplyTo),
+		rabbitmq.WithPublishOptionsPriority(d.Priority),
+	}
+	if d.DeliveryMode == rabbitmq.Persistent {
+		opts = append(opts, rabbitmq.WithPublishOptionsPersistentDelivery)
+	}
+	if !d.Timestamp.IsZero() {
+		opts = append(opts, rabbitmq.WithPublishOptionsTimestamp(d.Timestamp))
+	}
+
+	return opts
+}
@@ -2,26 +2,133 @@ package routers
 
 import (
 	"github.com/WildEgor/gNotifier/internal/adapters"
+	"github.com/WildEgor/gNotifier/internal/configs"
 	handlers "github.com/WildEgor/gNotifier/internal/handlers/http"
 	middleware "github.com/WildEgor/gNotifier/internal/middlewares"
+	"github.com/WildEgor/gNotifier/internal/services/opens"
 	"github.com/gofiber/fiber/v2"
 )
 
 type HTTPRouter struct {
-	ha                *adapters.HealthCheckAdapter
-	storeTokenHandler *handlers.StoreTokenHandler
-	unsubTokenHandler *handlers.UnsubTokenHandler
+	ha                 *adapters.HealthCheckAdapter
+	appConfig          *configs.AppConfig
+	realtimeConfig     *configs.RealtimeConfig
+	openTokens         *opens.Signer
+	storeTokenHandler  *handlers.StoreTokenHandler
+	unsubTokenHandler  *handlers.UnsubTokenHandler
+	webPushHandler     *handlers.StoreWebPushHandler
+	listInbox          *handlers.ListInboxHandler
+	inboxUnread        *handlers.InboxUnreadHandler
+	markInbox          *handlers.MarkInboxHandler
+	archiveInbox       *handlers.ArchiveInboxHandler
+	deleteInbox        *handlers.DeleteInboxHandler
+	realtimeWS         *handlers.RealtimeWSHandler
+	realtimeSSE        *handlers.RealtimeSSEHandler
+	getPreferences     *handlers.GetPreferencesHandler
+	updatePreferences  *handlers.UpdatePreferencesHandler
+	listSchedule       *handlers.ListScheduleHandler
+	reschedule         *handlers.RescheduleHandler
+	cancelSchedule     *handlers.CancelScheduleHandler
+	createRecurring    *handlers.CreateRecurringHandler
+	listRecurring      *handlers.ListRecurringHandler
+	getRecurring       *handlers.GetRecurringHandler
+	updateRecurring    *handlers.UpdateRecurringHandler
+	deleteRecurring    *handlers.DeleteRecurringHandler
+	pauseRecurring     *handlers.PauseRecurringHandler
+	resumeRecurring    *handlers.ResumeRecurringHandler
+	sendNotification   *handlers.SendNotificationHandler
+	throttleStats      *handlers.ThrottleStatsHandler
+	lanesStats         *handlers.LanesStatsHandler
+	pauseLane          *handlers.PauseLaneHandler
+	resumeLane         *handlers.ResumeLaneHandler
+	getContacts        *handlers.GetContactsHandler
+	updateContacts     *handlers.UpdateContactsHandler
+	notificationStatus *handlers.NotificationStatusHandler
+	notificationOpened *handlers.NotificationOpenedHandler
+	healthLive         *handlers.HealthLiveHandler
+	healthReady        *handlers.HealthReadyHandler
+	metrics            *handlers.MetricsHandler
 }
 
 func NewHTTPRouter(
 	ha *adapters.HealthCheckAdapter,
+	appConfig *configs.AppConfig,
+	realtimeConfig *configs.RealtimeConfig,
+	openTokens *opens.Signer,
 	storeTokenHandler *handlers.StoreTokenHandler,
 	unsubTokenHandler *handlers.UnsubTokenHandler,
+	webPushHandler *handlers.StoreWebPushHandler,
+	listInbox *handlers.ListInboxHandler,
+	inboxUnread *handlers.InboxUnreadHandler,
+	markInbox *handlers.MarkInboxHandler,
+	archiveInbox *handlers.ArchiveInboxHandler,
+	deleteInbox *handlers.DeleteInboxHandler,
+	realtimeWS *handlers.RealtimeWSHandler,
+	realtimeSSE *handlers.RealtimeSSEHandler,
+	getPreferences *handlers.GetPreferencesHandler,
+	updatePreferences *handlers.UpdatePreferencesHandler,
+	listSchedule *handlers.ListScheduleHandler,
+	reschedule *handlers.RescheduleHandler,
+	cancelSchedule *handlers.CancelScheduleHandler,
+	createRecurring *handlers.CreateRecurringHandler,
+	listRecurring *handlers.ListRecurringHandler,
+	getRecurring *handlers.GetRecurringHandler,
+	updateRecurring *handlers.UpdateRecurringHandler,
+	deleteRecurring *handlers.DeleteRecurringHandler,
+	pauseRecurring *handlers.PauseRecurringHandler,
+	resumeRecurring *handlers.ResumeRecurringHandler,
+	sendNotification *handlers.SendNotificationHandler,
+	throttleStats *handlers.ThrottleStatsHandler,
+	lanesStats *handlers.LanesStatsHandler,
+	pauseLane *handlers.PauseLaneHandler,
+	resumeLane *handlers.ResumeLaneHandler,
+	getContacts *handlers.GetContactsHandler,
+	updateContacts *handlers.UpdateContactsHandler,
+	notificationStatus *handlers.NotificationStatusHandler,
+	notificationOpened *handlers.NotificationOpenedHandler,
+	healthLive *handlers.HealthLiveHandler,
+	healthReady *handlers.HealthReadyHandler,
+	metrics *handlers.MetricsHandler,
 ) *HTTPRouter {
 	return &HTTPRouter{
-		ha:                ha,
-		storeTokenHandler: storeTokenHandler,
-		unsubTokenHandler: unsubTokenHandler,
+		ha:                 ha,
+		appConfig:          appConfig,
+		realtimeConfig:     realtimeConfig,
+		openTokens:         openTokens,
+		storeTokenHandler:  storeTokenHandler,
+		unsubTokenHandler:  unsubTokenHandler,
+		webPushHandler:     webPushHandler,
+		listInbox:          listInbox,
+		inboxUnread:        inboxUnread,
+		markInbox:          markInbox,
+		archiveInbox:       archiveInbox,
+		deleteInbox:        deleteInbox,
+		realtimeWS:         realtimeWS,
+		realtimeSSE:        realtimeSSE,
+		getPreferences:     getPreferences,
+		updatePreferences:  updatePreferences,
+		listSchedule:       listSchedule,
+		reschedule:         reschedule,
+		cancelSchedule:     cancelSchedule,
+		createRecurring:    createRecurring,
+		listRecurring:      listRecurring,
+		getRecurring:       getRecurring,
+		updateRecurring:    updateRecurring,
+		deleteRecurring:    deleteRecurring,
+		pauseRecurring:     pauseRecurring,
+		resumeRecurring:    resumeRecurring,
+		sendNotification:   sendNotification,
+		throttleStats:      throttleStats,
+		lanesStats:         lanesStats,
+		pauseLane:          pauseLane,
+		resumeLane:         resumeLane,
+		getContacts:        getContacts,
+		updateContacts:     updateContacts,
+		notificationStatus: notificationStatus,
+		notificationOpened: notificationOpened,
+		healthLive:         healthLive,
+		healthReady:        healthReady,
+		metrics:            metrics,
 	}
 }
 
@@ -31,15 +138,86 @@ func (r *HTTPRouter) SetupRoutes(app *fiber.App) error {
 		HealthCheckAdapter: r.ha,
 	}
 
-	app.Use(middleware.HealthCheck(&hCfg))
+	app.Get("/metrics", r.metrics.Handle)
 
 	v1 := app.Group("/api/v1")
 	healthCheckController := v1.Group("/health")
 	healthCheckController.Get("/check", middleware.HealthCheck(&hCfg))
+	healthCheckController.Get("/live", r.healthLive.Handle)
+	healthCheckController.Get("/ready", r.healthReady.Handle)
 
-	tokensController := v1.Group("/tokens")
+	// Management API (and synchronous send), subscriber routes are available to subscriber by its realtime token too. Subscriber
+	// auth is attached to routes rather than groups, because group middleware has no route params
+	adminAuth := middleware.Auth(&middleware.AuthConfig{
+		AdminToken: r.appConfig.AdminToken,
+	})
+	subscriberAuth := middleware.Auth(&middleware.AuthConfig{
+		AdminToken:       r.appConfig.AdminToken,
+		SubscriberSecret: r.realtimeConfig.JWTSecret,
+		SubIDParam:       "sub_id",
+	})
+	// tokens routes take subscriber ID from request body
+	tokensAuth := middleware.Auth(&middleware.AuthConfig{
+		AdminToken:       r.appConfig.AdminToken,
+		SubscriberSecret: r.realtimeConfig.JWTSecret,
+		SubIDField:       "sub_id",
+	})
+	// opens are reported by recipient apps with signed token of notification
+	openAuth := middleware.Auth(&middleware.AuthConfig{
+		AdminToken:   r.appConfig.AdminToken,
+		OpenTokens:   r.openTokens,
+		NotifIDParam: "notif_id",
+	})
+
+	notificationsController := v1.Group("/notifications")
+	notificationsController.Post("/send", adminAuth, r.sendNotification.Handle)
+	notificationsController.Get("/:notif_id", r.notificationStatus.Handle)
+	notificationsController.Post("/:notif_id/opened", openAuth, r.notificationOpened.Handle)
+
+	tokensController := v1.Group("/tokens", tokensAuth)
 	tokensController.Post("/store", r.storeTokenHandler.Handle)
 	tokensController.Post("/unsub", r.unsubTokenHandler.Handle)
+	tokensController.Post("/webpush", r.webPushHandler.Handle)
+
+	inboxController := v1.Group("/inbox")
+	inboxController.Get("/:sub_id", subscriberAuth, r.listInbox.Handle)
+	inboxController.Get("/:sub_id/unread", subscriberAuth, r.inboxUnread.Handle)
+	inboxController.Post("/:sub_id/read", subscriberAuth, r.markInbox.Handle)
+	inboxController.Post("/:sub_id/archive", subscriberAuth, r.archiveInbox.Handle)
+	inboxController.Delete("/:sub_id", subscriberAuth, r.deleteInbox.Handle)
+
+	realtimeController := v1.Group("/realtime")
+	realtimeController.Get("/ws", r.realtimeWS.Handle)
+	realtimeController.Get("/sse", r.realtimeSSE.Handle)
+
+	preferencesController := v1.Group("/preferences")
+	preferencesController.Get("/:sub_id", subscriberAuth, r.getPreferences.Handle)
+	preferencesController.Put("/:sub_id", subscriberAuth, r.updatePreferences.Handle)
+
+	contactsController := v1.Group("/contacts")
+	contactsController.Get("/:sub_id", subscriberAuth, r.getContacts.Handle)
+	contactsController.Put("/:sub_id", subscriberAuth, r.updateContacts.Handle)
+
+	scheduleController := v1.Group("/schedule", adminAuth)
+	scheduleController.Get("/", r.listSchedule.Handle)
+	scheduleController.Put("/:notif_id", r.reschedule.Handle)
+	scheduleController.Delete("/:notif_id", r.cancelSchedule.Handle)
+
+	recurringController := v1.Group("/recurring", adminAuth)
+	recurringController.Post("/", r.createRecurring.Handle)
+	recurringController.Get("/", r.listRecurring.Handle)
+	recurringController.Get("/:id", r.getRecurring.Handle)
+	recurringController.Put("/:id", r.updateRecurring.Handle)
+	recurringController.Delete("/:id", r.deleteRecurring.Handle)
+	recurringController.Post("/:id/pause", r.pauseRecurring.Handle)
+	recurringController.Post("/:id/resume", r.resumeRecurring.Handle)
+
+	v1.Get("/throttle", adminAuth, r.throttleStats.Handle)
+
+	lanesController := v1.Group("/lanes", adminAuth)
+	lanesController.Get("/", r.lanesStats.Handle)
+	lanesController.Post("/:name/pause", r.pauseLane.Handle)
+	lanesController.Post("/:name/resume", r.resumeLane.Handle)
 
 	return nil
 }
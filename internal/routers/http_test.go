@@ -0,0 +1,65 @@
+package routers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/services/opens"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTestApp setup routes without handlers, requests passed auth would panic on nil handler
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	r := &HTTPRouter{
+		appConfig:      &configs.AppConfig{AdminToken: "admin-token"},
+		realtimeConfig: &configs.RealtimeConfig{JWTSecret: "subscriber-secret", AllowAnonymous: true},
+		openTokens:     opens.NewSigner(&configs.AppConfig{OpenTokenSecret: "open-secret"}),
+	}
+
+	app := fiber.New()
+	if err := r.SetupRoutes(app); err != nil {
+		t.Fatalf("setup routes: %v", err)
+	}
+
+	return app
+}
+
+func TestHTTPRouterRequiresAuth(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: fiber.MethodPost, path: "/api/v1/notifications/send", body: `{"type":"sms","phone":{"number":"+79991234567","text":"hi"}}`},
+		{method: fiber.MethodPost, path: "/api/v1/notifications/n1/opened"},
+		{method: fiber.MethodPost, path: "/api/v1/notifications/n1/opened?token=n1"},
+		{method: fiber.MethodPost, path: "/api/v1/notifications/n2/opened?token=" + opens.NewSigner(&configs.AppConfig{OpenTokenSecret: "open-secret"}).Sign("n1")},
+	}
+
+	app := newTestApp(t)
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			for _, token := range []string{"", "wrong-token"} {
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				if token != "" {
+					req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+				}
+
+				res, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("request: %v", err)
+				}
+
+				if res.StatusCode != fiber.StatusUnauthorized {
+					t.Fatalf("status with token %q = %d, want %d", token, res.StatusCode, fiber.StatusUnauthorized)
+				}
+			}
+		})
+	}
+}
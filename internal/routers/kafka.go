@@ -0,0 +1,366 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/adapters"
+	"github.com/WildEgor/gNotifier/internal/configs"
+	handlers "github.com/WildEgor/gNotifier/internal/handlers/kafka"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/segmentio/kafka-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// Headers of messages republished to retry and dead letter topics
+const (
+	retryCountHeader    = "x-retry-count"
+	retryAfterHeader    = "x-retry-after"
+	originalTopicHeader = "x-original-topic"
+	// partition and offset of message in original topic, so retries keep its idempotency key
+	originalPartitionHeader = "x-original-partition"
+	originalOffsetHeader    = "x-original-offset"
+	errorHeader             = "x-error"
+)
+
+// Readiness gates: kafkaConsumerGate open while Kafka readers are running, kafkaCommitGate closed while
+// offsets of processed messages could not be committed
+const (
+	kafkaConsumerGate = "kafka-consumer"
+	kafkaCommitGate   = "kafka-commit"
+)
+
+// kafkaBackoff delay before fetch or republish failed because of broker is tried again
+const kafkaBackoff = time.Second
+
+// KafkaRouter consumes notifications from Kafka topics with consumer group. Offset is committed only after
+// notification is processed, failed notifications go through retry topic and end up in dead letter topic
+type KafkaRouter struct {
+	notifierHandler    *handlers.NotifierHandler
+	kafkaConfig        *configs.KafkaConfig
+	healthCheckAdapter *adapters.HealthCheckAdapter
+
+	readers []*kafka.Reader
+	writer  *kafka.Writer
+
+	// ctx canceled by Drain, stops fetching and waiting of retries
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func NewKafkaRouter(
+	notifierHandler *handlers.NotifierHandler,
+	kafkaConfig *configs.KafkaConfig,
+	healthCheckAdapter *adapters.HealthCheckAdapter,
+) *KafkaRouter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &KafkaRouter{
+		notifierHandler:    notifierHandler,
+		kafkaConfig:        kafkaConfig,
+		healthCheckAdapter: healthCheckAdapter,
+		ctx:                ctx,
+		cancel:             cancel,
+	}
+}
+
+func (r *KafkaRouter) SetupRoutes() error {
+	brokers := r.kafkaConfig.GetBrokers()
+	if len(brokers) == 0 {
+		return errors.New("[KafkaRouter] KAFKA_BROKERS is not set")
+	}
+
+	topics := r.kafkaConfig.GetTopics()
+	if len(topics) == 0 {
+		return errors.New("[KafkaRouter] KAFKA_TOPICS is not set")
+	}
+
+	r.healthCheckAdapter.SetReady(kafkaConsumerGate, false)
+	r.healthCheckAdapter.SetReady(kafkaCommitGate, true)
+
+	r.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		ErrorLogger:            kafka.LoggerFunc(logKafkaError),
+	}
+
+	// Partitions of topics are shared between readers of group, messages of partition are processed in order
+	for i := 0; i < r.kafkaConfig.Consumers; i++ {
+		r.consume(r.newReader(topics...), false)
+	}
+	// Retry topic of every attempt has own reader, so waiting for due message does not hold other attempts
+	for attempt := 1; attempt <= r.kafkaConfig.MaxRetries; attempt++ {
+		r.consume(r.newReader(r.kafkaConfig.GetRetryTopic(attempt)), true)
+	}
+
+	r.healthCheckAdapter.SetReady(kafkaConsumerGate, true)
+	log.Infof("[KafkaRouter] Consuming %v with group %s", topics, r.kafkaConfig.GroupID)
+
+	return nil
+}
+
+// Drain stop fetching messages and wait for messages in progress till ctx is done. Offsets of messages
+// left unprocessed are not committed, so they are consumed again by group
+func (r *KafkaRouter) Drain(ctx context.Context) error {
+	r.healthCheckAdapter.SetReady(kafkaConsumerGate, false)
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("[KafkaRouter] Messages in progress are not finished: %w", ctx.Err())
+	}
+}
+
+// Close leave consumer group and close connections
+func (r *KafkaRouter) Close() {
+	r.cancel()
+
+	for _, reader := range r.readers {
+		if err := reader.Close(); err != nil {
+			log.Error("[KafkaRouter] Failed close reader: ", err)
+		}
+	}
+	r.readers = nil
+
+	if r.writer != nil {
+		if err := r.writer.Close(); err != nil {
+			log.Error("[KafkaRouter] Failed close writer: ", err)
+		}
+		r.writer = nil
+	}
+}
+
+func (r *KafkaRouter) newReader(topics ...string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     r.kafkaConfig.GetBrokers(),
+		GroupID:     r.kafkaConfig.GroupID,
+		GroupTopics: topics,
+		MaxWait:     r.kafkaConfig.MaxWait,
+		// offsets are committed explicitly after processing
+		CommitInterval: 0,
+		ErrorLogger:    kafka.LoggerFunc(logKafkaError),
+	})
+}
+
+// consume fetch messages of reader one by one and commit each after it is processed or republished.
+// Retry reader waits till retry time of message first, messages behind it in topic are due later anyway
+func (r *KafkaRouter) consume(reader *kafka.Reader, retry bool) {
+	r.readers = append(r.readers, reader)
+
+	r.running.Add(1)
+	go func() {
+		defer r.running.Done()
+
+		for {
+			m, err := reader.FetchMessage(r.ctx)
+			if err != nil {
+				if r.ctx.Err() != nil || errors.Is(err, io.EOF) {
+					return
+				}
+
+				log.Error("[KafkaRouter] Failed fetch message: ", err)
+				if !r.sleep(kafkaBackoff) {
+					return
+				}
+				continue
+			}
+
+			if retry && !r.sleep(time.Until(retryAfter(m))) {
+				return
+			}
+
+			if !r.process(m) {
+				return
+			}
+
+			r.commit(reader, m)
+		}
+	}()
+}
+
+// commit offset of processed message. Uncommitted message is consumed again after rebalance (deduplicated
+// by its idempotency key), so failure is reported by metric and readiness till next successful commit
+func (r *KafkaRouter) commit(reader *kafka.Reader, m kafka.Message) {
+	if err := reader.CommitMessages(context.Background(), m); err != nil {
+		log.Errorf("[KafkaRouter] Failed commit offset %d of %s/%d: %v", m.Offset, m.Topic, m.Partition, err)
+		metrics.CommitFailed(m.Topic)
+		r.healthCheckAdapter.SetReady(kafkaCommitGate, false)
+		return
+	}
+
+	r.healthCheckAdapter.SetReady(kafkaCommitGate, true)
+}
+
+// process handle message and republish it to retry or dead letter topic if needed. It returns false if
+// message could not be republished till Drain, so its offset must not be committed
+func (r *KafkaRouter) process(m kafka.Message) bool {
+	attempt := retryCount(m)
+
+	action, err := r.notifierHandler.Handle(m, messageKey(m), attempt >= r.kafkaConfig.MaxRetries)
+	switch action {
+	case handlers.Retry:
+		after := time.Now().Add(r.kafkaConfig.GetRetryBackoff(attempt + 1))
+		return r.republish(m, r.kafkaConfig.GetRetryTopic(attempt+1),
+			kafka.Header{Key: retryCountHeader, Value: []byte(strconv.Itoa(attempt + 1))},
+			kafka.Header{Key: retryAfterHeader, Value: []byte(strconv.FormatInt(after.UnixMilli(), 10))},
+			errHeader(err),
+		)
+	case handlers.DeadLetter:
+		return r.republish(m, r.kafkaConfig.DeadLetterTopic,
+			kafka.Header{Key: retryCountHeader, Value: []byte(strconv.Itoa(attempt))},
+			errHeader(err),
+		)
+	}
+
+	return true
+}
+
+// republish copy message with its key to topic, so retries of the same key stay in order. Attempts go on
+// till Drain, because skipping message would lose notification
+func (r *KafkaRouter) republish(m kafka.Message, topic string, headers ...kafka.Header) bool {
+	original, partition, offset := originOf(m)
+	headers = append(headers,
+		kafka.Header{Key: originalTopicHeader, Value: []byte(original)},
+		kafka.Header{Key: originalPartitionHeader, Value: []byte(strconv.Itoa(partition))},
+		kafka.Header{Key: originalOffsetHeader, Value: []byte(strconv.FormatInt(offset, 10))},
+	)
+
+	out := kafka.Message{
+		Topic:   topic,
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: mergeHeaders(m.Headers, headers),
+	}
+
+	for {
+		err := r.writer.WriteMessages(context.Background(), out)
+		if err == nil {
+			return true
+		}
+
+		log.Errorf("[KafkaRouter] Failed republish message %s/%d@%d to %s: %v", m.Topic, m.Partition, m.Offset, topic, err)
+		if !r.sleep(kafkaBackoff) {
+			return false
+		}
+	}
+}
+
+// sleep wait for d, it returns false if Drain was called meanwhile
+func (r *KafkaRouter) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return r.ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-r.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// mergeHeaders returns headers of message with values of overrides
+func mergeHeaders(headers []kafka.Header, overrides []kafka.Header) []kafka.Header {
+	merged := make([]kafka.Header, 0, len(headers)+len(overrides))
+	for _, header := range headers {
+		overridden := false
+		for _, override := range overrides {
+			if override.Key == header.Key {
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			merged = append(merged, header)
+		}
+	}
+
+	return append(merged, overrides...)
+}
+
+// originOf returns topic, partition and offset message was produced to, before it was republished to retry topic
+func originOf(m kafka.Message) (string, int, int64) {
+	topic, partition, offset := m.Topic, m.Partition, m.Offset
+
+	for _, header := range m.Headers {
+		switch header.Key {
+		case originalTopicHeader:
+			topic = string(header.Value)
+		case originalPartitionHeader:
+			if n, err := strconv.Atoi(string(header.Value)); err == nil {
+				partition = n
+			}
+		case originalOffsetHeader:
+			if n, err := strconv.ParseInt(string(header.Value), 10, 64); err == nil {
+				offset = n
+			}
+		}
+	}
+
+	return topic, partition, offset
+}
+
+// messageKey stable idempotency key of message, the same for its redeliveries and retries
+func messageKey(m kafka.Message) string {
+	topic, partition, offset := originOf(m)
+
+	return fmt.Sprintf("kafka:%s/%d/%d", topic, partition, offset)
+}
+
+// retryCount how many times message was retried already
+func retryCount(m kafka.Message) int {
+	for _, header := range m.Headers {
+		if header.Key == retryCountHeader {
+			n, _ := strconv.Atoi(string(header.Value))
+			return n
+		}
+	}
+
+	return 0
+}
+
+// retryAfter time message should be retried at, zero time if message has no such header
+func retryAfter(m kafka.Message) time.Time {
+	for _, header := range m.Headers {
+		if header.Key == retryAfterHeader {
+			if ms, err := strconv.ParseInt(string(header.Value), 10, 64); err == nil {
+				return time.UnixMilli(ms)
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+func errHeader(err error) kafka.Header {
+	header := kafka.Header{Key: errorHeader}
+	if err != nil {
+		header.Value = []byte(err.Error())
+	}
+
+	return header
+}
+
+func logKafkaError(msg string, args ...interface{}) {
+	log.Errorf("[KafkaRouter] "+msg, args...)
+}
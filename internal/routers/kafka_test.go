@@ -0,0 +1,154 @@
+package routers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func header(key, value string) kafka.Header {
+	return kafka.Header{Key: key, Value: []byte(value)}
+}
+
+func TestMergeHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   []kafka.Header
+		overrides []kafka.Header
+		want      []kafka.Header
+	}{
+		{
+			name:      "no headers",
+			overrides: []kafka.Header{header(retryCountHeader, "1")},
+			want:      []kafka.Header{header(retryCountHeader, "1")},
+		},
+		{
+			name:    "no overrides",
+			headers: []kafka.Header{header("traceparent", "00-abc-01")},
+			want:    []kafka.Header{header("traceparent", "00-abc-01")},
+		},
+		{
+			name:      "override replaces value",
+			headers:   []kafka.Header{header("traceparent", "00-abc-01"), header(retryCountHeader, "1")},
+			overrides: []kafka.Header{header(retryCountHeader, "2")},
+			want:      []kafka.Header{header("traceparent", "00-abc-01"), header(retryCountHeader, "2")},
+		},
+		{
+			name:      "duplicated header replaced once",
+			headers:   []kafka.Header{header(errorHeader, "a"), header(errorHeader, "b")},
+			overrides: []kafka.Header{header(errorHeader, "c")},
+			want:      []kafka.Header{header(errorHeader, "c")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeHeaders(tt.headers, tt.overrides); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("mergeHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []kafka.Header
+		want    int
+	}{
+		{name: "no header", want: 0},
+		{name: "retried", headers: []kafka.Header{header("traceparent", "x"), header(retryCountHeader, "3")}, want: 3},
+		{name: "malformed", headers: []kafka.Header{header(retryCountHeader, "three")}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryCount(kafka.Message{Headers: tt.headers}); got != tt.want {
+				t.Fatalf("retryCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	at := time.UnixMilli(1709719200123)
+
+	tests := []struct {
+		name    string
+		headers []kafka.Header
+		want    time.Time
+	}{
+		{name: "no header"},
+		{name: "unix millis", headers: []kafka.Header{header(retryAfterHeader, "1709719200123")}, want: at},
+		{name: "malformed", headers: []kafka.Header{header(retryAfterHeader, "tomorrow")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(kafka.Message{Headers: tt.headers}); !got.Equal(tt.want) {
+				t.Fatalf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageKey(t *testing.T) {
+	tests := []struct {
+		name string
+		m    kafka.Message
+		want string
+	}{
+		{
+			name: "original message",
+			m:    kafka.Message{Topic: "notifications", Partition: 2, Offset: 42},
+			want: "kafka:notifications/2/42",
+		},
+		{
+			name: "retried message",
+			m: kafka.Message{Topic: "notifications.retry.1", Partition: 0, Offset: 7, Headers: []kafka.Header{
+				header(originalTopicHeader, "notifications"),
+				header(originalPartitionHeader, "2"),
+				header(originalOffsetHeader, "42"),
+			}},
+			want: "kafka:notifications/2/42",
+		},
+		{
+			name: "malformed origin",
+			m: kafka.Message{Topic: "notifications.retry.1", Partition: 0, Offset: 7, Headers: []kafka.Header{
+				header(originalTopicHeader, "notifications"),
+				header(originalOffsetHeader, "forty two"),
+			}},
+			want: "kafka:notifications/0/7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageKey(tt.m); got != tt.want {
+				t.Fatalf("messageKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want kafka.Header
+	}{
+		{name: "error", err: errors.New("provider is down"), want: header(errorHeader, "provider is down")},
+		{name: "no error", want: kafka.Header{Key: errorHeader}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errHeader(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("errHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/services/priority"
+	log "github.com/sirupsen/logrus"
+	"github.com/wagslane/go-rabbitmq"
+)
+
+// laneHead fields of notification its lane selected by
+type laneHead struct {
+	Priority string `json:"priority"`
+	Category string `json:"category"`
+}
+
+// setupLanes declare queue, delay queue and consumer of every priority lane. Lane queues are bound to own
+// direct exchange and inherit queue settings of the first consumer
+func (r *AMQPRouter) setupLanes(connection *rabbitmq.Conn) error {
+	base := r.amqpConfig.Consumers[0]
+
+	cfgs := make([]*configs.AMQPConsumerConfig, 0, len(r.priorityConfig.Lanes))
+	for _, lane := range r.priorityConfig.Lanes {
+		cfgs = append(cfgs, laneConsumerConfig(base, lane, r.priorityConfig))
+	}
+	if err := r.declareDelayQueues(cfgs); err != nil {
+		return err
+	}
+
+	for i, lane := range r.priorityConfig.Lanes {
+		cfg := cfgs[i]
+
+		consumer, err := rabbitmq.NewConsumer(
+			connection,
+			r.handle(cfg.Queue, r.laneHandler(lane.Name)),
+			cfg.Queue,
+			consumerOptions(cfg)...,
+		)
+		if err != nil {
+			return fmt.Errorf("[AMQPRouter] Failed create consumer of lane %s: %w", lane.Name, err)
+		}
+
+		r.addConsumer(consumer)
+	}
+
+	r.lanesExchange = r.priorityConfig.Exchange
+
+	return nil
+}
+
+// laneHandler handle notification with worker of lane, pausable lanes wait while higher lanes are loaded
+func (r *AMQPRouter) laneHandler(name string) deliveryHandler {
+	return func(d rabbitmq.Delivery) (rabbitmq.Action, time.Duration) {
+		release, ok := r.lanes.Acquire(name, r.stop)
+		if !ok {
+			return rabbitmq.NackRequeue, 0
+		}
+		defer release()
+
+		return r.notifierHandler.Handle(d)
+	}
+}
+
+// routeToLane move notification from intake queue to queue of its priority lane, so notifications of
+// higher lanes are not waiting behind lower ones. Invalid notifications are rejected by lane handler
+func (r *AMQPRouter) routeToLane(d rabbitmq.Delivery) (rabbitmq.Action, time.Duration) {
+	var head laneHead
+	_ = json.Unmarshal(d.Body, &head)
+
+	lane := r.lanes.Select(head.Priority, head.Category)
+
+	opts := append(republishOptions(d), rabbitmq.WithPublishOptionsExchange(r.lanesExchange))
+
+	err := r.publisher.PublishWithContext(context.Background(), d.Body, []string{priority.RoutingKey(lane)}, opts...)
+	if err != nil {
+		log.Errorf("[AMQPRouter] Failed route notification %s to lane %s: %v", d.MessageId, lane, err)
+		return rabbitmq.NackRequeue, 0
+	}
+
+	return rabbitmq.Ack, 0
+}
+
+// laneConsumerConfig consumer of lane queue with worker per delivery in flight
+func laneConsumerConfig(base *configs.AMQPConsumerConfig, lane *configs.PriorityLane, priorityConfig *configs.PriorityConfig) *configs.AMQPConsumerConfig {
+	cfg := *base
+	cfg.Name = lane.Name
+	cfg.Exchange = priorityConfig.Exchange
+	cfg.ExchangeType = "direct"
+	cfg.Queue = "notifier-lane-" + lane.Name + "-queue"
+	cfg.RoutingKeys = priority.RoutingKey(lane.Name)
+	cfg.Prefetch = lane.Workers
+	cfg.Concurrency = lane.Workers
+
+	if priorityConfig.MaxPriority > 0 {
+		// quorum queues reject x-max-priority
+		if cfg.IsQuorum() {
+			log.Warnf("[AMQPRouter] Lane %s queue is quorum, x-max-priority skipped", lane.Name)
+			return &cfg
+		}
+
+		arg := "x-max-priority=" + strconv.Itoa(priorityConfig.MaxPriority)
+		if cfg.QueueArgs != "" {
+			arg = cfg.QueueArgs + "," + arg
+		}
+		cfg.QueueArgs = arg
+	}
+
+	return &cfg
+}
@@ -9,4 +9,5 @@ var RoutersSet = wire.NewSet(
 	handlers.HandlersSet,
 	NewHTTPRouter,
 	NewAMQPRouter,
+	NewKafkaRouter,
 )
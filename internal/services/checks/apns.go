@@ -0,0 +1,128 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/sideshow/apns2"
+	"github.com/sideshow/apns2/certificate"
+	"github.com/sideshow/apns2/token"
+)
+
+const defaultCertExpiry = 14 * 24 * time.Hour
+
+type APNsCheckConfig struct {
+	KeyPath    string
+	KeyBase64  string
+	KeyType    string
+	Password   string
+	Production bool
+	// CertExpiry check fails if certificate expires within this period
+	CertExpiry time.Duration
+}
+
+func (c *APNsCheckConfig) initDefaultConfig() {
+	if c.CertExpiry == 0 {
+		c.CertExpiry = defaultCertExpiry
+	}
+}
+
+// NewAPNsCheck creates new APNs health check that verifies the following:
+// - certificate (.p12, .pem) is loaded and not going to expire soon, or .p8 token key is valid
+// - TLS connection to APNs host is established (with client certificate if any)
+func NewAPNsCheck(cfg *APNsCheckConfig) func(ctx context.Context) error {
+	cfg.initDefaultConfig()
+
+	return func(ctx context.Context) error {
+		cert, err := loadAPNsCredentials(cfg)
+		if err != nil {
+			return fmt.Errorf("[APNsCheck] Failed load credentials: %w", err)
+		}
+
+		tlsConfig := &tls.Config{
+			NextProtos: []string{"h2"},
+		}
+
+		if cert != nil && len(cert.Certificate) > 0 {
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			if err != nil {
+				return fmt.Errorf("[APNsCheck] Failed parse certificate: %w", err)
+			}
+
+			if time.Until(leaf.NotAfter) < cfg.CertExpiry {
+				return fmt.Errorf("[APNsCheck] Certificate expires at %s", leaf.NotAfter.Format(time.RFC3339))
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{*cert}
+		}
+
+		host := apns2.HostDevelopment
+		if cfg.Production {
+			host = apns2.HostProduction
+		}
+
+		u, err := url.Parse(host)
+		if err != nil {
+			return fmt.Errorf("[APNsCheck] Invalid host: %w", err)
+		}
+
+		dialer := tls.Dialer{Config: tlsConfig}
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), "443"))
+		if err != nil {
+			return fmt.Errorf("[APNsCheck] Failed on connection: %w", err)
+		}
+
+		return conn.Close()
+	}
+}
+
+// loadAPNsCredentials returns certificate loaded the same way APNAdapter does, nil for .p8 token key
+func loadAPNsCredentials(cfg *APNsCheckConfig) (*tls.Certificate, error) {
+	var key []byte
+	var err error
+
+	ext := filepath.Ext(cfg.KeyPath)
+	if cfg.KeyPath == "" {
+		ext = "." + cfg.KeyType
+
+		key, err = base64.StdEncoding.DecodeString(cfg.KeyBase64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var cert tls.Certificate
+
+	switch {
+	case ext == ".p12" && key == nil:
+		cert, err = certificate.FromP12File(cfg.KeyPath, cfg.Password)
+	case ext == ".p12":
+		cert, err = certificate.FromP12Bytes(key, cfg.Password)
+	case ext == ".pem" && key == nil:
+		cert, err = certificate.FromPemFile(cfg.KeyPath, cfg.Password)
+	case ext == ".pem":
+		cert, err = certificate.FromPemBytes(key, cfg.Password)
+	case ext == ".p8" && key == nil:
+		_, err = token.AuthKeyFromFile(cfg.KeyPath)
+		return nil, err
+	case ext == ".p8":
+		_, err = token.AuthKeyFromBytes(key)
+		return nil, err
+	default:
+		return nil, errors.New("wrong certificate key type")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &cert, nil
+}
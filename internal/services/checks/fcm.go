@@ -0,0 +1,38 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/appleboy/go-fcm"
+)
+
+// fcmProbeToken registration token of dry run message, FCM reports it as invalid registration
+// but only after server key accepted
+const fcmProbeToken = "health-check"
+
+type FCMCheckConfig struct {
+	APIKey string
+}
+
+// NewFCMCheck creates new FCM health check that verifies server key is accepted by FCM.
+// Dry run message is sent, so nothing is delivered
+func NewFCMCheck(cfg *FCMCheckConfig) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		client, err := fcm.NewClient(cfg.APIKey)
+		if err != nil {
+			return fmt.Errorf("[FCMCheck] Failed create client: %w", err)
+		}
+
+		// Rejected key answered with 401 status, which go-fcm returns as error
+		_, err = client.SendWithContext(ctx, &fcm.Message{
+			To:     fcmProbeToken,
+			DryRun: true,
+		})
+		if err != nil {
+			return fmt.Errorf("[FCMCheck] Failed dry run: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,20 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// NewMongoCheck creates new MongoDB health check that pings primary
+func NewMongoCheck(client *mongo.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := client.Ping(ctx, readpref.Primary()); err != nil {
+			return fmt.Errorf("[MongoCheck] Failed ping: %w", err)
+		}
+
+		return nil
+	}
+}
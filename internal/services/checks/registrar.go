@@ -0,0 +1,118 @@
+package checks
+
+import (
+	"fmt"
+
+	"github.com/WildEgor/gNotifier/internal/adapters"
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Registrar registers built-in checks of storage and providers to global HealthCheckAdapter
+type Registrar struct {
+	ha            *adapters.HealthCheckAdapter
+	healthConfig  *configs.HealthConfig
+	routingConfig *configs.RoutingConfig
+	fcmConfig     *configs.FCMConfig
+	apnConfig     *configs.APNConfig
+	mongoClient   *mongo.Client
+}
+
+func NewRegistrar(
+	ha *adapters.HealthCheckAdapter,
+	healthConfig *configs.HealthConfig,
+	routingConfig *configs.RoutingConfig,
+	fcmConfig *configs.FCMConfig,
+	apnConfig *configs.APNConfig,
+	mongoClient *mongo.Client,
+) *Registrar {
+	return &Registrar{
+		ha:            ha,
+		healthConfig:  healthConfig,
+		routingConfig: routingConfig,
+		fcmConfig:     fcmConfig,
+		apnConfig:     apnConfig,
+		mongoClient:   mongoClient,
+	}
+}
+
+// Register add checks, providers which are not configured are not checked
+func (r *Registrar) Register() error {
+	if err := r.register("mongo-health-check", r.healthConfig.Mongo(), NewMongoCheck(r.mongoClient)); err != nil {
+		return err
+	}
+
+	for _, route := range r.routingConfig.SMTP {
+		cfg := configs.NewSMTPProviderConfig(route.Name)
+		if cfg.Host == "" {
+			continue
+		}
+
+		err := r.register(checkName("smtp", route.Name), r.healthConfig.SMTP(), NewSMTPCheck(&SMTPCheckConfig{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, route := range r.routingConfig.SMS {
+		cfg := configs.NewSMSProviderConfig(route.Name)
+		if cfg.BaseURL == "" {
+			continue
+		}
+
+		err := r.register(checkName("sms", route.Name), r.healthConfig.SMS(), NewSMSCheck(&SMSCheckConfig{
+			BaseURL: cfg.BaseURL,
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.fcmConfig.APIKey != "" {
+		err := r.register("fcm-health-check", r.healthConfig.FCM(), NewFCMCheck(&FCMCheckConfig{
+			APIKey: r.fcmConfig.APIKey,
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.apnConfig.KeyPath != "" || r.apnConfig.KeyBase64 != "" {
+		err := r.register("apns-health-check", r.healthConfig.APNs(), NewAPNsCheck(&APNsCheckConfig{
+			KeyPath:    r.apnConfig.KeyPath,
+			KeyBase64:  r.apnConfig.KeyBase64,
+			KeyType:    r.apnConfig.KeyType,
+			Password:   r.apnConfig.Password,
+			Production: r.apnConfig.Production,
+			CertExpiry: r.healthConfig.APNsCertExpiry,
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Registrar) register(name string, policy configs.CheckPolicy, check adapters.HealthCheckFunc) error {
+	return r.ha.Register(adapters.HealthConfig{
+		Name:      name,
+		Timeout:   policy.Timeout,
+		SkipOnErr: policy.SkipOnErr,
+		Check:     check,
+	})
+}
+
+// checkName name of provider check, e.g. sms-health-check or sms-backup-health-check
+func checkName(channel, provider string) string {
+	if provider == configs.DefaultProvider {
+		return channel + "-health-check"
+	}
+
+	return fmt.Sprintf("%s-%s-health-check", channel, provider)
+}
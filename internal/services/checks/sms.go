@@ -0,0 +1,36 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type SMSCheckConfig struct {
+	BaseURL string
+}
+
+// NewSMSCheck creates new SMS gateway health check that verifies gateway answers on its base URL.
+// Any response except 5xx treated as reachable, since gateways rarely serve their root
+func NewSMSCheck(cfg *SMSCheckConfig) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.BaseURL, nil)
+		if err != nil {
+			return fmt.Errorf("[SMSCheck] Invalid base URL: %w", err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("[SMSCheck] Failed on connection: %w", err)
+		}
+		defer res.Body.Close()
+		io.Copy(io.Discard, res.Body)
+
+		if res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("[SMSCheck] Gateway responded with status %d", res.StatusCode)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+)
+
+type SMTPCheckConfig struct {
+	Host      string
+	Port      int16
+	Username  string
+	Password  string
+	LocalName string
+}
+
+func (c *SMTPCheckConfig) initDefaultConfig() {
+	if c.Port == 0 {
+		c.Port = 25
+	}
+
+	if c.LocalName == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "localhost"
+		}
+		c.LocalName = host
+	}
+}
+
+// NewSMTPCheck creates new SMTP health check that repeats handshake of SMTPAdapter without sending mail:
+// - connection establishing
+// - EHLO
+// - STARTTLS
+// - authentication if credentials provided
+func NewSMTPCheck(cfg *SMTPCheckConfig) func(ctx context.Context) error {
+	cfg.initDefaultConfig()
+
+	return func(ctx context.Context) error {
+		dialer := net.Dialer{}
+		conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%v:%v", cfg.Host, cfg.Port))
+		if err != nil {
+			return fmt.Errorf("[SMTPCheck] Failed on connection: %w", err)
+		}
+
+		// Dialer respects context only while connecting, handshake bounded by deadline
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+
+		client, err := smtp.NewClient(conn, cfg.Host)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("[SMTPCheck] Failed on greeting: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Hello(cfg.LocalName); err != nil {
+			return fmt.Errorf("[SMTPCheck] Failed on EHLO: %w", err)
+		}
+
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("[SMTPCheck] Server doesn't support STARTTLS")
+		}
+
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+			return fmt.Errorf("[SMTPCheck] Failed on STARTTLS: %w", err)
+		}
+
+		if cfg.Username != "" {
+			if ok, _ := client.Extension("AUTH"); !ok {
+				return errors.New("[SMTPCheck] Server doesn't support AUTH")
+			}
+
+			if err := client.Auth(sasl.NewPlainClient("", cfg.Username, cfg.Password)); err != nil {
+				return fmt.Errorf("[SMTPCheck] Failed on auth: %w", err)
+			}
+		}
+
+		return client.Quit()
+	}
+}
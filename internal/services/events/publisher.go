@@ -0,0 +1,158 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/services/logging"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
+	log "github.com/sirupsen/logrus"
+	"github.com/wagslane/go-rabbitmq"
+)
+
+// Status events of notification
+const (
+	EventAccepted = "accepted"
+	EventSent     = "sent"
+	EventFailed   = "failed"
+	EventRetried  = "retried"
+	EventSkipped  = "skipped"
+)
+
+// Event is a status event of notification published to events exchange
+type Event struct {
+	Event   string `json:"event"`
+	NotifID string `json:"notif_id"`
+	// Status stored status of notification, e.g. rate_limited for skipped one
+	Status    string `json:"status,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Recipient string `json:"recipient,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// CorrelationID of request (message ID of consumed message or correlation header of HTTP request)
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// RoutingKey returns routing key of event, e.g. email.failed, so consumers could bind to channel or event
+func (e *Event) RoutingKey() string {
+	return e.Channel + "." + e.Event
+}
+
+// Publisher publishes status events of notifications to topic exchange and replies to callers which
+// passed reply_to. Until started events are dropped
+type Publisher struct {
+	config *configs.EventsConfig
+
+	mu        sync.RWMutex
+	publisher *rabbitmq.Publisher
+}
+
+func NewPublisher(config *configs.EventsConfig) *Publisher {
+	return &Publisher{
+		config: config,
+	}
+}
+
+// Start declare events exchange
+func (p *Publisher) Start(conn *rabbitmq.Conn) error {
+	opts := []func(*rabbitmq.PublisherOptions){}
+	if p.config.Enabled {
+		opts = append(opts,
+			rabbitmq.WithPublisherOptionsExchangeName(p.config.Exchange),
+			rabbitmq.WithPublisherOptionsExchangeKind("topic"),
+			rabbitmq.WithPublisherOptionsExchangeDeclare,
+		)
+		if p.config.Durable {
+			opts = append(opts, rabbitmq.WithPublisherOptionsExchangeDurable)
+		}
+	}
+
+	publisher, err := rabbitmq.NewPublisher(conn, opts...)
+	if err != nil {
+		return fmt.Errorf("[EventsPublisher] Failed create publisher: %w", err)
+	}
+
+	p.mu.Lock()
+	p.publisher = publisher
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Close stop publishing
+func (p *Publisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.publisher != nil {
+		p.publisher.Close()
+		p.publisher = nil
+	}
+}
+
+// Publish event of notification, failures are only logged so delivery is not affected
+func (p *Publisher) Publish(ctx context.Context, e *Event) {
+	if !p.config.Enabled {
+		return
+	}
+
+	e.Timestamp = time.Now()
+	if e.CorrelationID == "" {
+		e.CorrelationID = logging.CorrelationID(ctx)
+	}
+
+	opts := []func(*rabbitmq.PublishOptions){
+		rabbitmq.WithPublishOptionsExchange(p.config.Exchange),
+	}
+	if e.CorrelationID != "" {
+		opts = append(opts, rabbitmq.WithPublishOptionsCorrelationID(e.CorrelationID))
+	}
+
+	if err := p.publish(ctx, e, e.RoutingKey(), opts...); err != nil {
+		log.WithContext(ctx).Warnf("[EventsPublisher] Failed publish %s event of %s: %v", e.Event, e.NotifID, err)
+	}
+}
+
+// Reply send result of request to reply_to queue of caller with its correlation_id
+func (p *Publisher) Reply(ctx context.Context, replyTo, correlationID string, result interface{}) error {
+	// reply_to is a queue name, published through default exchange
+	return p.publish(ctx, result, replyTo,
+		rabbitmq.WithPublishOptionsExchange(""),
+		rabbitmq.WithPublishOptionsCorrelationID(correlationID),
+	)
+}
+
+func (p *Publisher) publish(ctx context.Context, v interface{}, routingKey string, opts ...func(*rabbitmq.PublishOptions)) error {
+	p.mu.RLock()
+	publisher := p.publisher
+	p.mu.RUnlock()
+
+	if publisher == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// consumers of events continue trace of notification
+	headers := make(map[string]interface{})
+	tracing.InjectAMQP(ctx, headers)
+
+	opts = append(opts,
+		rabbitmq.WithPublishOptionsContentType("application/json"),
+		rabbitmq.WithPublishOptionsHeaders(headers),
+	)
+	if p.config.Persistent {
+		opts = append(opts, rabbitmq.WithPublishOptionsPersistentDelivery)
+	}
+
+	return publisher.PublishWithContext(ctx, body, []string{routingKey}, opts...)
+}
@@ -0,0 +1,90 @@
+package frequency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+)
+
+// how often expired counters removed from memory
+const memorySweepInterval = time.Minute
+
+// ICounter counts notifications within window, counter forgotten after expiresAt. Reserve must check and
+// increment atomically, so concurrent consumers never go over limit
+type ICounter interface {
+	Reserve(ctx context.Context, key string, limit int64, expiresAt time.Time) (bool, error)
+	Release(ctx context.Context, key string) error
+}
+
+// NewCounter returns counter configured by FREQUENCY_CAPS_STORE
+func NewCounter(config *configs.FrequencyConfig, countersRepo mongo.ICountersRepository) ICounter {
+	if config.Store == configs.FrequencyStoreMemory {
+		return NewMemoryCounter()
+	}
+
+	return countersRepo
+}
+
+type memoryEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryCounter keeps counters of single instance
+type MemoryCounter struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{
+		entries:   make(map[string]*memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Reserve increment counter if it is below limit
+func (c *MemoryCounter) Reserve(_ context.Context, key string, limit int64, expiresAt time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(c.lastSweep) > memorySweepInterval {
+		for k, e := range c.entries {
+			if !e.expiresAt.After(now) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	e, ok := c.entries[key]
+	if !ok || !e.expiresAt.After(now) {
+		e = &memoryEntry{expiresAt: expiresAt}
+		c.entries[key] = e
+	}
+
+	if e.count >= limit {
+		return false, nil
+	}
+	e.count++
+
+	return true, nil
+}
+
+// Release decrement counter of reservation not used
+func (c *MemoryCounter) Release(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok && e.count > 0 {
+		e.count--
+	}
+
+	return nil
+}
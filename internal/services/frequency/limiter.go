@@ -0,0 +1,102 @@
+package frequency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	log "github.com/sirupsen/logrus"
+)
+
+// Limiter enforce frequency caps per recipient, channel and category using fixed windows
+type Limiter struct {
+	config  *configs.FrequencyConfig
+	counter ICounter
+}
+
+func NewLimiter(config *configs.FrequencyConfig, counter ICounter) *Limiter {
+	return &Limiter{
+		config:  config,
+		counter: counter,
+	}
+}
+
+// Reservation of notification in counters of matching caps, released if notification was not sent
+type Reservation struct {
+	counter ICounter
+	keys    []string
+}
+
+// Reserve atomically count notification against every matching cap, returns nil and violated cap if any
+// is reached (notification is not counted then). Caller releases reservation if notification is not sent,
+// so rejected and failed ones do not use up caps
+func (l *Limiter) Reserve(ctx context.Context, recipient, channel, category string) (*Reservation, string) {
+	r := &Reservation{counter: l.counter}
+	violated := ""
+
+	l.each(recipient, channel, category, func(fc *configs.FrequencyCap, key string, expiresAt time.Time) bool {
+		if fc.Limit <= 0 {
+			violated = fc.String()
+			return false
+		}
+
+		reserved, err := l.counter.Reserve(ctx, key, fc.Limit, expiresAt)
+		if err != nil {
+			// better deliver than lose notification because of counter failure
+			log.Error("[FrequencyLimiter] Failed reserve counter: ", key, err)
+			return true
+		}
+
+		if !reserved {
+			violated = fc.String()
+			return false
+		}
+
+		r.keys = append(r.keys, key)
+		return true
+	})
+
+	if violated != "" {
+		r.Release(ctx)
+		return nil, violated
+	}
+
+	return r, ""
+}
+
+// Release return reserved notification to every counter
+func (r *Reservation) Release(ctx context.Context) {
+	for _, key := range r.keys {
+		if err := r.counter.Release(ctx, key); err != nil {
+			log.Error("[FrequencyLimiter] Failed release counter: ", key, err)
+		}
+	}
+	r.keys = nil
+}
+
+// each call fn with counter key and window end of every cap matching notification till fn returns false
+func (l *Limiter) each(recipient, channel, category string, fn func(fc *configs.FrequencyCap, key string, expiresAt time.Time) bool) {
+	if recipient == "" {
+		return
+	}
+
+	now := time.Now()
+
+	for _, fc := range l.config.Caps {
+		if !matches(fc.Channel, channel) || !matches(fc.Category, category) {
+			continue
+		}
+
+		windowStart := now.Truncate(fc.Window)
+		key := fmt.Sprintf("%s|%s|%d", fc, recipient, windowStart.Unix())
+
+		if !fn(fc, key, windowStart.Add(fc.Window)) {
+			return
+		}
+	}
+}
+
+func matches(pattern, value string) bool {
+	return pattern == "*" || pattern == value
+}
@@ -0,0 +1,143 @@
+package frequency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+)
+
+func newTestLimiter(caps ...*configs.FrequencyCap) *Limiter {
+	return NewLimiter(&configs.FrequencyConfig{Caps: caps}, NewMemoryCounter())
+}
+
+func TestLimiterReserve(t *testing.T) {
+	smsHour := &configs.FrequencyCap{Channel: "sms", Category: "*", Limit: 2, Window: time.Hour}
+	anyDay := &configs.FrequencyCap{Channel: "*", Category: "*", Limit: 3, Window: 24 * time.Hour}
+	blocked := &configs.FrequencyCap{Channel: "push", Category: "marketing", Limit: 0, Window: time.Hour}
+
+	type call struct {
+		channel  string
+		release  bool
+		violated string
+	}
+
+	tests := []struct {
+		name  string
+		caps  []*configs.FrequencyCap
+		calls []call
+	}{
+		{
+			name: "within cap",
+			caps: []*configs.FrequencyCap{smsHour},
+			calls: []call{
+				{channel: "sms"},
+				{channel: "sms"},
+				{channel: "sms", violated: smsHour.String()},
+			},
+		},
+		{
+			name: "released reservation returned to cap",
+			caps: []*configs.FrequencyCap{smsHour},
+			calls: []call{
+				{channel: "sms", release: true},
+				{channel: "sms", release: true},
+				{channel: "sms"},
+				{channel: "sms"},
+				{channel: "sms", violated: smsHour.String()},
+			},
+		},
+		{
+			name: "other channel not capped",
+			caps: []*configs.FrequencyCap{smsHour},
+			calls: []call{
+				{channel: "sms"},
+				{channel: "sms"},
+				{channel: "email"},
+				{channel: "email"},
+				{channel: "email"},
+			},
+		},
+		{
+			name: "violated cap rolls back reserved ones",
+			caps: []*configs.FrequencyCap{anyDay, smsHour},
+			calls: []call{
+				{channel: "sms"},
+				{channel: "sms"},
+				// day cap reserved and returned, so email still has one slot
+				{channel: "sms", violated: smsHour.String()},
+				{channel: "email"},
+				{channel: "email", violated: anyDay.String()},
+			},
+		},
+		{
+			name: "zero limit blocks",
+			caps: []*configs.FrequencyCap{blocked},
+			calls: []call{
+				{channel: "push", violated: blocked.String()},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLimiter(tt.caps...)
+			ctx := context.Background()
+
+			for i, c := range tt.calls {
+				category := ""
+				if c.channel == "push" {
+					category = "marketing"
+				}
+
+				r, violated := l.Reserve(ctx, "u1", c.channel, category)
+				if violated != c.violated {
+					t.Fatalf("call %d: violated = %q, want %q", i, violated, c.violated)
+				}
+				if (r == nil) != (c.violated != "") {
+					t.Fatalf("call %d: reservation = %v", i, r)
+				}
+
+				if c.release {
+					r.Release(ctx)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterReserveConcurrent(t *testing.T) {
+	const (
+		limit   = 5
+		callers = 100
+	)
+
+	l := newTestLimiter(&configs.FrequencyCap{Channel: "*", Category: "*", Limit: limit, Window: time.Hour})
+
+	var (
+		wg       sync.WaitGroup
+		reserved int64
+	)
+
+	start := make(chan struct{})
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			if r, _ := l.Reserve(context.Background(), "u1", "sms", ""); r != nil {
+				atomic.AddInt64(&reserved, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if reserved != limit {
+		t.Fatalf("reserved %d notifications, want %d", reserved, limit)
+	}
+}
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type correlationKey struct{}
+
+// WithCorrelationID returns ctx carrying correlation ID, entries logged with such ctx get correlation_id field
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns correlation ID of ctx or empty string
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// NewCorrelationID generate ID for requests and messages which came without one
+func NewCorrelationID() string {
+	return primitive.NewObjectID().Hex()
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"strings"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Fields added to every entry
+const (
+	FieldModule        = "module"
+	FieldCorrelationID = "correlation_id"
+	FieldTraceID       = "trace_id"
+	FieldSpanID        = "span_id"
+)
+
+// Configure install Formatter to global logger. Logger level is set to the most verbose of module levels,
+// Formatter drops entries below level of their module
+func Configure(config *configs.LogConfig) {
+	var inner log.Formatter = &log.TextFormatter{FullTimestamp: true}
+	if config.Format == configs.LogFormatJSON {
+		inner = &log.JSONFormatter{}
+	}
+
+	log.SetLevel(config.MaxLevel())
+	log.SetFormatter(&Formatter{
+		inner:  inner,
+		level:  config.DefaultLevel,
+		levels: config.ModuleLevels,
+		redact: config.Redact,
+	})
+}
+
+// Formatter moves [Module] prefix of message to module field, adds correlation and trace IDs of entry
+// context and redacts personal data before passing entry to inner formatter
+type Formatter struct {
+	inner  log.Formatter
+	level  log.Level
+	levels map[string]log.Level
+	redact bool
+}
+
+func (f *Formatter) Format(entry *log.Entry) ([]byte, error) {
+	module, message := splitModule(entry.Message)
+	if !f.enabled(module, entry.Level) {
+		return nil, nil
+	}
+
+	// entry is shared with hooks, format the copy
+	e := entry.Dup()
+	e.Level = entry.Level
+	e.Caller = entry.Caller
+	e.Message = message
+
+	if module != "" {
+		e.Data[FieldModule] = module
+	}
+
+	if e.Context != nil {
+		if id := CorrelationID(e.Context); id != "" {
+			e.Data[FieldCorrelationID] = id
+		}
+
+		if sc := trace.SpanContextFromContext(e.Context); sc.IsValid() {
+			e.Data[FieldTraceID] = sc.TraceID().String()
+			e.Data[FieldSpanID] = sc.SpanID().String()
+		}
+	}
+
+	if f.redact {
+		e.Message = Redact(e.Message)
+		for key, value := range e.Data {
+			e.Data[key] = redactField(key, value)
+		}
+	}
+
+	return f.inner.Format(e)
+}
+
+func (f *Formatter) enabled(module string, level log.Level) bool {
+	if l, ok := f.levels[module]; ok {
+		return level <= l
+	}
+
+	return level <= f.level
+}
+
+// splitModule split "[Pipeline] Failed ..." into module and message
+func splitModule(message string) (string, string) {
+	if !strings.HasPrefix(message, "[") {
+		return "", message
+	}
+
+	end := strings.Index(message, "]")
+	if end < 0 {
+		return "", message
+	}
+
+	return message[1:end], strings.TrimSpace(message[end+1:])
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	// tokenPattern device tokens, web push endpoint keys, API keys. IDs of notifications (ObjectID, UUID)
+	// and traces are shorter
+	tokenPattern = regexp.MustCompile(`[A-Za-z0-9_\-:]{40,}`)
+	// phonePattern international numbers with separators (+1 555-123-4567, +7 (999) 123-45-67), national ones
+	// with area code in parens or grouped 3-3-4 and plain digits. Digits glued to dash or word are parts of
+	// IDs (e.g. last group of UUID), not phones
+	phonePattern = regexp.MustCompile(`(?:^|[^\w\-+])(?:` +
+		`\+\d[\d \-.()]{7,22}\d|` +
+		`(?:\d{1,3}[ \-.]?)?\(\d{2,5}\)[ \-.]?\d[\d \-.]{4,12}\d|` +
+		`\d{3}[\-. ]\d{3}[\-. ]\d{4}|` +
+		`\d{10,15})\b`)
+)
+
+// bodyFields hold message content, dropped entirely
+var bodyFields = map[string]bool{
+	"message":       true,
+	"body":          true,
+	"text":          true,
+	"title":         true,
+	"subject":       true,
+	"html":          true,
+	"password":      true,
+	"authorization": true,
+}
+
+// Redact mask emails, phone numbers and tokens found in s
+func Redact(s string) string {
+	s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	s = tokenPattern.ReplaceAllStringFunc(s, maskToken)
+	s = phonePattern.ReplaceAllStringFunc(s, func(match string) string {
+		// keep preceding character matched as boundary
+		i := strings.IndexAny(match, "+(0123456789")
+		if !isPhone(match[i:]) {
+			return match
+		}
+		return match[:i] + maskPhone(match[i:])
+	})
+
+	return s
+}
+
+func redactField(key string, value interface{}) interface{} {
+	key = strings.ToLower(key)
+	if bodyFields[key] {
+		return redacted
+	}
+
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return value
+	}
+
+	if key == "token" {
+		return maskToken(s)
+	}
+
+	return Redact(s)
+}
+
+// maskToken keep 4 leading and trailing characters, so token could be matched with one stored
+func maskToken(token string) string {
+	if len(token) <= 12 {
+		return "***"
+	}
+
+	return token[:4] + "***" + token[len(token)-4:]
+}
+
+// isPhone check number has as many digits as phone could: 9-15 with country code, 10-15 without it
+func isPhone(number string) bool {
+	digits := len(digitsOf(number))
+	if strings.HasPrefix(number, "+") {
+		return digits >= 9 && digits <= 15
+	}
+
+	return digits >= 10 && digits <= 15
+}
+
+// maskPhone keep last 4 digits
+func maskPhone(phone string) string {
+	prefix := ""
+	if strings.HasPrefix(phone, "+") {
+		prefix = "+"
+	}
+
+	digits := digitsOf(phone)
+
+	return prefix + "***" + digits[len(digits)-4:]
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain text", in: "notification 65f1c2a9e4b0a1b2c3d4e5f6 sent", want: "notification 65f1c2a9e4b0a1b2c3d4e5f6 sent"},
+		{name: "email", in: "sent to john.doe@example.com", want: "sent to j***@example.com"},
+		{name: "international phone", in: "sms to +79991234567 failed", want: "sms to +***4567 failed"},
+		{name: "phone only", in: "+79991234567", want: "+***4567"},
+		{name: "local phone", in: "sms to 89991234567", want: "sms to ***4567"},
+		{name: "short number kept", in: "retry 3 of 12345", want: "retry 3 of 12345"},
+		{name: "international phone with dashes", in: "sms to +1 555-123-4567 failed", want: "sms to +***4567 failed"},
+		{name: "international phone with area code in parens", in: "sms to +7 (999) 123-45-67", want: "sms to +***4567"},
+		{name: "international phone with spaces", in: "sms to +44 20 7946 0958.", want: "sms to +***0958."},
+		{name: "local phone with area code in parens", in: "sms to 8 (999) 123-45-67", want: "sms to ***4567"},
+		{name: "area code in parens", in: "call (555) 123-4567 now", want: "call ***4567 now"},
+		{name: "grouped phone", in: "call 555.123.4567", want: "call ***4567"},
+		{name: "date kept", in: "sent at 2024-03-06 12:30:45", want: "sent at 2024-03-06 12:30:45"},
+		{name: "short international number kept", in: "error code +1 234", want: "error code +1 234"},
+		{
+			name: "device token",
+			in:   "token dGhpc2lzYXZlcnlsb25nZGV2aWNldG9rZW5mb3JmY21wdXNo invalid",
+			want: "token dGhp***dXNo invalid",
+		},
+		{
+			name: "uuid kept",
+			in:   "trace 123e4567-e89b-12d3-a456-426614174000",
+			want: "trace 123e4567-e89b-12d3-a456-426614174000",
+		},
+		{name: "several", in: "a@b.io, +441234567890", want: "a***@b.io, +***7890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Fatalf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactField(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "body dropped", key: "body", value: "Your code is 1234", want: redacted},
+		{name: "key case insensitive", key: "Authorization", value: "Bearer abc", want: redacted},
+		{name: "short token", key: "token", value: "abc123", want: "***"},
+		{name: "token", key: "token", value: "abcdefghijklmnop", want: "abcd***mnop"},
+		{name: "error", key: "error", value: errors.New("bad email john@example.com"), want: "bad email j***@example.com"},
+		{name: "not a string", key: "attempt", value: 3, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactField(tt.key, tt.value); got != tt.want {
+				t.Fatalf("redactField(%q, %v) = %v, want %v", tt.key, tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// IHealthResults last results of background health checks
+type IHealthResults interface {
+	Results() map[string]bool
+}
+
+// tokensRefreshInterval how often token store sizes are counted, scrapes serve last counts
+const tokensRefreshInterval = time.Minute
+
+// Collector collects gauges of state owned by other components on scrape: health check status
+// and token store sizes. Token store is aggregated in background, so scrapes do not query it
+type Collector struct {
+	health     IHealthResults
+	tokensRepo mongo.ITokensRepository
+
+	healthDesc *prometheus.Desc
+	tokensDesc *prometheus.Desc
+
+	mu     sync.RWMutex
+	tokens map[string]int64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func NewCollector(
+	health IHealthResults,
+	tokensRepo mongo.ITokensRepository,
+) *Collector {
+	c := &Collector{
+		health:     health,
+		tokensRepo: tokensRepo,
+		stop:       make(chan struct{}),
+		healthDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "health_check_status"),
+			"Result of the last health check run, 1 if passed.",
+			[]string{"check"}, nil,
+		),
+		tokensDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "tokens"),
+			"Stored push tokens by platform.",
+			[]string{"platform"}, nil,
+		),
+	}
+
+	prometheus.MustRegister(c)
+
+	return c
+}
+
+// Start count token store in background until closed
+func (c *Collector) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(tokensRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			c.refreshTokens()
+
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stop counting
+func (c *Collector) Close() {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	c.wg.Wait()
+}
+
+// refreshTokens count token store, last counts are kept if it fails
+func (c *Collector) refreshTokens() {
+	counts, err := c.tokensRepo.CountByPlatform(context.Background())
+	if err != nil {
+		log.Error("[MetricsCollector] Failed count tokens: ", err)
+		return
+	}
+
+	c.mu.Lock()
+	c.tokens = counts
+	c.mu.Unlock()
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.healthDesc
+	ch <- c.tokensDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for name, ok := range c.health.Results() {
+		value := 0.0
+		if ok {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.healthDesc, prometheus.GaugeValue, value, name)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for platform, count := range c.tokens {
+		ch <- prometheus.MustNewConstMetric(c.tokensDesc, prometheus.GaugeValue, float64(count), platform)
+	}
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "notifier"
+
+// Error classes of failed and retried notifications
+const (
+	ErrorClassRateLimited   = "rate_limited"
+	ErrorClassCircuitOpen   = "circuit_open"
+	ErrorClassInvalidTokens = "invalid_tokens"
+	ErrorClassValidation    = "validation"
+	ErrorClassTimeout       = "timeout"
+	ErrorClassProvider      = "provider"
+)
+
+var (
+	received = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_received_total",
+		Help:      "Notifications taken into pipeline by channel.",
+	}, []string{"channel"})
+
+	sent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_sent_total",
+		Help:      "Notifications accepted by provider.",
+	}, []string{"channel", "provider"})
+
+	failed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_failed_total",
+		Help:      "Notifications provider failed to send by error class.",
+	}, []string{"channel", "provider", "error_class"})
+
+	retried = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_retried_total",
+		Help:      "Provider calls retried by adapters by error class.",
+	}, []string{"channel", "provider", "error_class"})
+
+	sendDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "adapter_send_duration_seconds",
+		Help:      "Duration of adapter Send calls including adapter retries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"channel", "provider"})
+
+	deliveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "notification_delivery_duration_seconds",
+		Help:      "Time from notification consumed (or requested) till sent.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"channel"})
+
+	inFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "notifications_in_flight",
+		Help:      "Notifications being processed by pipeline.",
+	})
+
+	providerInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "provider_requests_in_flight",
+		Help:      "Adapter Send calls in progress by provider.",
+	}, []string{"provider"})
+
+	commitFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "consumer_commit_failures_total",
+		Help:      "Offsets of processed messages consumer failed to commit by topic.",
+	}, []string{"topic"})
+)
+
+func init() {
+	prometheus.MustRegister(received, sent, failed, retried, sendDuration, deliveryDuration, inFlight, providerInFlight, commitFailures)
+}
+
+// ChannelOf returns channel provider delivers to
+func ChannelOf(provider string) string {
+	switch provider {
+	case domain.ProviderSMTP:
+		return "email"
+	case domain.ProviderSMS:
+		return "sms"
+	}
+
+	return "push"
+}
+
+// ErrorClass returns low cardinality class of send error
+func ErrorClass(err error) string {
+	var (
+		limited *domain.RateLimitError
+		open    *domain.CircuitOpenError
+		invalid *domain.InvalidTokensError
+		valid   *domain.ValidationError
+		netErr  net.Error
+	)
+
+	switch {
+	case errors.As(err, &limited):
+		return ErrorClassRateLimited
+	case errors.As(err, &open):
+		return ErrorClassCircuitOpen
+	case errors.As(err, &invalid):
+		return ErrorClassInvalidTokens
+	case errors.As(err, &valid):
+		return ErrorClassValidation
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	}
+
+	return ErrorClassProvider
+}
+
+// Received count notification taken into pipeline
+func Received(channel string) {
+	received.WithLabelValues(channel).Inc()
+}
+
+// TrackInFlight count notification being processed, returned func must be called when it is done
+func TrackInFlight() func() {
+	inFlight.Inc()
+
+	return inFlight.Dec
+}
+
+// ObserveSend call adapter and record its outcome and duration
+func ObserveSend(provider string, send func() error) error {
+	channel := ChannelOf(provider)
+
+	providerInFlight.WithLabelValues(provider).Inc()
+	started := time.Now()
+
+	err := send()
+
+	sendDuration.WithLabelValues(channel, provider).Observe(time.Since(started).Seconds())
+	providerInFlight.WithLabelValues(provider).Dec()
+
+	// invalid tokens are pruned, send is successful only if it was delivered to the rest of tokens
+	var invalid *domain.InvalidTokensError
+	if err == nil || errors.As(err, &invalid) && invalid.Delivered > 0 && invalid.Err == nil {
+		sent.WithLabelValues(channel, provider).Inc()
+	} else {
+		failed.WithLabelValues(channel, provider, ErrorClass(err)).Inc()
+	}
+
+	return err
+}
+
+// Retried count adapter retry of provider call failed with err
+func Retried(provider string, err error) {
+	retried.WithLabelValues(ChannelOf(provider), provider, ErrorClass(err)).Inc()
+}
+
+// Delivered record time from notification consumed till sent
+func Delivered(channel string, since time.Time) {
+	if since.IsZero() {
+		return
+	}
+
+	deliveryDuration.WithLabelValues(channel).Observe(time.Since(since).Seconds())
+}
+
+// CommitFailed count offset of processed message consumer failed to commit, message is consumed again
+func CommitFailed(topic string) {
+	commitFailures.WithLabelValues(topic).Inc()
+}
+
+// RegisterSlots expose usage of provider concurrency slots
+func RegisterSlots(provider string, slots chan struct{}) {
+	labels := prometheus.Labels{"provider": provider}
+
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "provider_slots_in_use",
+			Help:        "Occupied concurrency slots of provider.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(len(slots)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "provider_slots_capacity",
+			Help:        "Concurrency slots of provider.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(cap(slots)) }),
+	)
+}
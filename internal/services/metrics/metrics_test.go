@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/WildEgor/gNotifier/internal/domain"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveSend(t *testing.T) {
+	var (
+		errDown    = errors.New("provider is down")
+		errLimited = &domain.RateLimitError{Provider: domain.ProviderWebPush}
+	)
+
+	tests := []struct {
+		name  string
+		err   error
+		sent  bool
+		class string
+	}{
+		{name: "delivered", sent: true},
+		{name: "failed", err: errDown, class: ErrorClassProvider},
+		{name: "some tokens invalid", err: &domain.InvalidTokensError{Tokens: []string{"t1"}, Delivered: 1}, sent: true},
+		{name: "every token invalid", err: &domain.InvalidTokensError{Tokens: []string{"t1"}}, class: ErrorClassInvalidTokens},
+		{name: "invalid tokens and rate limited", err: &domain.InvalidTokensError{Tokens: []string{"t1"}, Delivered: 1, Err: errLimited}, class: ErrorClassRateLimited},
+	}
+
+	provider := domain.ProviderWebPush
+	channel := ChannelOf(provider)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentBefore := testutil.ToFloat64(sent.WithLabelValues(channel, provider))
+			failedBefore := 0.0
+			if tt.class != "" {
+				failedBefore = testutil.ToFloat64(failed.WithLabelValues(channel, provider, tt.class))
+			}
+
+			if err := ObserveSend(provider, func() error { return tt.err }); err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			wantSent := sentBefore
+			if tt.sent {
+				wantSent++
+			}
+			if got := testutil.ToFloat64(sent.WithLabelValues(channel, provider)); got != wantSent {
+				t.Fatalf("sent = %v, want %v", got, wantSent)
+			}
+
+			if tt.class != "" {
+				if got := testutil.ToFloat64(failed.WithLabelValues(channel, provider, tt.class)); got != failedBefore+1 {
+					t.Fatalf("failed %s = %v, want %v", tt.class, got, failedBefore+1)
+				}
+			}
+		})
+	}
+}
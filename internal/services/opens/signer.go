@@ -0,0 +1,43 @@
+package opens
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+)
+
+// Signer sign per-notification tokens of open tracking, so notification could be marked opened only by
+// the one it was delivered to (token is passed to app in push data or embedded to tracking link)
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(config *configs.AppConfig) *Signer {
+	return &Signer{
+		secret: []byte(config.OpenTokenSecret),
+	}
+}
+
+// Enabled check tokens could be issued
+func (s *Signer) Enabled() bool {
+	return len(s.secret) > 0
+}
+
+// Sign returns token of notification: base64url (no padding) of HMAC-SHA256 of notif_id
+func (s *Signer) Sign(notifID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(notifID))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify check token issued for notification, always fails if secret is not set
+func (s *Signer) Verify(notifID, token string) bool {
+	if !s.Enabled() || notifID == "" || token == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(s.Sign(notifID)), []byte(token))
+}
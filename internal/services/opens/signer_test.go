@@ -0,0 +1,36 @@
+package opens
+
+import (
+	"testing"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+)
+
+func TestSignerVerify(t *testing.T) {
+	signer := NewSigner(&configs.AppConfig{OpenTokenSecret: "secret"})
+	other := NewSigner(&configs.AppConfig{OpenTokenSecret: "other"})
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		notifID string
+		token   string
+		want    bool
+	}{
+		{name: "valid", signer: signer, notifID: "n1", token: signer.Sign("n1"), want: true},
+		{name: "token of another notification", signer: signer, notifID: "n2", token: signer.Sign("n1")},
+		{name: "token of another secret", signer: signer, notifID: "n1", token: other.Sign("n1")},
+		{name: "bare id", signer: signer, notifID: "n1", token: "n1"},
+		{name: "empty token", signer: signer, notifID: "n1"},
+		{name: "empty id", signer: signer, token: signer.Sign("")},
+		{name: "secret not set", signer: NewSigner(&configs.AppConfig{}), notifID: "n1", token: NewSigner(&configs.AppConfig{}).Sign("n1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Verify(tt.notifID, tt.token); got != tt.want {
+				t.Fatalf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
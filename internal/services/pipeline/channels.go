@@ -0,0 +1,341 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/domain"
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tokens not refreshed by client for this period are treated as stale
+const tokenFreshPeriod = 30 * 24 * time.Hour
+
+// send deliver notification through channel adapter. Adapters are called through throttler, which blocks
+// while provider is saturated: AMQP consumer holds at most prefetch unacked messages, so the broker stops
+// delivering and backpressure reaches publishers
+func (p *Pipeline) send(req *notifierDtos.NotifierPayloadDto) error {
+	switch {
+	case req.IsEmail():
+		return p.sendEmail(req)
+	case req.IsSms():
+		return p.sendSMS(req)
+	case req.IsPush():
+		return p.sendPush(req)
+	}
+
+	return errors.New("[Pipeline] Unknown notification type " + req.Type)
+}
+
+// call adapter through provider throttler, recording outcome and latency of adapter call. SMTP and SMS
+// routers throttle gateway they pick themselves, so they are not throttled by channel here
+func (p *Pipeline) call(req *notifierDtos.NotifierPayloadDto, provider string, recipients int, send func() error) error {
+	_, span := tracing.Tracer().Start(req.GetContext(), provider+" send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("provider", provider),
+			attribute.Int("recipients", recipients),
+		),
+	)
+
+	var err error
+	if provider == domain.ProviderSMTP || provider == domain.ProviderSMS {
+		err = metrics.ObserveSend(provider, send)
+	} else {
+		err = p.throttler.Do(provider, recipients, func() error {
+			return metrics.ObserveSend(provider, send)
+		})
+	}
+
+	result := "sent"
+	if err != nil {
+		result = metrics.ErrorClass(err)
+	}
+	span.SetAttributes(attribute.String("result", result))
+	tracing.End(span, err)
+
+	return err
+}
+
+// recipientOf returns address frequency of notifications capped by
+func recipientOf(req *notifierDtos.NotifierPayloadDto) string {
+	switch {
+	case req.IsEmail():
+		return req.EmailSetting.Email
+	case req.IsSms():
+		return req.PhoneSetting.Number
+	}
+
+	return req.GetSubscriberID()
+}
+
+func (p *Pipeline) sendEmail(req *notifierDtos.NotifierPayloadDto) error {
+	if req.EmailSetting.Email == "" {
+		return errNoRecipient
+	}
+
+	notification := domain.EmailNotification{
+		Email:   req.EmailSetting.Email,
+		Subject: req.EmailSetting.Subject,
+		Message: req.EmailSetting.Text,
+	}
+
+	if req.WithTemplate() {
+		msg, err := p.parseTemplate(req)
+		if err != nil {
+			log.WithContext(req.GetContext()).Error("[Pipeline] template parse error: ", err.Error())
+			return err
+		}
+		notification.Message = msg
+	}
+
+	if err := p.call(req, domain.ProviderSMTP, 1, func() error { return p.smtpAdapter.Send(&notification) }); err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed send to: ", req.EmailSetting.Email, err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *Pipeline) sendSMS(req *notifierDtos.NotifierPayloadDto) error {
+	if req.PhoneSetting.Number == "" {
+		return errNoRecipient
+	}
+
+	notification := domain.SMSNotification{
+		Phone:   req.PhoneSetting.Number,
+		Message: req.PhoneSetting.Text,
+	}
+
+	if err := p.call(req, domain.ProviderSMS, 1, func() error { return p.smsAdapter.Send(&notification) }); err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed send to: ", req.PhoneSetting.Number, err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *Pipeline) sendPush(req *notifierDtos.NotifierPayloadDto) error {
+	subID := req.GetSubscriberID()
+
+	notification := domain.PushNotification{
+		ID:      req.NotifID,
+		To:      "",
+		Topic:   req.PushSetting.To,
+		Message: req.PushSetting.Message,
+		Title:   req.PushSetting.Title,
+		Image:   req.PushSetting.Image,
+		Data:    p.pushData(req),
+		Ctx:     req.GetContext(),
+	}
+
+	if req.WithTemplate() {
+		msg, err := p.parseTemplate(req)
+		if err != nil {
+			log.WithContext(req.GetContext()).Error("[Pipeline] template parse error: ", err.Error())
+			return err
+		}
+		notification.Message = msg
+	}
+
+	switch {
+	case req.IsForAndroid():
+		notification.Platform = domain.PlatFormAndroid
+		notification.Tokens = p.findTokens(req.GetContext(), subID, models.PlatformAndroid)
+		if len(notification.Tokens) == 0 {
+			return errNoRecipient
+		}
+
+		return p.pruneTokens(req.GetContext(), subID, p.call(req, domain.ProviderFCM, len(notification.Tokens), func() error { return p.fcmAdapter.Send(&notification) }))
+	case req.IsForIOS():
+		notification.Platform = domain.PlatFormIos
+		notification.Tokens = p.findTokens(req.GetContext(), subID, models.PlatformIOS)
+		if len(notification.Tokens) == 0 {
+			return errNoRecipient
+		}
+
+		return p.pruneTokens(req.GetContext(), subID, p.call(req, domain.ProviderAPNs, len(notification.Tokens), func() error { return p.apnAdapter.Send(&notification) }))
+	case req.IsForHuawei():
+		notification.Platform = domain.PlatformHuawei
+		notification.Tokens = p.findTokens(req.GetContext(), subID, models.PlatformHuawei)
+		if len(notification.Tokens) == 0 {
+			return errNoRecipient
+		}
+
+		return p.pruneTokens(req.GetContext(), subID, p.call(req, domain.ProviderHMS, len(notification.Tokens), func() error { return p.hmsAdapter.Send(&notification) }))
+	case req.IsForWeb():
+		webNotification := domain.WebPushNotification{
+			ID:            notification.ID,
+			Subscriptions: p.findWebPushSubscriptions(req.GetContext(), subID),
+			Title:         notification.Title,
+			Message:       notification.Message,
+			Image:         notification.Image,
+			Data:          notification.Data,
+			TTL:           req.PushSetting.TTL,
+			Urgency:       req.PushSetting.Urgency,
+			Topic:         req.PushSetting.Topic,
+			Ctx:           req.GetContext(),
+		}
+		if len(webNotification.Subscriptions) == 0 {
+			return errNoRecipient
+		}
+
+		return p.pruneTokens(req.GetContext(), subID, p.call(req, domain.ProviderWebPush, len(webNotification.Subscriptions), func() error { return p.webPushAdapter.Send(&webNotification) }))
+	}
+
+	return errors.New("[Pipeline] Unknown push platform " + req.PushSetting.Platform)
+}
+
+// findTokens returns fresh subscriber tokens for platform
+func (p *Pipeline) findTokens(ctx context.Context, subID, platform string) []string {
+	var tokens []string
+
+	for _, t := range p.findFreshTokens(ctx, subID, platform) {
+		tokens = append(tokens, t.Token)
+	}
+
+	return tokens
+}
+
+func (p *Pipeline) findWebPushSubscriptions(ctx context.Context, subID string) []*domain.WebPushSubscription {
+	var subscriptions []*domain.WebPushSubscription
+
+	for _, t := range p.findFreshTokens(ctx, subID, models.PlatformWeb) {
+		if t.Keys == nil {
+			continue
+		}
+
+		subscriptions = append(subscriptions, &domain.WebPushSubscription{
+			Endpoint: t.Token,
+			Keys: domain.WebPushKeys{
+				P256dh: t.Keys.P256dh,
+				Auth:   t.Keys.Auth,
+			},
+		})
+	}
+
+	return subscriptions
+}
+
+func (p *Pipeline) findFreshTokens(ctx context.Context, subID, platform string) (tokens []*models.TokenModel) {
+	if subID == "" {
+		return nil
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "tokens lookup", trace.WithAttributes(
+		attribute.String("subscriber.id", subID),
+		attribute.String("platform", platform),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int("tokens", len(tokens)))
+		span.End()
+	}()
+
+	sub, err := p.tokensRepo.FindSub(ctx, &mongo.TokensFilter{SubId: subID})
+	if err != nil {
+		log.WithContext(ctx).Error("[Pipeline] Failed find tokens of: ", subID, err)
+		span.RecordError(err)
+		return nil
+	}
+
+	if sub == nil {
+		return nil
+	}
+
+	for _, t := range sub.Tokens {
+		if t.Platform != platform || time.Since(t.UpdatedAt) > tokenFreshPeriod {
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens
+}
+
+// pruneTokens remove tokens reported by adapter as invalid. Push is failed if it was not delivered to any token
+// or delivery to the rest of tokens failed, otherwise it is considered successful
+func (p *Pipeline) pruneTokens(ctx context.Context, subID string, err error) error {
+	var invalid *domain.InvalidTokensError
+	if !errors.As(err, &invalid) {
+		if err != nil {
+			log.WithContext(ctx).Error("[Pipeline] Failed send push to: ", subID, err)
+		}
+		return err
+	}
+
+	if err := p.tokensRepo.DeleteTokens(ctx, subID, invalid.Tokens); err != nil {
+		log.WithContext(ctx).Error("[Pipeline] Failed prune tokens of: ", subID, err)
+	}
+
+	if invalid.Err != nil {
+		log.WithContext(ctx).Error("[Pipeline] Failed send push to: ", subID, invalid.Err)
+		return invalid.Err
+	}
+
+	if invalid.Delivered == 0 {
+		return invalid
+	}
+
+	return nil
+}
+
+func (p *Pipeline) parseTemplate(req *notifierDtos.NotifierPayloadDto) (msg string, err error) {
+	path := req.EmailSetting.Template
+	if req.IsPush() && req.PushSetting.Template != "" {
+		path = req.PushSetting.Template
+	}
+
+	_, span := tracing.Tracer().Start(req.GetContext(), "template render", trace.WithAttributes(
+		attribute.String("template", path),
+	))
+	defer func() { tracing.End(span, req.Error) }()
+
+	tml, err := template.ParseFiles(path)
+	if err != nil {
+		req.Error = err
+		return "", errors.New("[Pipeline] Cannot parse template")
+	}
+
+	buf := new(bytes.Buffer)
+	if err = tml.Execute(buf, req.Data); err != nil {
+		req.Error = err
+		return "", errors.New("[Pipeline] Cannot parse template")
+	}
+
+	return buf.String(), nil
+}
+
+// pushData returns data of push with notif_id and its open token, so app could report open of notification
+func (p *Pipeline) pushData(req *notifierDtos.NotifierPayloadDto) domain.AnyData {
+	data := toAnyData(req.PushSetting.Data)
+	if !p.openTokens.Enabled() {
+		return data
+	}
+
+	tracked := make(domain.AnyData, len(data)+2)
+	for k, v := range data {
+		tracked[k] = v
+	}
+	tracked["notif_id"] = req.NotifID
+	tracked["open_token"] = p.openTokens.Sign(req.NotifID)
+
+	return tracked
+}
+
+func toAnyData(data interface{}) domain.AnyData {
+	if m, ok := data.(map[string]interface{}); ok {
+		return m
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	log "github.com/sirupsen/logrus"
+)
+
+// quietUntil returns end of subscriber quiet hours if notification should wait for it
+func (p *Pipeline) quietUntil(prefs *models.PreferencesModel, req *notifierDtos.NotifierPayloadDto) (time.Time, bool) {
+	if prefs == nil || p.preferencesConfig.IsBreakthrough(req.Category) {
+		return time.Time{}, false
+	}
+
+	return prefs.QuietUntil(time.Now())
+}
+
+// deferUntil store notification to be sent by dispatcher later. Notification with the same notif_id already
+// pending is not stored again, its duplicate result carries mongo.ErrScheduleExists
+func (p *Pipeline) deferUntil(req *notifierDtos.NotifierPayloadDto, sendAt time.Time, status, reason string) (*Result, error) {
+	err := p.store(req, sendAt, reason)
+	if errors.Is(err, mongo.ErrScheduleExists) {
+		return &Result{
+			NotifID:   req.NotifID,
+			Status:    status,
+			Channel:   req.Type,
+			Reason:    reason,
+			Duplicate: true,
+			Error:     err,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p.record(req, &Result{
+		Status:  status,
+		Channel: req.Type,
+		Reason:  reason,
+	}), nil
+}
+
+// store notification to schedule, dispatcher hands it to pipeline again at sendAt
+func (p *Pipeline) store(req *notifierDtos.NotifierPayloadDto, sendAt time.Time, reason string) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Cannot encode deferred notification: ", req.NotifID, err)
+		return err
+	}
+
+	_, err = p.scheduleRepo.Create(req.GetContext(), &models.ScheduledNotificationModel{
+		NotifID: req.NotifID,
+		SubID:   req.GetSubscriberID(),
+		Reason:  reason,
+		SendAt:  sendAt,
+		Payload: string(payload),
+	})
+	if err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed defer notification: ", req.NotifID, err)
+		return err
+	}
+
+	log.WithContext(req.GetContext()).Debugf("[Pipeline] Notification %s deferred till %s: %s", req.NotifID, sendAt, reason)
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	textTemplate "text/template"
+	"time"
+
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultDigestTemplate used if DIGEST_TEMPLATE not set
+const defaultDigestTemplate = `{{range .Items}}- {{if .Title}}{{.Title}}: {{end}}{{.Body}}
+{{end}}{{if .More}}...and {{.More}} more
+{{end}}`
+
+// digestView is data digest template rendered with
+type digestView struct {
+	Key   string
+	Count int
+	// More count of collected notifications not listed in Items
+	More  int
+	Items []*digestItemView
+}
+
+type digestItemView struct {
+	NotifID   string
+	Title     string
+	Body      string
+	Data      interface{}
+	CreatedAt time.Time
+}
+
+type digestTemplate interface {
+	Execute(wr io.Writer, data interface{}) error
+}
+
+// collectDigest add notification to subscriber digest instead of sending. Digest made due once it collected
+// enough notifications, so flusher sends it on next poll instead of when window ends
+func (p *Pipeline) collectDigest(req *notifierDtos.NotifierPayloadDto) (*Result, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Cannot encode digest notification: ", req.NotifID, err)
+		return nil, err
+	}
+
+	title, body, data := req.GetInboxContent()
+	item := &models.DigestItemModel{
+		NotifID: req.NotifID,
+		Title:   title,
+		Body:    body,
+	}
+	if data != nil {
+		if encoded, err := json.Marshal(data); err == nil {
+			item.Data = string(encoded)
+		}
+	}
+
+	digest, err := p.digestsRepo.Append(req.GetContext(), &models.DigestModel{
+		SubID:    digestOwnerOf(req),
+		Key:      req.DigestKey,
+		Channel:  req.Type,
+		Category: req.Category,
+		Payload:  string(payload),
+		FlushAt:  time.Now().Add(p.digestConfig.Window),
+	}, item, p.digestConfig.MaxItems)
+	if err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed collect notification to digest: ", req.NotifID, err)
+		return nil, err
+	}
+
+	res := p.record(req, &Result{
+		Status:  models.StatusBatched,
+		Channel: req.Type,
+		Reason:  models.ReasonDigest,
+	})
+
+	if digest.Count >= p.digestConfig.FlushCount {
+		if err := p.digestsRepo.FlushNow(req.GetContext(), digest.ID); err != nil {
+			// flusher sends it when window ends
+			log.WithContext(req.GetContext()).Error("[Pipeline] Failed make full digest due: ", digest.ID.Hex(), err)
+		}
+	}
+
+	return res, nil
+}
+
+// FlushDigest send collected notifications as one message with channel settings of the first one and remove digest.
+// Failed digest is kept claimed, so flusher retries it when lock expires, till SchedulerConfig.MaxAttempts.
+// Digest has deterministic ID, so it is not sent twice if flush retried after crash; while notification of
+// previous flush is still locked by its idempotency key digest is kept claimed as well
+func (p *Pipeline) FlushDigest(d *models.DigestModel) *Result {
+	req := notifierDtos.NotifierPayloadDto{
+		TimeReqStart: time.Now(),
+	}
+	if err := json.Unmarshal([]byte(d.Payload), &req); err != nil {
+		// payload never becomes valid, so retry is pointless
+		log.Error("[Pipeline] Cannot parse digest notification: ", d.ID.Hex(), err)
+		return p.completeDigest(d, &Result{
+			NotifID: digestNotifID(d),
+			Status:  models.StatusFailed,
+			Channel: d.Channel,
+			Error:   err,
+		})
+	}
+
+	req.NotifID = digestNotifID(d)
+	req.IdempotencyKey = ""
+	req.DigestKey = ""
+	req.SendAt = nil
+	// collected notifications were stored to inbox one by one
+	req.Inbox = false
+	req.Realtime = false
+
+	msg, err := p.renderDigest(d, req.IsEmail())
+	if err != nil {
+		log.Error("[Pipeline] Cannot render digest: ", d.ID.Hex(), err)
+		res := &Result{
+			Status:  models.StatusFailed,
+			Channel: d.Channel,
+			Error:   err,
+		}
+		if p.retryDigest(d, res) {
+			res.NotifID = req.NotifID
+			return res
+		}
+
+		req.Error = err
+		return p.completeDigest(d, p.record(&req, res))
+	}
+
+	switch {
+	case req.IsEmail():
+		req.EmailSetting.Template = ""
+		req.EmailSetting.Text = msg
+		if d.Count > 1 {
+			req.EmailSetting.Subject = fmt.Sprintf("%s (%d)", req.EmailSetting.Subject, d.Count)
+		}
+	case req.IsSms():
+		req.PhoneSetting.Text = msg
+	case req.IsPush():
+		req.PushSetting.Template = ""
+		req.PushSetting.Message = msg
+	}
+
+	res := p.Process(&req)
+	if res.Duplicate && res.Status == models.StatusProcessing {
+		// previous flush of digest is still sending it (or died), digest left locked till outcome is known
+		log.Warnf("[Pipeline] Digest %s is still processing, retried when lock expires", d.ID.Hex())
+		return res
+	}
+
+	if res.IsFailed() && p.retryDigest(d, res) {
+		return res
+	}
+
+	return p.completeDigest(d, res)
+}
+
+// retryDigest whether failed digest is left locked, so flusher retries it when lock expires. It is given up
+// once attempts are exhausted
+func (p *Pipeline) retryDigest(d *models.DigestModel, res *Result) bool {
+	if d.Attempts < p.schedulerConfig.MaxAttempts {
+		log.Warnf("[Pipeline] Digest %s failed (attempt %d), retried when lock expires: %v", d.ID.Hex(), d.Attempts, res.Error)
+		return true
+	}
+
+	log.Errorf("[Pipeline] Digest %s failed %d times, giving up: %v", d.ID.Hex(), d.Attempts, res.Error)
+
+	return false
+}
+
+// completeDigest collected notifications get final status of digest, then digest removed
+func (p *Pipeline) completeDigest(d *models.DigestModel, res *Result) *Result {
+	res = p.recordDigest(d, res)
+
+	if err := p.digestsRepo.Delete(context.Background(), d.ID); err != nil {
+		log.Error("[Pipeline] Failed remove flushed digest: ", d.ID.Hex(), err)
+	}
+
+	return res
+}
+
+// recordDigest collected notifications get status of digest they were sent with
+func (p *Pipeline) recordDigest(d *models.DigestModel, res *Result) *Result {
+	for _, notifID := range d.NotifIDs {
+		p.record(&notifierDtos.NotifierPayloadDto{
+			NotifID:      notifID,
+			SubscriberID: d.SubID,
+			Category:     d.Category,
+		}, &Result{
+			Status:  res.Status,
+			Channel: res.Channel,
+			Reason:  models.ReasonDigest,
+			Error:   res.Error,
+		})
+	}
+
+	log.Debugf("[Pipeline] Digest %s of %d notifications %s", res.NotifID, d.Count, res.Status)
+
+	return res
+}
+
+func (p *Pipeline) renderDigest(d *models.DigestModel, html bool) (string, error) {
+	view := digestView{
+		Key:   d.Key,
+		Count: d.Count,
+		More:  d.Count - len(d.Items),
+	}
+
+	for _, item := range d.Items {
+		itemView := &digestItemView{
+			NotifID:   item.NotifID,
+			Title:     item.Title,
+			Body:      item.Body,
+			CreatedAt: item.CreatedAt,
+		}
+		if item.Data != "" {
+			_ = json.Unmarshal([]byte(item.Data), &itemView.Data)
+		}
+		view.Items = append(view.Items, itemView)
+	}
+
+	tml, err := p.digestTemplate(html)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tml.Execute(buf, view); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// digestTemplate returns digest template, custom one html escaped for emails (as other email templates).
+// Built-in template is plain text
+func (p *Pipeline) digestTemplate(html bool) (digestTemplate, error) {
+	path := p.digestConfig.Template
+
+	switch {
+	case path == "":
+		return textTemplate.New("digest").Parse(defaultDigestTemplate)
+	case html:
+		return htmlTemplate.ParseFiles(path)
+	}
+
+	return textTemplate.ParseFiles(path)
+}
+
+// digestOwnerOf returns whom digest collected for: subscriber or channel address if there is no subscriber ID
+func digestOwnerOf(req *notifierDtos.NotifierPayloadDto) string {
+	if subID := req.GetSubscriberID(); subID != "" {
+		return subID
+	}
+
+	return recipientOf(req)
+}
+
+func digestNotifID(d *models.DigestModel) string {
+	return "digest-" + d.ID.Hex()
+}
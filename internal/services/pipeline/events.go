@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/services/events"
+)
+
+// reasonFallback notification retried through next channel of fallback chain
+const reasonFallback = "fallback"
+
+// emit publish status event of notification, res is nil for events before delivery
+func (p *Pipeline) emit(req *notifierDtos.NotifierPayloadDto, event string, res *Result) {
+	e := &events.Event{
+		Event:     event,
+		NotifID:   req.NotifID,
+		Channel:   req.Type,
+		Category:  req.Category,
+		Recipient: recipientOf(req),
+	}
+
+	if res != nil {
+		e.Status = res.Status
+		e.Reason = res.Reason
+		if res.Channel != "" {
+			e.Channel = res.Channel
+		}
+		if res.Error != nil {
+			e.Error = res.Error.Error()
+		}
+	}
+
+	p.events.Publish(req.GetContext(), e)
+}
+
+// emitRecorded publish event of recorded status, statuses of notifications waiting for delivery
+// (scheduled, deferred, batched) have no event
+func (p *Pipeline) emitRecorded(req *notifierDtos.NotifierPayloadDto, res *Result) {
+	switch res.Status {
+	case models.StatusSent:
+		p.emit(req, events.EventSent, res)
+	case models.StatusFailed:
+		// transport publishes retried event instead
+		if req.RetryIf != nil && req.RetryIf(res.Error) {
+			return
+		}
+		p.emit(req, events.EventFailed, res)
+	case models.StatusSkipped, models.StatusRateLimited:
+		p.emit(req, events.EventSkipped, res)
+	}
+}
+
+// Requeued publish retried event of notification returned to queue to be processed again
+func (p *Pipeline) Requeued(req *notifierDtos.NotifierPayloadDto, res *Result) {
+	p.emit(req, events.EventRetried, res)
+}
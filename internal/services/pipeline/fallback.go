@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"time"
+
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/services/events"
+	log "github.com/sirupsen/logrus"
+)
+
+// walkFallback deliver notification through type channel and then through fallback channels one by one
+// while delivery meets chain conditions. Waiting for open stored to schedule, so chain survives restarts
+func (p *Pipeline) walkFallback(req *notifierDtos.NotifierPayloadDto, prefs *models.PreferencesModel) *Result {
+	chain := req.Fallback
+
+	if chain.AwaitOpen {
+		chain.AwaitOpen = false
+		if p.isOpened(req) {
+			log.WithContext(req.GetContext()).Debugf("[Pipeline] Notification %s opened, fallback stopped", req.NotifID)
+			return &Result{
+				NotifID: req.NotifID,
+				Status:  models.StatusOpened,
+			}
+		}
+	}
+
+	var contacts *models.ContactsModel
+	for {
+		stepReq := req
+		if chain.Step > 0 {
+			if contacts == nil {
+				contacts = p.findContacts(req)
+			}
+			stepReq = fallbackRequest(req, chain.Channels[chain.Step-1], contacts)
+		}
+
+		res := p.deliver(stepReq, prefs)
+		if chain.IsLast() {
+			return res
+		}
+
+		if res.Status == models.StatusSent {
+			if chain.FallsBackOn(notifierDtos.FallbackOnNotOpened) {
+				chain.Step++
+				chain.AwaitOpen = true
+				// notification delivered anyway, so failure to store only cancels the rest of chain
+				_ = p.store(req, time.Now().Add(chain.GetOpenTimeout()), models.ReasonAwaitOpen)
+			}
+			return res
+		}
+
+		if condition := fallbackCondition(res); condition != "" && !chain.FallsBackOn(condition) {
+			return res
+		}
+
+		log.WithContext(req.GetContext()).Debugf("[Pipeline] Notification %s not delivered through %s (%s), falling back", req.NotifID, res.Channel, res.Status)
+		p.emit(stepReq, events.EventRetried, &Result{
+			Status:  res.Status,
+			Channel: res.Channel,
+			Reason:  reasonFallback,
+			Error:   res.Error,
+		})
+		chain.Step++
+	}
+}
+
+// fallbackCondition returns chain condition met by not delivered notification. Channels subscriber
+// opted out of or capped by frequency are always skipped, so empty condition returned for them
+func fallbackCondition(res *Result) string {
+	switch {
+	case res.Status == models.StatusFailed:
+		return notifierDtos.FallbackOnFailed
+	case res.Status == models.StatusSkipped && res.Reason == models.ReasonNoRecipient:
+		return notifierDtos.FallbackOnNoRecipient
+	}
+
+	return ""
+}
+
+// fallbackRequest returns notification addressed to fallback channel. Address taken from subscriber
+// contacts and content from notification itself if channel settings are not passed
+func fallbackRequest(req *notifierDtos.NotifierPayloadDto, channel *notifierDtos.FallbackChannelDto, contacts *models.ContactsModel) *notifierDtos.NotifierPayloadDto {
+	stepReq := *req
+	stepReq.Type = channel.Type
+
+	title, body, _ := req.GetInboxContent()
+
+	switch {
+	case stepReq.IsEmail():
+		stepReq.PushSetting.Template = ""
+		if stepReq.EmailSetting.Email == "" && contacts != nil {
+			stepReq.EmailSetting.Email = contacts.Email
+		}
+		if stepReq.EmailSetting.Subject == "" {
+			stepReq.EmailSetting.Subject = title
+		}
+		if stepReq.EmailSetting.Text == "" && stepReq.EmailSetting.Template == "" {
+			stepReq.EmailSetting.Text = body
+		}
+	case stepReq.IsSms():
+		if stepReq.PhoneSetting.Number == "" && contacts != nil {
+			stepReq.PhoneSetting.Number = contacts.Phone
+		}
+		if stepReq.PhoneSetting.Text == "" {
+			stepReq.PhoneSetting.Text = body
+		}
+	case stepReq.IsPush():
+		stepReq.EmailSetting.Template = ""
+		stepReq.PushSetting.Platform = channel.Platform
+		if stepReq.PushSetting.To == "" {
+			stepReq.PushSetting.To = req.GetSubscriberID()
+		}
+		if stepReq.PushSetting.Title == "" {
+			stepReq.PushSetting.Title = title
+		}
+		if stepReq.PushSetting.Message == "" && stepReq.PushSetting.Template == "" {
+			stepReq.PushSetting.Message = body
+		}
+	}
+
+	return &stepReq
+}
+
+func (p *Pipeline) findContacts(req *notifierDtos.NotifierPayloadDto) *models.ContactsModel {
+	subID := req.GetSubscriberID()
+	if subID == "" {
+		return nil
+	}
+
+	contacts, err := p.contactsRepo.Find(req.GetContext(), subID)
+	if err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed find contacts of: ", subID, err)
+		return nil
+	}
+
+	return contacts
+}
+
+func (p *Pipeline) isOpened(req *notifierDtos.NotifierPayloadDto) bool {
+	status, err := p.statusesRepo.FindByNotifID(req.GetContext(), req.NotifID)
+	if err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed find status of: ", req.NotifID, err)
+		return false
+	}
+
+	return status != nil && status.OpenedAt != nil
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// beginIdempotent returns result of original processing if notification is a duplicate
+func (p *Pipeline) beginIdempotent(key string, req *notifierDtos.NotifierPayloadDto) *Result {
+	existing, err := p.idempotencyRepo.Begin(req.GetContext(), key, req.NotifID, p.idempotencyConfig.TTL, p.idempotencyConfig.LockTimeout)
+	if err != nil {
+		// better send twice than lose notification
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed check idempotency key: ", key, err)
+		return nil
+	}
+
+	if existing == nil {
+		return nil
+	}
+
+	log.WithContext(req.GetContext()).Debugf("[Pipeline] Duplicate of %s skipped by key %s", existing.NotifID, key)
+
+	status := existing.ResultStatus
+	if existing.Status == models.IdempotencyProcessing {
+		status = models.StatusProcessing
+	}
+
+	return &Result{
+		NotifID:   existing.NotifID,
+		Status:    status,
+		Channel:   existing.ResultChannel,
+		Reason:    existing.ResultReason,
+		Duplicate: true,
+	}
+}
+
+// completeIdempotent remember result for duplicates. Failed notification forgotten, so redelivery could retry it
+func (p *Pipeline) completeIdempotent(key string, req *notifierDtos.NotifierPayloadDto, res *Result) {
+	if res.IsFailed() {
+		if err := p.idempotencyRepo.Release(req.GetContext(), key); err != nil {
+			log.WithContext(req.GetContext()).Error("[Pipeline] Failed release idempotency key: ", key, err)
+		}
+		return
+	}
+
+	if err := p.idempotencyRepo.Complete(req.GetContext(), &models.IdempotencyModel{
+		Key:           key,
+		NotifID:       res.NotifID,
+		ResultStatus:  res.Status,
+		ResultChannel: res.Channel,
+		ResultReason:  res.Reason,
+	}, p.idempotencyConfig.TTL); err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed complete idempotency key: ", key, err)
+	}
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"time"
+
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	log "github.com/sirupsen/logrus"
+)
+
+// deliverInbox store notification to inbox (if asked) and publish it to live connections
+func (p *Pipeline) deliverInbox(req *notifierDtos.NotifierPayloadDto) {
+	var item *models.InboxItemModel
+	if req.Inbox {
+		item = p.saveToInbox(req)
+	}
+
+	p.publishRealtime(req, item)
+}
+
+// saveToInbox store notification to subscriber inbox, so it could be read later even if push missed
+func (p *Pipeline) saveToInbox(req *notifierDtos.NotifierPayloadDto) *models.InboxItemModel {
+	title, body, data := req.GetInboxContent()
+
+	item, err := p.inboxRepo.Create(req.GetContext(), &models.InboxItemModel{
+		SubID:     req.GetSubscriberID(),
+		NotifID:   req.NotifID,
+		Title:     title,
+		Body:      body,
+		Data:      data,
+		Category:  req.Category,
+		CreatedAt: time.Now(),
+		ExpiresAt: req.InboxSetting.ExpiresAt,
+	})
+	if err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed save to inbox of: ", req.GetSubscriberID(), err)
+		return nil
+	}
+
+	return item
+}
+
+// publishRealtime deliver notification (or stored inbox item) to subscriber live connections
+func (p *Pipeline) publishRealtime(req *notifierDtos.NotifierPayloadDto, item *models.InboxItemModel) {
+	event := &realtime.Event{
+		SubID:     req.GetSubscriberID(),
+		NotifID:   req.NotifID,
+		Category:  req.Category,
+		CreatedAt: time.Now(),
+	}
+	event.Title, event.Body, event.Data = req.GetInboxContent()
+
+	if item != nil {
+		event.ID = item.ID.Hex()
+		event.CreatedAt = item.CreatedAt
+	}
+
+	if err := p.realtimeHub.Publish(event); err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed publish realtime event to: ", event.SubID, err)
+	}
+}
@@ -0,0 +1,310 @@
+package pipeline
+
+import (
+	"errors"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/adapters"
+	"github.com/WildEgor/gNotifier/internal/configs"
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/events"
+	"github.com/WildEgor/gNotifier/internal/services/frequency"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/WildEgor/gNotifier/internal/services/opens"
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errNoRecipient returned by channels when subscriber has no address (or tokens) to deliver to
+var errNoRecipient = errors.New("[Pipeline] No recipient to deliver")
+
+// Result of notification processing
+type Result struct {
+	NotifID string `json:"notif_id"`
+	Status  string `json:"status"`
+	Channel string `json:"channel,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	// Duplicate notification was already processed, result of original processing returned
+	Duplicate bool  `json:"duplicate,omitempty"`
+	Error     error `json:"-"`
+}
+
+func (r *Result) IsFailed() bool {
+	return r.Status == models.StatusFailed
+}
+
+// Pipeline is a send pipeline shared by every transport: it schedules notifications with send_at,
+// evaluates subscriber preferences, defers notifications within quiet hours, walks fallback channels, collects digests, enforces frequency caps, stores inbox, publishes realtime events, calls channel adapters
+// (shaped by provider throttler) and records resulting status
+type Pipeline struct {
+	preferencesConfig *configs.PreferencesConfig
+	idempotencyConfig *configs.IdempotencyConfig
+	schedulerConfig   *configs.SchedulerConfig
+	digestConfig      *configs.DigestConfig
+	smtpAdapter       adapters.ISMTPAdapter
+	smsAdapter        adapters.ISMSAdapter
+	fcmAdapter        adapters.IFCMAdapter
+	apnAdapter        adapters.IAPNAdapter
+	webPushAdapter    adapters.IWebPushAdapter
+	hmsAdapter        adapters.IHMSAdapter
+	tokensRepo        mongo.ITokensRepository
+	inboxRepo         mongo.IInboxRepository
+	statusesRepo      mongo.IStatusesRepository
+	preferencesRepo   mongo.IPreferencesRepository
+	scheduleRepo      mongo.IScheduleRepository
+	idempotencyRepo   mongo.IIdempotencyRepository
+	digestsRepo       mongo.IDigestsRepository
+	contactsRepo      mongo.IContactsRepository
+	realtimeHub       *realtime.Hub
+	frequencyLimiter  *frequency.Limiter
+	throttler         *throttle.Throttler
+	events            *events.Publisher
+	openTokens        *opens.Signer
+}
+
+func NewPipeline(
+	preferencesConfig *configs.PreferencesConfig,
+	idempotencyConfig *configs.IdempotencyConfig,
+	schedulerConfig *configs.SchedulerConfig,
+	digestConfig *configs.DigestConfig,
+	smtpAdapter adapters.ISMTPAdapter,
+	smsAdapter adapters.ISMSAdapter,
+	fcmAdapter adapters.IFCMAdapter,
+	apnAdapter adapters.IAPNAdapter,
+	webPushAdapter adapters.IWebPushAdapter,
+	hmsAdapter adapters.IHMSAdapter,
+	tokensRepo mongo.ITokensRepository,
+	inboxRepo mongo.IInboxRepository,
+	statusesRepo mongo.IStatusesRepository,
+	preferencesRepo mongo.IPreferencesRepository,
+	scheduleRepo mongo.IScheduleRepository,
+	idempotencyRepo mongo.IIdempotencyRepository,
+	digestsRepo mongo.IDigestsRepository,
+	contactsRepo mongo.IContactsRepository,
+	realtimeHub *realtime.Hub,
+	frequencyLimiter *frequency.Limiter,
+	throttler *throttle.Throttler,
+	events *events.Publisher,
+	openTokens *opens.Signer,
+) *Pipeline {
+	return &Pipeline{
+		preferencesConfig: preferencesConfig,
+		idempotencyConfig: idempotencyConfig,
+		schedulerConfig:   schedulerConfig,
+		digestConfig:      digestConfig,
+		smtpAdapter:       smtpAdapter,
+		smsAdapter:        smsAdapter,
+		fcmAdapter:        fcmAdapter,
+		apnAdapter:        apnAdapter,
+		webPushAdapter:    webPushAdapter,
+		hmsAdapter:        hmsAdapter,
+		tokensRepo:        tokensRepo,
+		inboxRepo:         inboxRepo,
+		statusesRepo:      statusesRepo,
+		preferencesRepo:   preferencesRepo,
+		scheduleRepo:      scheduleRepo,
+		idempotencyRepo:   idempotencyRepo,
+		digestsRepo:       digestsRepo,
+		contactsRepo:      contactsRepo,
+		realtimeHub:       realtimeHub,
+		frequencyLimiter:  frequencyLimiter,
+		throttler:         throttler,
+		events:            events,
+		openTokens:        openTokens,
+	}
+}
+
+// Process run validated notification through pipeline. Duplicates (by idempotency key or notif_id)
+// are not processed again, result of original processing returned instead
+func (p *Pipeline) Process(req *notifierDtos.NotifierPayloadDto) (res *Result) {
+	defer metrics.TrackInFlight()()
+	metrics.Received(req.Type)
+
+	key := req.GetIdempotencyKey()
+
+	if req.NotifID == "" {
+		req.NotifID = primitive.NewObjectID().Hex()
+	}
+
+	span := startProcess(req)
+	defer func() { endProcess(span, res) }()
+
+	if key != "" {
+		if res := p.beginIdempotent(key, req); res != nil {
+			return res
+		}
+	}
+
+	p.emit(req, events.EventAccepted, nil)
+
+	res = p.process(req)
+
+	if key != "" {
+		p.completeIdempotent(key, req, res)
+	}
+
+	return res
+}
+
+// ProcessDue run notification released by dispatcher, it was deduplicated before stored
+func (p *Pipeline) ProcessDue(req *notifierDtos.NotifierPayloadDto) *Result {
+	defer metrics.TrackInFlight()()
+
+	span := startProcess(req)
+	res := p.process(req)
+	endProcess(span, res)
+
+	if key := req.GetIdempotencyKey(); key != "" {
+		p.completeIdempotent(key, req, res)
+	}
+
+	return res
+}
+
+func (p *Pipeline) process(req *notifierDtos.NotifierPayloadDto) *Result {
+	if req.SendAt != nil && req.SendAt.After(time.Now()) {
+		res, err := p.deferUntil(req, *req.SendAt, models.StatusScheduled, models.ReasonSendAt)
+		if err != nil {
+			req.Error = err
+			return p.record(req, &Result{
+				Status:  models.StatusFailed,
+				Channel: req.Type,
+				Error:   err,
+			})
+		}
+		return res
+	}
+
+	prefs := p.findPreferences(req)
+
+	// not urgent notifications wait for the end of subscriber quiet hours (sent right away if cannot be stored)
+	if until, quiet := p.quietUntil(prefs, req); quiet {
+		if res, err := p.deferUntil(req, until, models.StatusDeferred, models.ReasonQuietHours); err == nil {
+			return res
+		}
+	}
+
+	// resumed fallback chain was delivered to inbox on first run
+	resumed := req.Fallback != nil && req.Fallback.Step > 0
+	if (req.Inbox || req.Realtime) && !resumed {
+		if allowed, reason := p.allows(prefs, req, models.ChannelInbox); allowed {
+			p.deliverInbox(req)
+		} else {
+			log.WithContext(req.GetContext()).Debugf("[Pipeline] Inbox of %s skipped: %s", req.GetSubscriberID(), reason)
+		}
+	}
+
+	if req.Fallback != nil {
+		return p.walkFallback(req, prefs)
+	}
+
+	return p.deliver(req, prefs)
+}
+
+// deliver send notification through its channel unless subscriber opted out or frequency cap reached
+func (p *Pipeline) deliver(req *notifierDtos.NotifierPayloadDto, prefs *models.PreferencesModel) *Result {
+	channel := req.Type
+	if allowed, reason := p.allows(prefs, req, channel); !allowed {
+		return p.record(req, &Result{
+			Status:  models.StatusSkipped,
+			Channel: channel,
+			Reason:  reason,
+		})
+	}
+
+	// notifications with digest key sent later as one message (sent right away if cannot be collected)
+	if req.DigestKey != "" && req.Fallback == nil {
+		if res, err := p.collectDigest(req); err == nil {
+			return res
+		}
+	}
+
+	reservation, violated := p.frequencyLimiter.Reserve(req.GetContext(), recipientOf(req), channel, req.Category)
+	if reservation == nil {
+		return p.record(req, &Result{
+			Status:  models.StatusRateLimited,
+			Channel: channel,
+			Reason:  violated,
+		})
+	}
+
+	if err := p.send(req); err != nil {
+		reservation.Release(req.GetContext())
+
+		if errors.Is(err, errNoRecipient) {
+			return p.record(req, &Result{
+				Status:  models.StatusSkipped,
+				Channel: channel,
+				Reason:  models.ReasonNoRecipient,
+			})
+		}
+
+		req.Error = err
+		return p.record(req, &Result{
+			Status:  models.StatusFailed,
+			Channel: channel,
+			Error:   err,
+		})
+	}
+
+	metrics.Delivered(channel, req.TimeReqStart)
+
+	return p.record(req, &Result{
+		Status:  models.StatusSent,
+		Channel: channel,
+	})
+}
+
+// startProcess start span of notification processing, request carries it to pipeline stages
+func startProcess(req *notifierDtos.NotifierPayloadDto) trace.Span {
+	ctx, span := tracing.Tracer().Start(req.GetContext(), "pipeline process", trace.WithAttributes(
+		attribute.String("notification.id", req.NotifID),
+		attribute.String("notification.type", req.Type),
+		attribute.String("notification.category", req.Category),
+	))
+	req.Ctx = ctx
+
+	return span
+}
+
+func endProcess(span trace.Span, res *Result) {
+	span.SetAttributes(
+		attribute.String("notification.status", res.Status),
+		attribute.String("notification.channel", res.Channel),
+		attribute.String("notification.reason", res.Reason),
+		attribute.Bool("notification.duplicate", res.Duplicate),
+	)
+	tracing.End(span, res.Error)
+}
+
+// record store status of notification
+func (p *Pipeline) record(req *notifierDtos.NotifierPayloadDto, res *Result) *Result {
+	res.NotifID = req.NotifID
+
+	status := &models.NotificationStatusModel{
+		NotifID:  req.NotifID,
+		SubID:    req.GetSubscriberID(),
+		Category: req.Category,
+		Status:   res.Status,
+		Channel:  res.Channel,
+		Reason:   res.Reason,
+	}
+	if res.Error != nil {
+		status.Error = res.Error.Error()
+	}
+
+	if err := p.statusesRepo.Save(req.GetContext(), status); err != nil {
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed save status of: ", req.NotifID, err)
+	}
+
+	p.emitRecorded(req, res)
+
+	return res
+}
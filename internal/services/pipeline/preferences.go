@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// findPreferences returns subscriber preferences, nil means everything allowed
+func (p *Pipeline) findPreferences(req *notifierDtos.NotifierPayloadDto) *models.PreferencesModel {
+	// transactional messages (OTP, password reset, etc.) must be delivered anyway
+	if req.BypassPreferences || req.GetSubscriberID() == "" {
+		return nil
+	}
+
+	prefs, err := p.preferencesRepo.Find(req.GetContext(), req.GetSubscriberID())
+	if err != nil {
+		// better deliver than silently lose notification
+		log.WithContext(req.GetContext()).Error("[Pipeline] Failed find preferences of: ", req.GetSubscriberID(), err)
+		return nil
+	}
+
+	return prefs
+}
+
+func (p *Pipeline) allows(prefs *models.PreferencesModel, req *notifierDtos.NotifierPayloadDto, channel string) (bool, string) {
+	if prefs == nil {
+		return true, ""
+	}
+
+	return prefs.Allows(req.Category, channel)
+}
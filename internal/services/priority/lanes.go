@@ -0,0 +1,191 @@
+package priority
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	log "github.com/sirupsen/logrus"
+)
+
+// pausePoll how often paused lane checks whether it could go on
+const pausePoll = 200 * time.Millisecond
+
+var (
+	ErrUnknownLane = errors.New("[PriorityLanes] Unknown lane")
+	// ErrLaneNotPausable returned on pause of the highest lane: urgent notifications (e.g. OTP) must not
+	// wait for operator, and its workers would hang in Acquire till resumed
+	ErrLaneNotPausable = errors.New("[PriorityLanes] The highest lane could not be paused")
+)
+
+// Stats of priority lane
+type Stats struct {
+	Workers  int   `json:"workers"`
+	Busy     int64 `json:"busy"`
+	Consumed int64 `json:"consumed"`
+	Pausable bool  `json:"pausable"`
+	// Highest lane could not be paused by operator
+	Highest bool `json:"highest"`
+	// Paused lane paused by operator, Yielding pausable lane waits while higher lanes are loaded
+	Paused   bool `json:"paused"`
+	Yielding bool `json:"yielding"`
+}
+
+type lane struct {
+	config   *configs.PriorityLane
+	busy     int64
+	consumed int64
+	paused   int32
+}
+
+// Lanes selects priority lane of notification and shares workers between lanes: every lane has own
+// workers, so higher lanes always have capacity, and pausable lanes wait while higher lanes are loaded
+type Lanes struct {
+	config *configs.PriorityConfig
+	lanes  []*lane
+	byName map[string]*lane
+}
+
+func NewLanes(config *configs.PriorityConfig) *Lanes {
+	l := &Lanes{
+		config: config,
+		byName: make(map[string]*lane, len(config.Lanes)),
+	}
+
+	for _, cfg := range config.Lanes {
+		ln := &lane{config: cfg}
+		l.lanes = append(l.lanes, ln)
+		l.byName[cfg.Name] = ln
+	}
+
+	return l
+}
+
+// RoutingKey routing key lane queue bound with, producers could publish to lane directly
+func RoutingKey(name string) string {
+	return "notifier.lane." + name
+}
+
+// Enabled notifications are consumed through priority lanes
+func (l *Lanes) Enabled() bool {
+	return len(l.lanes) > 0
+}
+
+// Select returns lane named by priority of notification, lane of its category or default lane
+func (l *Lanes) Select(priority, category string) string {
+	if _, ok := l.byName[priority]; ok {
+		return priority
+	}
+
+	if name, ok := l.config.Categories[category]; ok {
+		return name
+	}
+
+	return l.config.DefaultLane
+}
+
+// Acquire wait while lane is paused and take its worker, returned func releases worker. It returns false
+// if stop is closed while waiting
+func (l *Lanes) Acquire(name string, stop <-chan struct{}) (func(), bool) {
+	ln, ok := l.byName[name]
+	if !ok {
+		return func() {}, true
+	}
+
+	logged := false
+	for l.isPaused(ln) {
+		if !logged {
+			log.Debugf("[PriorityLanes] Lane %s paused", name)
+			logged = true
+		}
+
+		select {
+		case <-stop:
+			return nil, false
+		case <-time.After(pausePoll):
+		}
+	}
+
+	atomic.AddInt64(&ln.busy, 1)
+	atomic.AddInt64(&ln.consumed, 1)
+
+	return func() {
+		atomic.AddInt64(&ln.busy, -1)
+	}, true
+}
+
+// Pause stop lane till Resume: its workers wait in Acquire and notifications stay in lane queue. The highest
+// lane is never paused
+func (l *Lanes) Pause(name string) error {
+	ln, ok := l.byName[name]
+	if !ok {
+		return ErrUnknownLane
+	}
+
+	if ln == l.lanes[0] {
+		return ErrLaneNotPausable
+	}
+
+	atomic.StoreInt32(&ln.paused, 1)
+	log.Infof("[PriorityLanes] Lane %s paused", name)
+
+	return nil
+}
+
+// Resume lane paused by Pause
+func (l *Lanes) Resume(name string) error {
+	ln, ok := l.byName[name]
+	if !ok {
+		return ErrUnknownLane
+	}
+
+	atomic.StoreInt32(&ln.paused, 0)
+	log.Infof("[PriorityLanes] Lane %s resumed", name)
+
+	return nil
+}
+
+// Stats returns stats of lanes by name
+func (l *Lanes) Stats() map[string]*Stats {
+	stats := make(map[string]*Stats, len(l.lanes))
+	for _, ln := range l.lanes {
+		stats[ln.config.Name] = &Stats{
+			Workers:  ln.config.Workers,
+			Busy:     atomic.LoadInt64(&ln.busy),
+			Consumed: atomic.LoadInt64(&ln.consumed),
+			Pausable: ln.config.Pausable,
+			Highest:  ln == l.lanes[0],
+			Paused:   atomic.LoadInt32(&ln.paused) == 1,
+			Yielding: l.isYielding(ln),
+		}
+	}
+
+	return stats
+}
+
+func (l *Lanes) isPaused(ln *lane) bool {
+	return atomic.LoadInt32(&ln.paused) == 1 || l.isYielding(ln)
+}
+
+// isYielding pausable lane yields while workers of higher lanes are busy above threshold
+func (l *Lanes) isYielding(ln *lane) bool {
+	if !ln.config.Pausable {
+		return false
+	}
+
+	var busy, workers int64
+	for _, higher := range l.lanes {
+		if higher == ln {
+			break
+		}
+		if higher.config.Pausable {
+			continue
+		}
+
+		busy += atomic.LoadInt64(&higher.busy)
+		workers += int64(higher.config.Workers)
+	}
+
+	return workers > 0 && float64(busy) >= l.config.PauseThreshold*float64(workers)
+}
@@ -0,0 +1,42 @@
+package priority
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+)
+
+func TestLanesPause(t *testing.T) {
+	lanes := NewLanes(&configs.PriorityConfig{
+		Lanes: []*configs.PriorityLane{
+			{Name: "high", Workers: 8},
+			{Name: "normal", Workers: 4},
+			{Name: "low", Workers: 2, Pausable: true},
+		},
+	})
+
+	tests := []struct {
+		name string
+		lane string
+		err  error
+	}{
+		{name: "the highest lane", lane: "high", err: ErrLaneNotPausable},
+		{name: "lower lane", lane: "normal"},
+		{name: "pausable lane", lane: "low"},
+		{name: "unknown lane", lane: "urgent", err: ErrUnknownLane},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := lanes.Pause(tt.lane); !errors.Is(err, tt.err) {
+				t.Fatalf("Pause(%q) = %v, want %v", tt.lane, err, tt.err)
+			}
+
+			stats, ok := lanes.Stats()[tt.lane]
+			if ok && stats.Paused != (tt.err == nil) {
+				t.Fatalf("paused = %v, want %v", stats.Paused, tt.err == nil)
+			}
+		})
+	}
+}
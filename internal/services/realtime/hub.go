@@ -0,0 +1,282 @@
+package realtime
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/wagslane/go-rabbitmq"
+)
+
+// Event is a notification delivered to subscriber live connections
+type Event struct {
+	// ID of inbox item if notification stored to inbox
+	ID        string      `json:"id,omitempty"`
+	SubID     string      `json:"sub_id"`
+	NotifID   string      `json:"notif_id,omitempty"`
+	Title     string      `json:"title,omitempty"`
+	Body      string      `json:"body,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Category  string      `json:"category,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Subscription is a live connection of subscriber
+type Subscription struct {
+	C     <-chan *Event
+	c     chan *Event
+	subID string
+	hub   *Hub
+	once  sync.Once
+}
+
+// Close unsubscribe connection from hub
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.unsubscribe(s)
+	})
+}
+
+// Hub fan out events to live connections. Events published through fanout exchange,
+// so connections held by other instances receive them too
+type Hub struct {
+	config *configs.RealtimeConfig
+
+	mu   sync.RWMutex
+	subs map[string]map[*Subscription]struct{}
+
+	publisher *rabbitmq.Publisher
+	consumer  *rabbitmq.Consumer
+}
+
+func NewHub(config *configs.RealtimeConfig) *Hub {
+	return &Hub{
+		config: config,
+		subs:   make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Start declare fanout exchange and own instance queue. Until started, events delivered only to local connections
+func (h *Hub) Start(conn *rabbitmq.Conn) error {
+	publisher, err := rabbitmq.NewPublisher(
+		conn,
+		rabbitmq.WithPublisherOptionsExchangeName(h.config.Exchange),
+		rabbitmq.WithPublisherOptionsExchangeKind("fanout"),
+		rabbitmq.WithPublisherOptionsExchangeDurable,
+		rabbitmq.WithPublisherOptionsExchangeDeclare,
+	)
+	if err != nil {
+		return fmt.Errorf("[RealtimeHub] Failed create publisher: %w", err)
+	}
+
+	consumer, err := rabbitmq.NewConsumer(
+		conn,
+		h.handle,
+		instanceQueueName(h.config.Exchange),
+		rabbitmq.WithConsumerOptionsRoutingKey(""),
+		rabbitmq.WithConsumerOptionsExchangeName(h.config.Exchange),
+		rabbitmq.WithConsumerOptionsExchangeKind("fanout"),
+		rabbitmq.WithConsumerOptionsExchangeDurable,
+		rabbitmq.WithConsumerOptionsExchangeDeclare,
+		rabbitmq.WithConsumerOptionsQueueAutoDelete,
+		rabbitmq.WithConsumerOptionsQueueExclusive,
+	)
+	if err != nil {
+		publisher.Close()
+		return fmt.Errorf("[RealtimeHub] Failed create consumer: %w", err)
+	}
+
+	h.mu.Lock()
+	h.publisher = publisher
+	h.consumer = consumer
+	h.mu.Unlock()
+
+	return nil
+}
+
+// Close stop cross-instance delivery and close all live subscriptions
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.consumer != nil {
+		h.consumer.Close()
+		h.consumer = nil
+	}
+
+	if h.publisher != nil {
+		h.publisher.Close()
+		h.publisher = nil
+	}
+
+	for subID, subs := range h.subs {
+		for s := range subs {
+			close(s.c)
+		}
+		delete(h.subs, subID)
+	}
+}
+
+// Subscribe register new live connection of subscriber
+func (h *Hub) Subscribe(subID string) *Subscription {
+	c := make(chan *Event, h.config.BufferSize)
+	s := &Subscription{
+		C:     c,
+		c:     c,
+		subID: subID,
+		hub:   h,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[subID]; !ok {
+		h.subs[subID] = make(map[*Subscription]struct{})
+	}
+	h.subs[subID][s] = struct{}{}
+
+	return s
+}
+
+func (h *Hub) unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.subs[s.subID]
+	if !ok {
+		return
+	}
+
+	if _, ok := subs[s]; !ok {
+		return
+	}
+
+	delete(subs, s)
+	close(s.c)
+
+	if len(subs) == 0 {
+		delete(h.subs, s.subID)
+	}
+}
+
+// Publish send event to every instance (or to local connections only if hub not started)
+func (h *Hub) Publish(e *Event) error {
+	h.mu.RLock()
+	publisher := h.publisher
+	h.mu.RUnlock()
+
+	if publisher == nil {
+		h.deliver(e)
+		return nil
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return publisher.PublishWithContext(
+		context.Background(),
+		body,
+		[]string{""},
+		rabbitmq.WithPublishOptionsContentType("application/json"),
+		rabbitmq.WithPublishOptionsExchange(h.config.Exchange),
+	)
+}
+
+// Authorize check client token grants access to subscriber events
+func (h *Hub) Authorize(token, subID string) error {
+	if h.config.JWTSecret == "" && h.config.AllowAnonymous {
+		if subID == "" {
+			return errors.New("[RealtimeHub] Subscriber ID is empty")
+		}
+		return nil
+	}
+
+	return VerifyToken(h.config.JWTSecret, token, subID)
+}
+
+// VerifyToken check HS256 token is signed by secret, not expired (exp claim is required) and issued for
+// subscriber. Always fails if secret is empty
+func VerifyToken(secret, token, subID string) error {
+	if subID == "" {
+		return errors.New("[RealtimeHub] Subscriber ID is empty")
+	}
+
+	if secret == "" {
+		return errors.New("[RealtimeHub] Authorization is not configured")
+	}
+
+	claims := jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return fmt.Errorf("[RealtimeHub] Invalid token: %w", err)
+	}
+
+	// token without expiration would be valid forever
+	if !claims.VerifyExpiresAt(time.Now(), true) {
+		return errors.New("[RealtimeHub] Token has no expiration")
+	}
+
+	if claims.Subject != subID {
+		return errors.New("[RealtimeHub] Token issued for another subscriber")
+	}
+
+	return nil
+}
+
+// PingInterval how often keep-alive sent to live connections
+func (h *Hub) PingInterval() time.Duration {
+	return h.config.PingInterval
+}
+
+func (h *Hub) handle(d rabbitmq.Delivery) rabbitmq.Action {
+	e := &Event{}
+	if err := json.Unmarshal(d.Body, e); err != nil {
+		log.Error("[RealtimeHub] Cannot parse event: ", err)
+		return rabbitmq.Ack
+	}
+
+	h.deliver(e)
+
+	return rabbitmq.Ack
+}
+
+func (h *Hub) deliver(e *Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for s := range h.subs[e.SubID] {
+		select {
+		case s.c <- e:
+		default:
+			log.Warn("[RealtimeHub] Slow connection, event dropped for: ", e.SubID)
+		}
+	}
+}
+
+func instanceQueueName(exchange string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	return fmt.Sprintf("%s.%s.%s", exchange, host, hex.EncodeToString(suffix))
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/robfig/cron/v3"
+)
+
+// standard 5 fields cron expressions and descriptors (@daily, @every 1h, etc.)
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// NextRun returns first occurrence of schedule after passed time, nil if schedule has ended
+func NextRun(m *models.RecurringScheduleModel, after time.Time) (*time.Time, error) {
+	schedule, err := cronParser.Parse(m.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(m.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	// start date is inclusive
+	if m.StartAt != nil && !m.StartAt.Before(after) {
+		after = m.StartAt.Add(-time.Nanosecond)
+	}
+
+	next := schedule.Next(after.In(loc))
+	if next.IsZero() || (m.EndAt != nil && next.After(*m.EndAt)) {
+		return nil, nil
+	}
+
+	return &next, nil
+}
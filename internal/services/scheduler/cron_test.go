@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/models"
+)
+
+func TestNextRun(t *testing.T) {
+	at := func(s string) *time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("parse %q: %v", s, err)
+		}
+		return &v
+	}
+
+	tests := []struct {
+		name     string
+		schedule models.RecurringScheduleModel
+		after    string
+		want     *time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "daily in UTC",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *"},
+			after:    "2024-03-06T10:00:00Z",
+			want:     at("2024-03-07T09:00:00Z"),
+		},
+		{
+			name:     "exact occurrence is not repeated",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *"},
+			after:    "2024-03-06T09:00:00Z",
+			want:     at("2024-03-07T09:00:00Z"),
+		},
+		{
+			name:     "descriptor",
+			schedule: models.RecurringScheduleModel{Cron: "@every 90m"},
+			after:    "2024-03-06T10:00:00Z",
+			want:     at("2024-03-06T11:30:00Z"),
+		},
+		{
+			name:     "evaluated in timezone",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *", Timezone: "Europe/Moscow"},
+			after:    "2024-03-06T07:00:00Z",
+			want:     at("2024-03-07T06:00:00Z"),
+		},
+		{
+			name:     "daylight saving shift",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *", Timezone: "America/New_York"},
+			// clocks moved forward on 2024-03-10
+			after: "2024-03-09T15:00:00Z",
+			want:  at("2024-03-10T13:00:00Z"),
+		},
+		{
+			name:     "start date inclusive",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *", StartAt: at("2024-04-01T09:00:00Z")},
+			after:    "2024-03-06T10:00:00Z",
+			want:     at("2024-04-01T09:00:00Z"),
+		},
+		{
+			name:     "start date passed",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *", StartAt: at("2024-01-01T00:00:00Z")},
+			after:    "2024-03-06T10:00:00Z",
+			want:     at("2024-03-07T09:00:00Z"),
+		},
+		{
+			name:     "end date inclusive",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *", EndAt: at("2024-03-07T09:00:00Z")},
+			after:    "2024-03-06T10:00:00Z",
+			want:     at("2024-03-07T09:00:00Z"),
+		},
+		{
+			name:     "ended",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *", EndAt: at("2024-03-07T08:59:00Z")},
+			after:    "2024-03-06T10:00:00Z",
+		},
+		{
+			name:     "malformed expression",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * *"},
+			after:    "2024-03-06T10:00:00Z",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown timezone",
+			schedule: models.RecurringScheduleModel{Cron: "0 9 * * *", Timezone: "Mars/Olympus"},
+			after:    "2024-03-06T10:00:00Z",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextRun(&tt.schedule, *at(tt.after))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+
+			switch {
+			case tt.want == nil && got != nil:
+				t.Fatalf("next = %v, want none", got)
+			case tt.want != nil && (got == nil || !got.Equal(*tt.want)):
+				t.Fatalf("next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	log "github.com/sirupsen/logrus"
+)
+
+// DigestFlusher send digests which window is over. Digests are stored, so collected
+// notifications survive restarts; every instance could run it
+type DigestFlusher struct {
+	config      *configs.SchedulerConfig
+	digestsRepo mongo.IDigestsRepository
+	pipeline    *pipeline.Pipeline
+	// lockTimeout digest claimed for, longer than idempotency lock of its notification
+	lockTimeout time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func NewDigestFlusher(
+	config *configs.SchedulerConfig,
+	idempotencyConfig *configs.IdempotencyConfig,
+	digestsRepo mongo.IDigestsRepository,
+	pipeline *pipeline.Pipeline,
+) *DigestFlusher {
+	// digest must not be claimed again while its notification is locked by idempotency key, otherwise
+	// flusher gets "processing" duplicate instead of sending it
+	lockTimeout := config.LockTimeout
+	if lockTimeout <= idempotencyConfig.LockTimeout {
+		lockTimeout = idempotencyConfig.LockTimeout + config.PollInterval
+	}
+
+	return &DigestFlusher{
+		config:      config,
+		digestsRepo: digestsRepo,
+		pipeline:    pipeline,
+		lockTimeout: lockTimeout,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start poll store in background until closed
+func (f *DigestFlusher) Start() {
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+
+		ticker := time.NewTicker(f.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			f.flushDue()
+
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stop polling and wait for digest in progress
+func (f *DigestFlusher) Close() {
+	f.once.Do(func() {
+		close(f.stop)
+	})
+	f.wg.Wait()
+}
+
+func (f *DigestFlusher) flushDue() {
+	for i := 0; i < f.config.BatchSize; i++ {
+		select {
+		case <-f.stop:
+			return
+		default:
+		}
+
+		digest, err := f.digestsRepo.ClaimDue(context.Background(), time.Now(), f.lockTimeout)
+		if err != nil {
+			log.Error("[DigestFlusher] Failed claim due digest: ", err)
+			return
+		}
+
+		if digest == nil {
+			return
+		}
+
+		f.flush(digest)
+	}
+}
+
+func (f *DigestFlusher) flush(digest *models.DigestModel) {
+	res := f.pipeline.FlushDigest(digest)
+	log.Debugf("[DigestFlusher] digest %s (%s) of %s %s: %s", res.NotifID, digest.Key, digest.SubID, res.Status, res.Reason)
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+)
+
+func TestDigestFlusherLockTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		lock        time.Duration
+		idempotency time.Duration
+		want        time.Duration
+	}{
+		{name: "longer than idempotency lock", lock: 10 * time.Minute, idempotency: 5 * time.Minute, want: 10 * time.Minute},
+		{name: "equal to idempotency lock", lock: 5 * time.Minute, idempotency: 5 * time.Minute, want: 5*time.Minute + 10*time.Second},
+		{name: "shorter than idempotency lock", lock: time.Minute, idempotency: 5 * time.Minute, want: 5*time.Minute + 10*time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewDigestFlusher(
+				&configs.SchedulerConfig{LockTimeout: tt.lock, PollInterval: 10 * time.Second},
+				&configs.IdempotencyConfig{LockTimeout: tt.idempotency},
+				nil,
+				nil,
+			)
+
+			if f.lockTimeout != tt.want {
+				t.Fatalf("lock timeout = %s, want %s", f.lockTimeout, tt.want)
+			}
+		})
+	}
+}
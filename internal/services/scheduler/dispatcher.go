@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	log "github.com/sirupsen/logrus"
+)
+
+// Dispatcher release stored notifications to send pipeline when they are due.
+// Every instance could run it: notification claimed by one instance at a time
+type Dispatcher struct {
+	config       *configs.SchedulerConfig
+	scheduleRepo mongo.IScheduleRepository
+	pipeline     *pipeline.Pipeline
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func NewDispatcher(
+	config *configs.SchedulerConfig,
+	scheduleRepo mongo.IScheduleRepository,
+	pipeline *pipeline.Pipeline,
+) *Dispatcher {
+	return &Dispatcher{
+		config:       config,
+		scheduleRepo: scheduleRepo,
+		pipeline:     pipeline,
+		stop:         make(chan struct{}),
+	}
+}
+
+// Start poll store in background until closed
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			d.dispatchDue()
+
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stop polling and wait for notification in progress
+func (d *Dispatcher) Close() {
+	d.once.Do(func() {
+		close(d.stop)
+	})
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) dispatchDue() {
+	for i := 0; i < d.config.BatchSize; i++ {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		item, err := d.scheduleRepo.ClaimDue(context.Background(), time.Now(), d.config.LockTimeout)
+		if err != nil {
+			log.Error("[Dispatcher] Failed claim due notification: ", err)
+			return
+		}
+
+		if item == nil {
+			return
+		}
+
+		d.dispatch(item)
+	}
+}
+
+// dispatch hand notification to pipeline. It is removed from store only after it is processed, failed
+// one is dispatched again with growing delay till SchedulerConfig.MaxAttempts
+func (d *Dispatcher) dispatch(item *models.ScheduledNotificationModel) {
+	req := notifierDtos.NotifierPayloadDto{
+		TimeReqStart: time.Now(),
+	}
+
+	if err := json.Unmarshal([]byte(item.Payload), &req); err != nil {
+		// payload never becomes valid, so retry is pointless
+		log.Error("[Dispatcher] Cannot parse stored notification, dropping it: ", item.NotifID, err)
+		d.delete(item)
+		return
+	}
+
+	// stored time is authoritative, it could be changed by reschedule
+	req.SendAt = nil
+
+	res := d.pipeline.ProcessDue(&req)
+	log.Debugf("[Dispatcher] notification %s (%s) %s: %s", res.NotifID, item.Reason, res.Status, res.Reason)
+
+	if !res.IsFailed() {
+		d.delete(item)
+		return
+	}
+
+	if item.Attempts >= d.config.MaxAttempts {
+		log.Errorf("[Dispatcher] Notification %s failed %d times, dropping it: %v", item.NotifID, item.Attempts, res.Error)
+		d.delete(item)
+		return
+	}
+
+	sendAt := time.Now().Add(time.Duration(item.Attempts) * d.config.RetryBackoff)
+	if err := d.scheduleRepo.Retry(context.Background(), item.ID, sendAt); err != nil {
+		// lock expires, so notification is dispatched again anyway
+		log.Error("[Dispatcher] Failed postpone failed notification: ", item.NotifID, err)
+	}
+}
+
+func (d *Dispatcher) delete(item *models.ScheduledNotificationModel) {
+	if err := d.scheduleRepo.DeleteClaimed(context.Background(), item); err != nil {
+		log.Error("[Dispatcher] Failed remove dispatched notification: ", item.NotifID, err)
+	}
+}
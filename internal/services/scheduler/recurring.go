@@ -0,0 +1,256 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	notifierDtos "github.com/WildEgor/gNotifier/internal/dtos/notifier"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	log "github.com/sirupsen/logrus"
+)
+
+// RecurringScheduler materialize occurrences of recurring schedules into sends.
+// Every instance could run it: occurrence fired only by instance which claimed it
+type RecurringScheduler struct {
+	config        *configs.SchedulerConfig
+	recurringRepo mongo.IRecurringRepository
+	tokensRepo    mongo.ITokensRepository
+	pipeline      *pipeline.Pipeline
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+func NewRecurringScheduler(
+	config *configs.SchedulerConfig,
+	recurringRepo mongo.IRecurringRepository,
+	tokensRepo mongo.ITokensRepository,
+	pipeline *pipeline.Pipeline,
+) *RecurringScheduler {
+	return &RecurringScheduler{
+		config:        config,
+		recurringRepo: recurringRepo,
+		tokensRepo:    tokensRepo,
+		pipeline:      pipeline,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Start poll schedules in background until closed
+func (s *RecurringScheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			s.fireDue()
+
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stop polling and wait for occurrence in progress
+func (s *RecurringScheduler) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	s.wg.Wait()
+}
+
+func (s *RecurringScheduler) fireDue() {
+	now := time.Now()
+
+	items, err := s.recurringRepo.FindDue(context.Background(), now, int64(s.config.BatchSize))
+	if err != nil {
+		log.Error("[RecurringScheduler] Failed find due schedules: ", err)
+		return
+	}
+
+	for _, item := range items {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		claimed, err := s.recurringRepo.Claim(context.Background(), item, now, s.config.LockTimeout)
+		if err != nil {
+			log.Error("[RecurringScheduler] Failed claim schedule: ", item.ID.Hex(), err)
+			continue
+		}
+
+		// fired by another instance
+		if !claimed {
+			continue
+		}
+
+		// audience could be large, so occurrences are fired in background not to hold other schedules
+		s.wg.Add(1)
+		go func(item *models.RecurringScheduleModel) {
+			defer s.wg.Done()
+			s.run(item, now)
+		}(item)
+	}
+}
+
+// run fire claimed occurrence and only then advance schedule. Claim is extended while audience is fired,
+// so large audience is not fired by another instance meanwhile. Occurrence interrupted by Close, failure or
+// lost claim is fired again when claim expires, subscribers already sent to are skipped as duplicates by stable IDs
+func (s *RecurringScheduler) run(item *models.RecurringScheduleModel, now time.Time) {
+	occurrence := *item.NextRunAt
+
+	// missed occurrences (e.g. service was down) fired once
+	next, err := NextRun(item, now)
+	if err != nil {
+		log.Error("[RecurringScheduler] Broken schedule, completing it: ", item.ID.Hex(), err)
+		next = nil
+	}
+
+	lost := make(chan struct{})
+	stopHeartbeat := s.heartbeat(item, now.Add(s.config.LockTimeout), lost)
+	fired := s.fire(item, occurrence, lost)
+	stopHeartbeat()
+
+	if !fired {
+		return
+	}
+
+	advanced, err := s.recurringRepo.Advance(context.Background(), item, next, now)
+	if err != nil {
+		log.Error("[RecurringScheduler] Failed advance schedule: ", item.ID.Hex(), err)
+		return
+	}
+
+	// changed meanwhile, e.g. updated or paused
+	if !advanced {
+		log.Debugf("[RecurringScheduler] Schedule %s changed while firing, not advanced", item.ID.Hex())
+	}
+}
+
+// heartbeat extend claim of occurrence every third of lock timeout till returned func called. lost is closed
+// if claim could not be extended because another instance took it over or schedule was changed
+func (s *RecurringScheduler) heartbeat(item *models.RecurringScheduleModel, lockedUntil time.Time, lost chan struct{}) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(s.config.LockTimeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			until := time.Now().Add(s.config.LockTimeout)
+			extended, err := s.recurringRepo.Extend(context.Background(), item, lockedUntil, until)
+			if err != nil {
+				// claim is still held till lockedUntil, extended on next tick
+				log.Error("[RecurringScheduler] Failed extend claim of schedule: ", item.ID.Hex(), err)
+				continue
+			}
+
+			if !extended {
+				log.Warnf("[RecurringScheduler] Claim of schedule %s is lost, firing stopped", item.ID.Hex())
+				close(lost)
+				return
+			}
+
+			lockedUntil = until
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// fire send occurrence to every subscriber of audience. It returns false if occurrence was not sent to
+// whole audience, because scheduler is closed, claim is lost or audience could not be iterated
+func (s *RecurringScheduler) fire(item *models.RecurringScheduleModel, occurrence time.Time, lost <-chan struct{}) bool {
+	log.Debugf("[RecurringScheduler] Firing %s (%s) at %s", item.Name, item.ID.Hex(), occurrence)
+
+	send := func(subID string) bool {
+		select {
+		case <-s.stop:
+			return false
+		case <-lost:
+			return false
+		default:
+		}
+
+		req := notifierDtos.NotifierPayloadDto{
+			TimeReqStart: time.Now(),
+		}
+		if err := json.Unmarshal([]byte(item.Payload), &req); err != nil {
+			log.Error("[RecurringScheduler] Cannot parse notification of schedule: ", item.ID.Hex(), err)
+			return true
+		}
+
+		// occurrence of subscriber has stable ID, so it could be deduplicated downstream
+		req.NotifID = fmt.Sprintf("%s-%d-%s", item.ID.Hex(), occurrence.Unix(), subID)
+		req.SubscriberID = subID
+		if req.IsPush() && req.PushSetting.To == "" {
+			req.PushSetting.To = subID
+		}
+
+		if item.Template != "" {
+			req.EmailSetting.Template = item.Template
+			req.PushSetting.Template = item.Template
+		}
+
+		res := s.pipeline.Process(&req)
+		if res.IsFailed() {
+			log.Error("[RecurringScheduler] Failed send occurrence to: ", subID, res.Error)
+		}
+
+		return true
+	}
+
+	if len(item.Audience.SubIDs) > 0 {
+		for _, subID := range item.Audience.SubIDs {
+			if !send(subID) {
+				return false
+			}
+		}
+		return true
+	}
+
+	platform := item.Audience.Segment
+	if platform == models.SegmentAll {
+		platform = ""
+	}
+
+	completed := true
+	err := s.tokensRepo.EachSubID(context.Background(), platform, func(subID string) bool {
+		completed = send(subID)
+		return completed
+	})
+	if err != nil {
+		log.Error("[RecurringScheduler] Failed iterate audience of schedule: ", item.ID.Hex(), err)
+		return false
+	}
+
+	return completed
+}
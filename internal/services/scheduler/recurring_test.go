@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/models"
+	"github.com/WildEgor/gNotifier/internal/repository/mongo"
+)
+
+// claimRepository holds claim of single schedule, it is taken over after takeOverAfter extensions
+type claimRepository struct {
+	mongo.IRecurringRepository
+
+	mu            sync.Mutex
+	lockedUntil   time.Time
+	extensions    int
+	takeOverAfter int
+}
+
+func (r *claimRepository) Extend(_ context.Context, _ *models.RecurringScheduleModel, lockedUntil, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !lockedUntil.Equal(r.lockedUntil) || (r.takeOverAfter > 0 && r.extensions >= r.takeOverAfter) {
+		return false, nil
+	}
+
+	r.lockedUntil = until
+	r.extensions++
+
+	return true, nil
+}
+
+func TestRecurringSchedulerHeartbeat(t *testing.T) {
+	tests := []struct {
+		name          string
+		takeOverAfter int
+		lost          bool
+	}{
+		{name: "claim extended while firing", lost: false},
+		{name: "claim taken over", takeOverAfter: 2, lost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lockedUntil := time.Now().Add(30 * time.Millisecond)
+			repo := &claimRepository{lockedUntil: lockedUntil, takeOverAfter: tt.takeOverAfter}
+			s := NewRecurringScheduler(&configs.SchedulerConfig{LockTimeout: 30 * time.Millisecond}, repo, nil, nil)
+
+			lost := make(chan struct{})
+			stop := s.heartbeat(&models.RecurringScheduleModel{}, lockedUntil, lost)
+
+			select {
+			case <-lost:
+			case <-time.After(200 * time.Millisecond):
+			}
+			stop()
+
+			select {
+			case <-lost:
+				if !tt.lost {
+					t.Fatal("claim is lost")
+				}
+			default:
+				if tt.lost {
+					t.Fatal("claim is not lost")
+				}
+			}
+
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			if repo.extensions < 2 {
+				t.Fatalf("claim extended %d times, want at least 2", repo.extensions)
+			}
+			if !tt.lost && !repo.lockedUntil.After(time.Now()) {
+				t.Fatalf("claim expired at %s", repo.lockedUntil)
+			}
+		})
+	}
+}
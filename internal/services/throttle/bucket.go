@@ -0,0 +1,120 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// share of configured rate restored after every successful request
+const recoveryStep = 0.05
+
+// bucket is a token bucket with adaptive rate: halved when provider throttles us and slowly restored on success
+type bucket struct {
+	mu sync.Mutex
+
+	limit   float64
+	minRate float64
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	// pausedUntil provider asked not to send till this time
+	pausedUntil time.Time
+
+	stats Stats
+}
+
+func newBucket(limit float64, burst int, minRateFactor float64) *bucket {
+	return &bucket{
+		limit:   limit,
+		minRate: limit * minRateFactor,
+		rate:    limit,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// reserve take cost tokens and returns how long caller should wait before use them
+func (b *bucket) reserve(cost int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	var wait time.Duration
+	if b.pausedUntil.After(now) {
+		wait = b.pausedUntil.Sub(now)
+	}
+
+	// unlimited provider could be paused only
+	if b.limit > 0 {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+
+		b.tokens -= float64(cost)
+		if b.tokens < 0 {
+			wait += time.Duration(-b.tokens / b.rate * float64(time.Second))
+		}
+	}
+
+	b.stats.Requests++
+	if wait > 0 {
+		b.stats.Throttled++
+		b.stats.ThrottledSeconds += wait.Seconds()
+	}
+
+	return wait
+}
+
+// penalize pause bucket and slow it down after provider throttled us
+func (b *bucket) penalize(retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if until := now.Add(retryAfter); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+
+	if b.limit > 0 {
+		b.rate /= 2
+		if b.rate < b.minRate {
+			b.rate = b.minRate
+		}
+		b.tokens = 0
+		b.last = now
+	}
+
+	b.stats.RateLimited++
+}
+
+// recover restore bucket rate step by step after successful request
+func (b *bucket) recover() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limit == 0 || b.rate >= b.limit {
+		return
+	}
+
+	b.rate += b.limit * recoveryStep
+	if b.rate > b.limit {
+		b.rate = b.limit
+	}
+}
+
+func (b *bucket) snapshot() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stats
+	s.Limit = b.limit
+	s.Rate = b.rate
+	s.PausedUntil = b.pausedUntil
+
+	return s
+}
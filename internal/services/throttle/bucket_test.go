@@ -0,0 +1,122 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit float64
+		burst int
+		calls int
+		// cost tokens taken by every call
+		cost int
+		// wait of the last call within [min, max]
+		min, max time.Duration
+	}{
+		{name: "within burst", limit: 10, burst: 3, calls: 3, cost: 1, min: 0, max: 0},
+		{name: "over burst waits for token", limit: 10, burst: 2, calls: 3, cost: 1, min: 90 * time.Millisecond, max: 100 * time.Millisecond},
+		{name: "waits accumulate", limit: 10, burst: 1, calls: 4, cost: 1, min: 290 * time.Millisecond, max: 300 * time.Millisecond},
+		{name: "call charged per recipient", limit: 10, burst: 2, calls: 1, cost: 5, min: 290 * time.Millisecond, max: 300 * time.Millisecond},
+		{name: "recipients within burst", limit: 10, burst: 6, calls: 2, cost: 3, min: 0, max: 0},
+		{name: "unlimited never waits", limit: 0, burst: 0, calls: 100, cost: 1, min: 0, max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(tt.limit, tt.burst, 0.1)
+
+			var wait time.Duration
+			for i := 0; i < tt.calls; i++ {
+				wait = b.reserve(tt.cost)
+			}
+
+			if wait < tt.min || wait > tt.max {
+				t.Fatalf("wait = %v, want within [%v, %v]", wait, tt.min, tt.max)
+			}
+
+			stats := b.snapshot()
+			if stats.Requests != int64(tt.calls) {
+				t.Fatalf("requests = %d, want %d", stats.Requests, tt.calls)
+			}
+			if (tt.max > 0) != (stats.Throttled > 0) {
+				t.Fatalf("throttled = %d", stats.Throttled)
+			}
+		})
+	}
+}
+
+func TestBucketAdaptation(t *testing.T) {
+	const (
+		penalize = "penalize"
+		recover  = "recover"
+	)
+
+	tests := []struct {
+		name  string
+		limit float64
+		ops   []string
+		want  float64
+	}{
+		{name: "penalty halves rate", limit: 100, ops: []string{penalize}, want: 50},
+		{name: "penalties stack", limit: 100, ops: []string{penalize, penalize}, want: 25},
+		{name: "rate not below minimum", limit: 100, ops: []string{penalize, penalize, penalize, penalize, penalize}, want: 10},
+		{name: "recovery step", limit: 100, ops: []string{penalize, recover, recover}, want: 60},
+		{name: "recovery capped by limit", limit: 100, ops: []string{penalize, recover, recover, recover, recover, recover, recover, recover, recover, recover, recover, recover}, want: 100},
+		{name: "nothing to recover", limit: 100, ops: []string{recover}, want: 100},
+		{name: "unlimited rate unchanged", limit: 0, ops: []string{penalize, recover}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(tt.limit, 1, 0.1)
+
+			for _, op := range tt.ops {
+				switch op {
+				case penalize:
+					b.penalize(0)
+				case recover:
+					b.recover()
+				}
+			}
+
+			if got := b.snapshot().Rate; got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Fatalf("rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketPause(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit float64
+		// retry after values passed to penalize in order
+		pauses []time.Duration
+		min    time.Duration
+	}{
+		{name: "limited", limit: 10, pauses: []time.Duration{time.Second}, min: time.Second},
+		{name: "unlimited", limit: 0, pauses: []time.Duration{time.Second}, min: 900 * time.Millisecond},
+		{name: "shorter pause does not shorten longer one", limit: 0, pauses: []time.Duration{5 * time.Second, time.Second}, min: 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(tt.limit, 10, 0.1)
+
+			for _, pause := range tt.pauses {
+				b.penalize(pause)
+			}
+
+			if wait := b.reserve(1); wait < tt.min {
+				t.Fatalf("wait = %v, want at least %v", wait, tt.min)
+			}
+
+			if got := b.snapshot().RateLimited; got != int64(len(tt.pauses)) {
+				t.Fatalf("rate limited = %d, want %d", got, len(tt.pauses))
+			}
+		})
+	}
+}
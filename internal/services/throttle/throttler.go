@@ -0,0 +1,129 @@
+package throttle
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	"github.com/WildEgor/gNotifier/internal/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// Stats of provider throttling
+type Stats struct {
+	// Limit configured requests per second (0 - unlimited), Rate current one after adaptive slow-down
+	Limit float64 `json:"limit"`
+	Rate  float64 `json:"rate"`
+	// Requests passed through throttler, Throttled of them waited for token
+	Requests  int64 `json:"requests"`
+	Throttled int64 `json:"throttled"`
+	// ThrottledSeconds total time callers waited for provider
+	ThrottledSeconds float64 `json:"throttled_seconds"`
+	// RateLimited times provider throttled us
+	RateLimited int64     `json:"rate_limited"`
+	PausedUntil time.Time `json:"paused_until"`
+}
+
+// Throttler shape requests to providers by their token buckets. It blocks caller while provider saturated,
+// so AMQP consumer (limited by prefetch) stops taking new messages and backpressure reaches the broker
+type Throttler struct {
+	config *configs.ThrottleConfig
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewThrottler(config *configs.ThrottleConfig) *Throttler {
+	return &Throttler{
+		config:  config,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Do wait for provider tokens, one per recipient of call (provider quotas count messages, not requests),
+// call it and adapt provider rate to result
+func (t *Throttler) Do(provider string, recipients int, call func() error) error {
+	b := t.bucket(provider)
+
+	if recipients < 1 {
+		recipients = 1
+	}
+
+	if wait := b.reserve(recipients); wait > 0 {
+		log.Debugf("[Throttler] %s throttled for %s", provider, wait)
+		time.Sleep(wait)
+	}
+
+	err := call()
+
+	var rateLimited *domain.RateLimitError
+	if errors.As(err, &rateLimited) {
+		retryAfter := rateLimited.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = t.config.Penalty
+		}
+
+		log.Warnf("[Throttler] %s rate limited us, slowing down for %s", provider, retryAfter)
+		b.penalize(retryAfter)
+
+		return err
+	}
+
+	if err == nil {
+		b.recover()
+	}
+
+	return err
+}
+
+// Stats returns throttling stats per provider
+func (t *Throttler) Stats() map[string]Stats {
+	t.mu.Lock()
+	buckets := make(map[string]*bucket, len(t.buckets))
+	for provider, b := range t.buckets {
+		buckets[provider] = b
+	}
+	t.mu.Unlock()
+
+	stats := make(map[string]Stats, len(buckets))
+	for provider, b := range buckets {
+		stats[provider] = b.snapshot()
+	}
+
+	return stats
+}
+
+func (t *Throttler) bucket(provider string) *bucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.buckets[provider]
+	if !ok {
+		var limit float64
+		burst := 1
+		if l := t.limitOf(provider); l != nil {
+			limit, burst = l.Rate, l.Burst
+		}
+
+		b = newBucket(limit, burst, t.config.MinRateFactor)
+		t.buckets[provider] = b
+	}
+
+	return b
+}
+
+// limitOf returns limit of provider. Gateways of routed channels (channel:gateway) without own limit get
+// limit of their channel, every gateway has own bucket anyway
+func (t *Throttler) limitOf(provider string) *configs.ProviderLimit {
+	if l, ok := t.config.Limits[provider]; ok {
+		return l
+	}
+
+	if channel, _, ok := strings.Cut(provider, ":"); ok {
+		return t.config.Limits[channel]
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+)
+
+// AMQPCarrier adapts AMQP message headers to propagation.TextMapCarrier
+type AMQPCarrier map[string]interface{}
+
+func (c AMQPCarrier) Get(key string) string {
+	if value, ok := c[key].(string); ok {
+		return value
+	}
+
+	return ""
+}
+
+func (c AMQPCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c AMQPCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// ExtractAMQP returns context with remote span of producer passed in message headers (traceparent, tracestate)
+func ExtractAMQP(ctx context.Context, headers map[string]interface{}) context.Context {
+	if headers == nil {
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, AMQPCarrier(headers))
+}
+
+// InjectAMQP add trace context of ctx to message headers
+func InjectAMQP(ctx context.Context, headers map[string]interface{}) {
+	otel.GetTextMapPropagator().Inject(ctx, AMQPCarrier(headers))
+}
+
+// KafkaCarrier adapts Kafka message headers to propagation.TextMapCarrier
+type KafkaCarrier struct {
+	Headers *[]kafka.Header
+}
+
+func (c KafkaCarrier) Get(key string) string {
+	for _, header := range *c.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func (c KafkaCarrier) Set(key, value string) {
+	for i, header := range *c.Headers {
+		if header.Key == key {
+			(*c.Headers)[i].Value = []byte(value)
+			return
+		}
+	}
+
+	*c.Headers = append(*c.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c KafkaCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.Headers))
+	for _, header := range *c.Headers {
+		keys = append(keys, header.Key)
+	}
+
+	return keys
+}
+
+// ExtractKafka returns context with remote span of producer passed in message headers
+func ExtractKafka(ctx context.Context, headers []kafka.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, KafkaCarrier{Headers: &headers})
+}
+
+// InjectKafka add trace context of ctx to message headers
+func InjectKafka(ctx context.Context, headers *[]kafka.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, KafkaCarrier{Headers: headers})
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/WildEgor/gNotifier/internal/configs"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/WildEgor/gNotifier"
+	shutdownTimeout     = 5 * time.Second
+)
+
+// Provider installs global tracer provider exporting spans over OTLP/HTTP and W3C trace context propagator
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+func NewProvider(config *configs.TracingConfig) (*Provider, error) {
+	// trace context is propagated even if spans are not exported, so traces are not broken on this service
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !config.Enabled() {
+		log.Info("[Tracing] OTLP endpoint is not set, spans are not exported")
+		return &Provider{}, nil
+	}
+
+	opts, err := exporterOptions(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("[Tracing] Failed create OTLP exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(newSampler(config.Sampler, config.SamplerArg)),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(config.ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return &Provider{tp: tp}, nil
+}
+
+// Close flush buffered spans
+func (p *Provider) Close() {
+	if p.tp == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := p.tp.Shutdown(ctx); err != nil {
+		log.Error("[Tracing] Failed flush spans: ", err)
+	}
+}
+
+// Tracer returns tracer of service spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// End record err on span if any and end it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func exporterOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("[Tracing] Invalid OTLP endpoint %q", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+	}
+
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if u.Path != "" && u.Path != "/" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	return opts, nil
+}
+
+func newSampler(name string, ratio float64) sdktrace.Sampler {
+	switch name {
+	case configs.SamplerAlwaysOn:
+		return sdktrace.AlwaysSample()
+	case configs.SamplerAlwaysOff:
+		return sdktrace.NeverSample()
+	case configs.SamplerTraceIDRatio:
+		return sdktrace.TraceIDRatioBased(ratio)
+	case configs.SamplerParentBasedAlwaysOff:
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	case configs.SamplerParentBasedTraceIDRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	case configs.SamplerParentBasedAlwaysOn:
+	default:
+		log.Errorf("[Tracing] Unknown sampler %q, %s used", name, configs.SamplerParentBasedAlwaysOn)
+	}
+
+	return sdktrace.ParentBased(sdktrace.AlwaysSample())
+}
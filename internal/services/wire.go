@@ -0,0 +1,33 @@
+package services
+
+import (
+	"github.com/WildEgor/gNotifier/internal/services/checks"
+	"github.com/WildEgor/gNotifier/internal/services/events"
+	"github.com/WildEgor/gNotifier/internal/services/frequency"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/WildEgor/gNotifier/internal/services/opens"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	"github.com/WildEgor/gNotifier/internal/services/priority"
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	"github.com/WildEgor/gNotifier/internal/services/scheduler"
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
+	"github.com/google/wire"
+)
+
+var ServicesSet = wire.NewSet(
+	checks.NewRegistrar,
+	realtime.NewHub,
+	frequency.NewCounter,
+	frequency.NewLimiter,
+	throttle.NewThrottler,
+	pipeline.NewPipeline,
+	scheduler.NewDispatcher,
+	scheduler.NewRecurringScheduler,
+	scheduler.NewDigestFlusher,
+	metrics.NewCollector,
+	tracing.NewProvider,
+	priority.NewLanes,
+	events.NewPublisher,
+	opens.NewSigner,
+)
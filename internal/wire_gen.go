@@ -11,8 +11,20 @@ import (
 	"github.com/WildEgor/gNotifier/internal/configs"
 	handlers2 "github.com/WildEgor/gNotifier/internal/handlers/amqp"
 	"github.com/WildEgor/gNotifier/internal/handlers/http"
+	handlers3 "github.com/WildEgor/gNotifier/internal/handlers/kafka"
 	"github.com/WildEgor/gNotifier/internal/repository/mongo"
 	"github.com/WildEgor/gNotifier/internal/routers"
+	"github.com/WildEgor/gNotifier/internal/services/checks"
+	"github.com/WildEgor/gNotifier/internal/services/events"
+	"github.com/WildEgor/gNotifier/internal/services/frequency"
+	"github.com/WildEgor/gNotifier/internal/services/metrics"
+	"github.com/WildEgor/gNotifier/internal/services/opens"
+	"github.com/WildEgor/gNotifier/internal/services/pipeline"
+	"github.com/WildEgor/gNotifier/internal/services/priority"
+	"github.com/WildEgor/gNotifier/internal/services/realtime"
+	"github.com/WildEgor/gNotifier/internal/services/scheduler"
+	"github.com/WildEgor/gNotifier/internal/services/throttle"
+	"github.com/WildEgor/gNotifier/internal/services/tracing"
 	"github.com/google/wire"
 )
 
@@ -21,12 +33,19 @@ import (
 func NewServer() (*Server, error) {
 	configurator := configs.NewConfigurator()
 	appConfig := configs.NewAppConfig(configurator)
-	healthCheckAdapter, err := adapters.NewHealthCheckAdapter()
+	logConfig := configs.NewLogConfig(configurator, appConfig)
+	healthConfig := configs.NewHealthConfig(configurator)
+	breakerConfig := configs.NewBreakerConfig(configurator)
+	circuitBreakers := adapters.NewCircuitBreakers(breakerConfig)
+	healthCheckAdapter, err := adapters.NewHealthCheckAdapter(healthConfig, circuitBreakers)
 	if err != nil {
 		return nil, err
 	}
+	realtimeConfig := configs.NewRealtimeConfig(configurator)
+	signer := opens.NewSigner(appConfig)
 	mongoConfig := configs.NewMongoConfig(configurator)
-	client, err := mongo.NewMongoClient(mongoConfig)
+	tracingConfig := configs.NewTracingConfig(configurator)
+	client, err := mongo.NewMongoClient(mongoConfig, tracingConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -37,19 +56,123 @@ func NewServer() (*Server, error) {
 	}
 	storeTokenHandler := handlers.NewStoreTokenHandler(tokensRepository)
 	unsubTokenHandler := handlers.NewUnsubTokenHandler(tokensRepository)
-	httpRouter := routers.NewHTTPRouter(healthCheckAdapter, storeTokenHandler, unsubTokenHandler)
+	storeWebPushHandler := handlers.NewStoreWebPushHandler(tokensRepository)
+	inboxRepository, err := mongo.NewInboxRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	listInboxHandler := handlers.NewListInboxHandler(inboxRepository)
+	inboxUnreadHandler := handlers.NewInboxUnreadHandler(inboxRepository)
+	markInboxHandler := handlers.NewMarkInboxHandler(inboxRepository)
+	archiveInboxHandler := handlers.NewArchiveInboxHandler(inboxRepository)
+	deleteInboxHandler := handlers.NewDeleteInboxHandler(inboxRepository)
+	hub := realtime.NewHub(realtimeConfig)
+	realtimeWSHandler := handlers.NewRealtimeWSHandler(hub)
+	realtimeSSEHandler := handlers.NewRealtimeSSEHandler(hub)
+	preferencesRepository, err := mongo.NewPreferencesRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	getPreferencesHandler := handlers.NewGetPreferencesHandler(preferencesRepository)
+	updatePreferencesHandler := handlers.NewUpdatePreferencesHandler(preferencesRepository)
+	scheduleRepository, err := mongo.NewScheduleRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	listScheduleHandler := handlers.NewListScheduleHandler(scheduleRepository)
+	rescheduleHandler := handlers.NewRescheduleHandler(scheduleRepository)
+	statusesRepository, err := mongo.NewStatusesRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	cancelScheduleHandler := handlers.NewCancelScheduleHandler(scheduleRepository, statusesRepository)
+	recurringRepository, err := mongo.NewRecurringRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	createRecurringHandler := handlers.NewCreateRecurringHandler(recurringRepository)
+	listRecurringHandler := handlers.NewListRecurringHandler(recurringRepository)
+	getRecurringHandler := handlers.NewGetRecurringHandler(recurringRepository)
+	updateRecurringHandler := handlers.NewUpdateRecurringHandler(recurringRepository)
+	deleteRecurringHandler := handlers.NewDeleteRecurringHandler(recurringRepository)
+	pauseRecurringHandler := handlers.NewPauseRecurringHandler(recurringRepository)
+	resumeRecurringHandler := handlers.NewResumeRecurringHandler(recurringRepository)
+	preferencesConfig := configs.NewPreferencesConfig(configurator)
+	idempotencyConfig := configs.NewIdempotencyConfig(configurator)
+	schedulerConfig := configs.NewSchedulerConfig(configurator)
+	digestConfig := configs.NewDigestConfig(configurator)
 	smtpConfig := configs.NewSMTPConfig(configurator)
-	smtpAdapter := adapters.NewSMTPAdapter(smtpConfig)
+	routingConfig := configs.NewRoutingConfig(configurator)
+	throttleConfig := configs.NewThrottleConfig(configurator)
+	throttler := throttle.NewThrottler(throttleConfig)
+	smtpRouter := adapters.NewSMTPRouter(smtpConfig, routingConfig, circuitBreakers, throttler)
 	smsConfig := configs.NewSMSConfig(configurator)
-	smsAdapter := adapters.NewSMSAdapter(smsConfig)
+	smsRouter := adapters.NewSMSRouter(smsConfig, routingConfig, circuitBreakers, throttler)
 	fcmConfig := configs.NewFCMConfig(configurator)
 	fcmAdapter := adapters.NewFCMAdapter(fcmConfig)
+	ifcmAdapter := adapters.NewFCMBreaker(fcmAdapter, circuitBreakers)
 	apnConfig := configs.NewAPNConfig(configurator)
 	apnAdapter := adapters.NewAPNAdapter(apnConfig)
-	notifierHandler := handlers2.NewNotifierHandler(smtpAdapter, smsAdapter, fcmAdapter, apnAdapter)
+	iapnAdapter := adapters.NewAPNBreaker(apnAdapter, circuitBreakers)
+	webPushConfig := configs.NewWebPushConfig(configurator)
+	webPushAdapter := adapters.NewWebPushAdapter(webPushConfig)
+	iWebPushAdapter := adapters.NewWebPushBreaker(webPushAdapter, circuitBreakers)
+	hmsConfig := configs.NewHMSConfig(configurator)
+	hmsAdapter := adapters.NewHMSAdapter(hmsConfig)
+	ihmsAdapter := adapters.NewHMSBreaker(hmsAdapter, circuitBreakers)
+	idempotencyRepository, err := mongo.NewIdempotencyRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	digestsRepository, err := mongo.NewDigestsRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	contactsRepository, err := mongo.NewContactsRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	frequencyConfig := configs.NewFrequencyConfig(configurator)
+	countersRepository, err := mongo.NewCountersRepository(database)
+	if err != nil {
+		return nil, err
+	}
+	iCounter := frequency.NewCounter(frequencyConfig, countersRepository)
+	limiter := frequency.NewLimiter(frequencyConfig, iCounter)
+	eventsConfig := configs.NewEventsConfig(configurator)
+	publisher := events.NewPublisher(eventsConfig)
+	pipelinePipeline := pipeline.NewPipeline(preferencesConfig, idempotencyConfig, schedulerConfig, digestConfig, smtpRouter, smsRouter, ifcmAdapter, iapnAdapter, iWebPushAdapter, ihmsAdapter, tokensRepository, inboxRepository, statusesRepository, preferencesRepository, scheduleRepository, idempotencyRepository, digestsRepository, contactsRepository, hub, limiter, throttler, publisher, signer)
+	sendNotificationHandler := handlers.NewSendNotificationHandler(pipelinePipeline)
+	throttleStatsHandler := handlers.NewThrottleStatsHandler(throttler)
+	priorityConfig := configs.NewPriorityConfig(configurator)
+	lanes := priority.NewLanes(priorityConfig)
+	lanesStatsHandler := handlers.NewLanesStatsHandler(lanes)
+	pauseLaneHandler := handlers.NewPauseLaneHandler(lanes)
+	resumeLaneHandler := handlers.NewResumeLaneHandler(lanes)
+	getContactsHandler := handlers.NewGetContactsHandler(contactsRepository)
+	updateContactsHandler := handlers.NewUpdateContactsHandler(contactsRepository)
+	notificationStatusHandler := handlers.NewNotificationStatusHandler(statusesRepository)
+	notificationOpenedHandler := handlers.NewNotificationOpenedHandler(statusesRepository)
+	healthLiveHandler := handlers.NewHealthLiveHandler()
+	healthReadyHandler := handlers.NewHealthReadyHandler(healthCheckAdapter)
+	collector := metrics.NewCollector(healthCheckAdapter, tokensRepository)
+	metricsHandler := handlers.NewMetricsHandler(collector)
+	httpRouter := routers.NewHTTPRouter(healthCheckAdapter, appConfig, realtimeConfig, signer, storeTokenHandler, unsubTokenHandler, storeWebPushHandler, listInboxHandler, inboxUnreadHandler, markInboxHandler, archiveInboxHandler, deleteInboxHandler, realtimeWSHandler, realtimeSSEHandler, getPreferencesHandler, updatePreferencesHandler, listScheduleHandler, rescheduleHandler, cancelScheduleHandler, createRecurringHandler, listRecurringHandler, getRecurringHandler, updateRecurringHandler, deleteRecurringHandler, pauseRecurringHandler, resumeRecurringHandler, sendNotificationHandler, throttleStatsHandler, lanesStatsHandler, pauseLaneHandler, resumeLaneHandler, getContactsHandler, updateContactsHandler, notificationStatusHandler, notificationOpenedHandler, healthLiveHandler, healthReadyHandler, metricsHandler)
+	notifierHandler := handlers2.NewNotifierHandler(pipelinePipeline, publisher)
 	amqpConfig := configs.NewAMQPConfig(configurator)
-	amqpRouter := routers.NewAMQPRouter(notifierHandler, amqpConfig, healthCheckAdapter)
-	server := NewApp(appConfig, httpRouter, amqpRouter)
+	amqpRouter := routers.NewAMQPRouter(notifierHandler, appConfig, amqpConfig, healthCheckAdapter, hub, priorityConfig, lanes, publisher, eventsConfig, realtimeConfig)
+	handlersNotifierHandler := handlers3.NewNotifierHandler(pipelinePipeline, logConfig)
+	kafkaConfig := configs.NewKafkaConfig(configurator)
+	kafkaRouter := routers.NewKafkaRouter(handlersNotifierHandler, kafkaConfig, healthCheckAdapter)
+	dispatcher := scheduler.NewDispatcher(schedulerConfig, scheduleRepository, pipelinePipeline)
+	recurringScheduler := scheduler.NewRecurringScheduler(schedulerConfig, recurringRepository, tokensRepository, pipelinePipeline)
+	digestFlusher := scheduler.NewDigestFlusher(schedulerConfig, idempotencyConfig, digestsRepository, pipelinePipeline)
+	registrar := checks.NewRegistrar(healthCheckAdapter, healthConfig, routingConfig, fcmConfig, apnConfig, client)
+	provider, err := tracing.NewProvider(tracingConfig)
+	if err != nil {
+		return nil, err
+	}
+	server := NewApp(appConfig, logConfig, httpRouter, amqpRouter, kafkaRouter, dispatcher, recurringScheduler, digestFlusher, collector, registrar, healthCheckAdapter, hub, apnAdapter, provider, client)
 	return server, nil
 }
 